@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+
 	"github.com/spf13/cobra"
 )
 
+var addDescription string
+
 // addCmd represents the add command which creates a new alias and saves it to storage.
 // It takes a name and a command as arguments, joining multiple command arguments into a single string.
-// Example usage: aliasctl add ll "ls -la"
+// An optional description can be attached with --description.
+// Example usage: aliasctl add ll "ls -la" --description "Long listing"
 var addCmd = &cobra.Command{
 	Use:   "add [name] [command]",
 	Short: "Add a new alias",
@@ -18,8 +23,15 @@ var addCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		command := strings.Join(args[1:], " ")
+		if err := aliasctl.ValidateAliasName(name); err != nil {
+			return err
+		}
 
 		am.AddAlias(name, command)
+		am.SetOperation(aliasctl.OpAdd, fmt.Sprintf("add %s", name))
+		if addDescription != "" {
+			am.SetAliasDescription(name, addDescription)
+		}
 		if err := am.SaveAliases(); err != nil {
 			return fmt.Errorf("failed to save alias: %w\n\nTry ensuring you have write permissions to %s or specify an alternative location with 'aliasctl set-file'", err, am.AliasStore)
 		}
@@ -31,4 +43,6 @@ var addCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(addCmd)
+
+	addCmd.Flags().StringVarP(&addDescription, "description", "d", "", "Description of what the alias does")
 }
@@ -1,12 +1,28 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
 	"github.com/spf13/cobra"
 )
 
+// aiCmd represents the ai command group which holds the tools for working with the AI
+// providers themselves. The provider setup commands stay at the top level.
+// Example usage: aliasctl ai cache stats
+var aiCmd = &cobra.Command{
+	Use:   "ai",
+	Short: "Manage AI providers and their answers",
+	Long: `Tools for the AI providers used by generate, convert and suggest --ai, and
+commands that ask the AI about aliases and commands (explain, ask).`,
+}
+
 // configureOllamaCmd represents the configure-ollama command which sets up Ollama AI provider.
 // It requires the endpoint URL and model name as arguments.
 // Ollama is a local AI model server that can be used for generating and converting aliases.
@@ -20,7 +36,9 @@ var configureOllamaCmd = &cobra.Command{
 		endpoint := args[0]
 		model := args[1]
 
-		am.ConfigureOllama(endpoint, model)
+		if err := am.ConfigureOllama(endpoint, model); err != nil {
+			return err
+		}
 		fmt.Println("Ollama AI provider successfully configured")
 		return nil
 	},
@@ -40,7 +58,9 @@ var configureOpenAICmd = &cobra.Command{
 		apiKey := args[1]
 		model := args[2]
 
-		am.ConfigureOpenAI(endpoint, apiKey, model)
+		if err := am.ConfigureOpenAI(endpoint, apiKey, model); err != nil {
+			return err
+		}
 		fmt.Println("OpenAI-compatible AI provider successfully configured")
 
 		// If encryption is enabled, remind the user about the key security
@@ -68,7 +88,9 @@ var configureAnthropicCmd = &cobra.Command{
 		apiKey := args[1]
 		model := args[2]
 
-		am.ConfigureAnthropic(endpoint, apiKey, model)
+		if err := am.ConfigureAnthropic(endpoint, apiKey, model); err != nil {
+			return err
+		}
 		fmt.Println("Anthropic Claude AI provider successfully configured")
 
 		// If encryption is enabled, remind the user about the key security
@@ -82,58 +104,182 @@ var configureAnthropicCmd = &cobra.Command{
 	},
 }
 
+var (
+	configureAIName    string
+	configureAIKeyRef  string
+	configureAIHeaders map[string]string
+	configureAIOptions map[string]string
+
+	configureAIProxy         string
+	configureAICACert        string
+	configureAIClientCert    string
+	configureAIClientKey     string
+	configureAITLSMinVersion string
+
+	configureAINoModelCheck bool
+)
+
 // configureAICmd represents the configure-ai command which is a unified interface for all AI providers.
-// It takes a provider name as the first argument, followed by provider-specific arguments.
-// This provides a consistent interface for all supported AI providers.
-// Example usage: aliasctl configure-ai ollama http://localhost:11434 llama2
+// It takes a provider type as the first argument, followed by the endpoint, the model and,
+// for hosted services, the API key. The provider is named after its type unless --name is
+// given, so several providers of one type can be configured. Settings specific to a type
+// are given with --option, extra request headers with --header, and the proxy and TLS
+// settings of networks such as a company's with --proxy, --ca-cert and --client-cert.
+// Example usage: aliasctl configure-ai openai https://llm.corp.example gpt-4o --name corp --api-key-ref env:CORP_LLM_KEY
 var configureAICmd = &cobra.Command{
-	Use:   "configure-ai [provider] [arguments...]",
+	Use:   "configure-ai [type] [endpoint] [model] [api-key]",
 	Short: "Configure AI provider",
-	Long:  `Configure an AI provider for alias generation and conversion.`,
-	Args:  cobra.MinimumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		provider := args[0]
+	Long: `Configure an AI provider for alias generation and conversion.
 
-		switch provider {
-		case "ollama":
-			if len(args) < 3 {
-				return fmt.Errorf("insufficient arguments for ollama configuration\n\nUsage: aliasctl configure-ai ollama <endpoint> <model>\nExample: aliasctl configure-ai ollama http://localhost:11434 llama2")
-			}
-			am.ConfigureOllama(args[1], args[2])
-			fmt.Println("Ollama AI provider successfully configured")
+` + providerTypesHelp() + `
+
+For Azure OpenAI the endpoint is the resource URL and the model is the deployment
+name; set the API version with --option api_version=2024-10-21. The llama.cpp
+server and LM Studio only need an API key if they were started with one.
+
+Use --name to configure several providers of one type, such as a company gateway
+next to api.openai.com. Instead of the API key, --api-key-ref env:NAME or
+--api-key-ref file:PATH reads the key from an environment variable or a file
+whenever it is needed, and only the reference is saved.
+
+Behind a company proxy or gateway, --proxy sends the provider's requests through
+a proxy (or 'none' to ignore HTTPS_PROXY), --ca-cert trusts an internal
+certificate authority, --client-cert and --client-key authenticate with a client
+certificate, --tls-min-version refuses older TLS versions, and --header adds
+request headers.
 
-		case "openai":
-			if len(args) < 4 {
-				return fmt.Errorf("insufficient arguments for OpenAI configuration\n\nUsage: aliasctl configure-ai openai <endpoint> <model> <api-key>\nExample: aliasctl configure-ai openai https://api.openai.com gpt-3.5-turbo YOUR_API_KEY")
+When run in a terminal, the model is looked up in the models the provider offers
+(see 'aliasctl ai models'); if it is not there, you can pick one of them instead.`,
+	Args: cobra.RangeArgs(1, 4),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		providerType, ok := findProviderType(args[0])
+		if !ok {
+			return fmt.Errorf("unsupported AI provider '%s'\n\n%s\n\nExamples:\n  aliasctl configure-ai ollama http://localhost:11434 llama2\n  aliasctl configure-ai openai https://api.openai.com gpt-4o-mini YOUR_API_KEY", args[0], providerTypesHelp())
+		}
+		if len(args) < 3 || (providerType.NeedsAPIKey && len(args) < 4 && configureAIKeyRef == "") {
+			usage := fmt.Sprintf("aliasctl configure-ai %s <endpoint> <model>", providerType.Name)
+			if providerType.NeedsAPIKey {
+				usage += " <api-key>"
+			} else {
+				usage += " [api-key]"
 			}
-			am.ConfigureOpenAI(args[1], args[3], args[2])
-			fmt.Println("OpenAI-compatible AI provider successfully configured")
+			return fmt.Errorf("insufficient arguments for %s configuration\n\nUsage: %s", providerType.Name, usage)
+		}
 
-		case "anthropic":
-			if len(args) < 4 {
-				return fmt.Errorf("insufficient arguments for Anthropic configuration\n\nUsage: aliasctl configure-ai anthropic <endpoint> <model> <api-key>\nExample: aliasctl configure-ai anthropic https://api.anthropic.com claude-2 YOUR_API_KEY")
+		if len(args) == 4 && configureAIKeyRef != "" {
+			return fmt.Errorf("give either an API key or --api-key-ref, not both")
+		}
+
+		name := configureAIName
+		if name == "" {
+			name = providerType.Name
+		}
+		provider := aliasctl.ProviderConfig{
+			Type:          providerType.Name,
+			Endpoint:      args[1],
+			Model:         args[2],
+			APIKeyRef:     configureAIKeyRef,
+			Headers:       configureAIHeaders,
+			Options:       configureAIOptions,
+			Proxy:         configureAIProxy,
+			CACert:        configureAICACert,
+			ClientCert:    configureAIClientCert,
+			ClientKey:     configureAIClientKey,
+			TLSMinVersion: configureAITLSMinVersion,
+		}
+		apiKey := ""
+		if len(args) == 4 {
+			apiKey = args[3]
+			provider.APIKey = apiKey
+		}
+
+		if err := am.ValidateProviderConfig(name, provider); err != nil {
+			return err
+		}
+
+		// Offer the provider's models if it does not have the chosen one
+		if !configureAINoModelCheck && stdinIsTerminal() {
+			ctx, stop := interruptContext(cmd)
+			models, err := am.ListAIModelsFor(ctx, name, provider)
+			stop()
+			switch {
+			case errors.Is(err, ai.ErrModelsUnsupported):
+			case err != nil:
+				fmt.Printf("Warning: could not check model '%s': %v\n", provider.Model, err)
+			case !aliasctl.AIModelAvailable(models, provider.Model) && len(models) > 0:
+				provider.Model = chooseModel(provider.Model, models)
 			}
-			am.ConfigureAnthropic(args[1], args[3], args[2])
-			fmt.Println("Anthropic Claude AI provider successfully configured")
+		}
 
-		default:
-			return fmt.Errorf("unsupported AI provider '%s'\n\nSupported providers: ollama, openai, anthropic\n\nExamples:\n  aliasctl configure-ai ollama http://localhost:11434 llama2\n  aliasctl configure-ai openai https://api.openai.com gpt-3.5-turbo YOUR_API_KEY\n  aliasctl configure-ai anthropic https://api.anthropic.com claude-2 YOUR_API_KEY", provider)
+		if err := am.ConfigureProvider(name, provider); err != nil {
+			return err
+		}
+		if name != providerType.Name {
+			fmt.Printf("%s AI provider '%s' successfully configured\n", providerType.Title, name)
+		} else {
+			fmt.Printf("%s AI provider successfully configured\n", providerType.Title)
 		}
 
+		// If encryption is enabled, remind the user about the key security
+		if apiKey != "" {
+			if am.EncryptionUsed {
+				fmt.Println("API key will be encrypted using the key stored at:", am.EncryptionKey)
+				fmt.Println("WARNING: Keep this key file secure as it's needed to decrypt your API keys.")
+			} else {
+				fmt.Println("Warning: API key is stored in plaintext. Use 'aliasctl encrypt-api-keys' to encrypt it.")
+			}
+		}
 		return nil
 	},
 }
 
+// findProviderType returns the registered AI provider type with the given name.
+func findProviderType(name string) (aliasctl.AIProviderType, bool) {
+	for _, providerType := range aliasctl.AIProviderTypes() {
+		if providerType.Name == name {
+			return providerType, true
+		}
+	}
+	return aliasctl.AIProviderType{}, false
+}
+
+// providerTypesHelp lists the registered AI provider types for help and error messages.
+func providerTypesHelp() string {
+	var help strings.Builder
+	help.WriteString("Supported provider types:")
+	for _, providerType := range aliasctl.AIProviderTypes() {
+		var notes []string
+		if providerType.DefaultEndpoint != "" {
+			notes = append(notes, providerType.DefaultEndpoint)
+		}
+		if providerType.NeedsAPIKey {
+			notes = append(notes, "needs an API key")
+		}
+		fmt.Fprintf(&help, "\n  %-13s %s", providerType.Name, providerType.Description)
+		if len(notes) > 0 {
+			fmt.Fprintf(&help, " (%s)", strings.Join(notes, ", "))
+		}
+	}
+	return help.String()
+}
+
+var listProvidersOutput string
+
 // listProvidersCmd represents the list-providers command which shows all configured AI providers.
-// It lists the names of all AI providers that have been set up and are available for use.
+// It lists the name, type, endpoint and model of every AI provider that has been set up,
+// marking the default one. The --output flag selects a table or a structured format.
 // The command will return an error if no providers are configured.
-// Example usage: aliasctl list-providers
+// Example usage: aliasctl list-providers --output json
 var listProvidersCmd = &cobra.Command{
 	Use:   "list-providers",
 	Short: "List all configured AI providers",
 	Long:  `List all AI providers that have been configured.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		providers := am.GetAvailableProviders()
+		if err := validateOutputFormat(listProvidersOutput); err != nil {
+			return err
+		}
+
+		providers := am.ProviderDetails()
 		if len(providers) == 0 {
 			return fmt.Errorf("no AI providers are configured\n\nTo configure a provider, use one of:\n" +
 				"  aliasctl configure-ollama <endpoint> <model>\n" +
@@ -142,15 +288,30 @@ var listProvidersCmd = &cobra.Command{
 				"Example for Ollama: aliasctl configure-ollama http://localhost:11434 llama2")
 		}
 
-		fmt.Println("Configured AI providers:")
+		rows := make([][]string, 0, len(providers))
 		for _, provider := range providers {
-			fmt.Println("- " + provider)
+			isDefault := ""
+			if provider.Default {
+				isDefault = "yes"
+			}
+			fallback := ""
+			if provider.Fallback > 0 {
+				fallback = strconv.Itoa(provider.Fallback)
+			}
+			rows = append(rows, []string{provider.Name, provider.Type, provider.Endpoint, provider.Model, provider.Timeout, isDefault, fallback})
 		}
-		return nil
+		data := struct {
+			Providers []aliasctl.ProviderDetails `json:"providers" yaml:"providers" toml:"providers"`
+		}{providers}
+		return writeOutput(os.Stdout, listProvidersOutput, data, []string{"NAME", "TYPE", "ENDPOINT", "MODEL", "TIMEOUT", "DEFAULT", "FALLBACK"}, rows)
 	},
 }
 
-var generateProvider string
+var (
+	generateProvider string
+	generateNoStream bool
+	generateNoCache  bool
+)
 
 // generateCmd represents the generate command which uses AI to suggest an alias for a shell command.
 // It takes a shell command as an argument and uses the configured AI provider to generate a suitable alias.
@@ -159,8 +320,11 @@ var generateProvider string
 var generateCmd = &cobra.Command{
 	Use:   "generate [command]",
 	Short: "Generate alias suggestion for a command",
-	Long:  `Use AI to generate an alias suggestion for a shell command.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Use AI to generate an alias suggestion for a shell command.
+
+When stderr is a terminal the response is shown as it arrives; use --no-stream
+to wait for the complete response instead. Press Ctrl-C to abort the request.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		shellCommand := args[0]
 
@@ -172,43 +336,31 @@ var generateCmd = &cobra.Command{
 				"Example: aliasctl configure-ollama http://localhost:11434 llama2")
 		}
 
-		aliasCommand, err := am.GenerateAlias(shellCommand, generateProvider)
-		if err != nil {
-			// Check if it's a network-related error
-			if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "no such host") {
-				return fmt.Errorf("failed to connect to AI provider: %w\n\nMake sure the AI service is running and accessible. If using Ollama, ensure it's started with 'ollama serve'", err)
-			}
-			return fmt.Errorf("failed to generate alias: %w\n\nCheck that your API key is valid and the AI service is available", err)
+		if generateNoCache {
+			am.SkipAICache()
 		}
 
-		fmt.Printf("Generated alias suggestion: %s\n", aliasCommand)
-
-		// Parse the alias name and command
-		aliasName, aliasCmd := parseAliasDefinition(aliasCommand, string(am.Shell))
-		if aliasName == "" || aliasCmd == "" {
-			return fmt.Errorf("failed to parse the generated alias definition: %s", aliasCommand)
+		stream := aiStream(generateNoStream)
+		ctx, stop := interruptContext(cmd)
+		generated, risks, err := am.GenerateAlias(ctx, shellCommand, generateProvider, stream)
+		stop()
+		if stream != nil {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return aiRequestError("generate alias", err)
 		}
 
-		// Ask if user wants to use suggested name or provide a different one
-		fmt.Printf("Use suggested alias name '%s'? [Y/n/custom name]: ", aliasName)
-		var nameResponse string
-		fmt.Scanln(&nameResponse)
-
-		nameResponse = strings.TrimSpace(nameResponse)
-		if nameResponse != "" && strings.ToLower(nameResponse) != "y" && strings.ToLower(nameResponse) != "yes" {
-			// If response isn't yes/y and isn't empty, use the response as the custom name
-			if strings.ToLower(nameResponse) != "n" && strings.ToLower(nameResponse) != "no" {
-				aliasName = nameResponse
-			} else {
-				// User entered n/no, so prompt for the name explicitly
-				fmt.Print("Enter custom alias name: ")
-				fmt.Scanln(&aliasName)
-				aliasName = strings.TrimSpace(aliasName)
+		aliasName, aliasCmd := generated.Name, generated.Command
+		fmt.Printf("Generated alias suggestion (by %s%s): %s = %s\n", generated.Provider, cachedNote(generated.Cached), aliasName, aliasCmd)
+		if generated.Explanation != "" {
+			fmt.Printf("  %s\n", generated.Explanation)
+		}
+		printRiskFindings(risks)
 
-				if aliasName == "" {
-					return fmt.Errorf("alias name cannot be empty")
-				}
-			}
+		aliasName, err = chooseAliasName(aliasName)
+		if err != nil {
+			return err
 		}
 
 		// Ask if the user wants to save this alias
@@ -217,7 +369,27 @@ var generateCmd = &cobra.Command{
 		fmt.Scanln(&saveResponse)
 
 		if saveResponse == "" || strings.ToLower(saveResponse) == "y" || strings.ToLower(saveResponse) == "yes" {
+			// A command the AI rewrote is held for review instead of going straight into the shell file
+			if aliasCmd != shellCommand {
+				pending := aliasctl.PendingCommand{
+					Name:          aliasName,
+					Shell:         am.Shell,
+					Command:       aliasCmd,
+					SourceShell:   am.Shell,
+					SourceCommand: shellCommand,
+					Origin:        aliasctl.OriginGenerate,
+					Risks:         risks,
+				}
+				if err := am.AddPendingCommands([]aliasctl.PendingCommand{pending}); err != nil {
+					return fmt.Errorf("failed to queue the new alias for review: %w", err)
+				}
+				fmt.Printf("The AI changed the command (%s), so the alias is waiting for review.\n", aliasctl.ExplainCommandDiff(shellCommand, aliasCmd))
+				fmt.Println("Run 'aliasctl review' to approve, edit or reject it.")
+				return nil
+			}
+
 			am.AddAlias(aliasName, aliasCmd)
+			am.SetOperation(aliasctl.OpAIGenerate, fmt.Sprintf("ai-generate %s", aliasName))
 			if err := am.SaveAliases(); err != nil {
 				return fmt.Errorf("failed to save the new alias: %w", err)
 			}
@@ -229,14 +401,189 @@ var generateCmd = &cobra.Command{
 	},
 }
 
+// setAISafetyCmd represents the set-ai-safety command which chooses what happens to risky AI results.
+// Every command produced by AI is screened for destructive commands, sudo, pipes to shells,
+// downloads, file overwrites and programs the source command does not run. With "warn" the
+// findings are shown next to the result; with "block" results with new risks are refused.
+// Example usage: aliasctl set-ai-safety block
+var setAISafetyCmd = &cobra.Command{
+	Use:   "set-ai-safety [warn|block]",
+	Short: "Choose whether risky AI results are blocked or shown with a warning",
+	Long: `Choose what happens when a command produced by AI looks risky.
+
+Every AI result is screened for destructive commands (rm -rf, dd, mkfs, git push
+--force, ...), sudo and other privilege escalation, code piped into a shell
+(curl ... | sh), network access, redirections that replace files, and programs
+the source command does not run.
+
+  warn   show the findings next to the result (default)
+  block  refuse results with risks the source command did not already have`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{aliasctl.SafetyPolicyWarn, aliasctl.SafetyPolicyBlock},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := am.SetAISafetyPolicy(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("AI safety policy set to %s\n", args[0])
+		return nil
+	},
+}
+
+// setAIFallbackCmd represents the set-ai-fallback command which sets the providers tried in
+// order when no --provider is given. Each provider is retried on rate limits and server
+// errors before the next one is tried.
+// Example usage: aliasctl set-ai-fallback ollama anthropic openai
+var setAIFallbackCmd = &cobra.Command{
+	Use:   "set-ai-fallback [provider...]",
+	Short: "Set the AI providers tried in order until one answers",
+	Long: `Set the AI providers that generate, convert and suggest try in order when no
+--provider is given. If a provider fails, for example because it is not running,
+times out or keeps answering with rate limits or server errors, the next one is
+asked. Run without providers to use only the default provider again.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := am.SetAIFallbackChain(args); err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			fmt.Println("AI fallback chain cleared, using the default provider only")
+		} else {
+			fmt.Printf("AI providers will be tried in this order: %s\n", strings.Join(args, ", "))
+		}
+		return nil
+	},
+}
+
+// setAITimeoutCmd represents the set-ai-timeout command which sets how long a provider may
+// take to answer. The timeout covers the whole request, including a streamed response.
+// Example usage: aliasctl set-ai-timeout ollama 10m
+var setAITimeoutCmd = &cobra.Command{
+	Use:   "set-ai-timeout [provider] [duration]",
+	Short: "Set how long an AI provider may take to answer",
+	Long: `Set how long a request to an AI provider may take, from sending the prompt to
+receiving the complete response. Durations are written like 45s, 2m or 1m30s;
+use 0 to restore the default (5m for Ollama, 30s for the others).`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, err := time.ParseDuration(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid duration '%s': use a value like 45s, 2m or 1m30s", args[1])
+		}
+		if err := am.SetAITimeout(args[0], timeout); err != nil {
+			return err
+		}
+		if timeout == 0 {
+			fmt.Printf("AI timeout for %s reset to the default\n", args[0])
+		} else {
+			fmt.Printf("AI timeout for %s set to %s\n", args[0], timeout)
+		}
+		return nil
+	},
+}
+
+// aiRequestError adds advice to the error of a failed AI request, such as "generate
+// alias", depending on what went wrong.
+func aiRequestError(action string, err error) error {
+	var unsafe *aliasctl.UnsafeCommandError
+	if errors.As(err, &unsafe) {
+		return fmt.Errorf("%w\n\nRisky AI results are blocked by the safety policy. Run 'aliasctl set-ai-safety warn' to be warned instead", err)
+	}
+	var budget *aliasctl.AIBudgetError
+	if errors.As(err, &budget) {
+		return fmt.Errorf("failed to %s: %w\n\nRun 'aliasctl ai usage' to see what was spent, use a local provider with --provider, or raise the budget with 'aliasctl set-ai-budget'", action, err)
+	}
+
+	// Check if it's a network-related error
+	if strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "no such host") {
+		return fmt.Errorf("failed to connect to AI provider: %w\n\nMake sure the AI service is running and accessible. If using Ollama, ensure it's started with 'ollama serve'", err)
+	}
+	// A timeout or Ctrl-C says nothing about the API key
+	if strings.Contains(err.Error(), "timed out") || strings.Contains(err.Error(), "was canceled") {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	return fmt.Errorf("failed to %s: %w\n\nCheck that your API key is valid and the AI service is available", action, err)
+}
+
+// chooseAliasName asks whether to use the alias name suggested by the AI, and returns
+// the suggested name or the one the user enters instead.
+func chooseAliasName(aliasName string) (string, error) {
+	// Ask if user wants to use suggested name or provide a different one
+	fmt.Printf("Use suggested alias name '%s'? [Y/n/custom name]: ", aliasName)
+	var nameResponse string
+	fmt.Scanln(&nameResponse)
+
+	nameResponse = strings.TrimSpace(nameResponse)
+	if nameResponse != "" && strings.ToLower(nameResponse) != "y" && strings.ToLower(nameResponse) != "yes" {
+		// If response isn't yes/y and isn't empty, use the response as the custom name
+		if strings.ToLower(nameResponse) != "n" && strings.ToLower(nameResponse) != "no" {
+			return nameResponse, nil
+		}
+		// User entered n/no, so prompt for the name explicitly
+		fmt.Print("Enter custom alias name: ")
+		fmt.Scanln(&aliasName)
+		aliasName = strings.TrimSpace(aliasName)
+
+		if aliasName == "" {
+			return "", fmt.Errorf("alias name cannot be empty")
+		}
+	}
+	return aliasName, nil
+}
+
+// aiStream returns the function that shows an AI response as it arrives, on stderr so it
+// stays out of redirected output, or nil if the response should not be streamed.
+func aiStream(noStream bool) func(text string) {
+	if noStream || !stderrIsTerminal() {
+		return nil
+	}
+	return func(text string) { fmt.Fprint(os.Stderr, text) }
+}
+
+// cachedNote returns the note added to the provider name of an answer from the AI cache.
+func cachedNote(cached bool) string {
+	if cached {
+		return ", cached"
+	}
+	return ""
+}
+
+// printRiskFindings warns about the risks found in an AI result.
+func printRiskFindings(findings []aliasctl.RiskFinding) {
+	if len(findings) == 0 {
+		return
+	}
+	fmt.Println("Warning: review this command carefully, it looks risky:")
+	for _, finding := range findings {
+		fmt.Printf("  - %s\n", finding)
+	}
+}
+
 func init() {
+	rootCmd.AddCommand(aiCmd)
 	rootCmd.AddCommand(configureOllamaCmd)
 	rootCmd.AddCommand(configureOpenAICmd)
 	rootCmd.AddCommand(configureAnthropicCmd)
 	rootCmd.AddCommand(configureAICmd)
 	rootCmd.AddCommand(listProvidersCmd)
 	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(setAISafetyCmd)
+	rootCmd.AddCommand(setAITimeoutCmd)
+	rootCmd.AddCommand(setAIFallbackCmd)
+
+	addOutputFlag(listProvidersCmd, &listProvidersOutput)
+
+	configureAICmd.Flags().StringVar(&configureAIName, "name", "", "Name of the provider, to configure several of one type (default: the type)")
+	configureAICmd.Flags().StringVar(&configureAIKeyRef, "api-key-ref", "", "Read the API key from env:NAME or file:PATH instead of saving it")
+	configureAICmd.Flags().StringToStringVar(&configureAIHeaders, "header", nil, "Send an extra request header, as name=value (repeatable)")
+	configureAICmd.Flags().BoolVar(&configureAINoModelCheck, "no-model-check", false, "Do not check the model against the models the provider offers")
+	configureAICmd.Flags().StringToStringVar(&configureAIOptions, "option", nil, "Set an option of the provider type, as key=value (repeatable)")
+	configureAICmd.Flags().StringVar(&configureAIProxy, "proxy", "", "Send requests through this proxy URL, or 'none' to ignore HTTPS_PROXY")
+	configureAICmd.Flags().StringVar(&configureAICACert, "ca-cert", "", "Trust the certificate authorities in this PEM file besides the system's")
+	configureAICmd.Flags().StringVar(&configureAIClientCert, "client-cert", "", "Authenticate with the client certificate in this PEM file")
+	configureAICmd.Flags().StringVar(&configureAIClientKey, "client-key", "", "The PEM private key of the client certificate")
+	configureAICmd.Flags().StringVar(&configureAITLSMinVersion, "tls-min-version", "", "Refuse TLS versions older than this one (1.2 or 1.3)")
 
 	// Add provider flag to generate command
 	generateCmd.Flags().StringVarP(&generateProvider, "provider", "p", "", "Specify AI provider for generation")
+	generateCmd.Flags().BoolVar(&generateNoStream, "no-stream", false, "Wait for the complete response instead of showing it as it arrives")
+	generateCmd.Flags().BoolVar(&generateNoCache, "no-cache", false, "Ask the AI provider even if the answer is cached")
 }
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	aiExplainProvider string
+	aiExplainSave     bool
+	aiExplainNoStream bool
+	aiExplainNoCache  bool
+
+	aiAskProvider string
+	aiAskNoStream bool
+	aiAskNoCache  bool
+)
+
+// aiExplainCmd represents the ai explain command which asks the AI what an alias does.
+// The explanation covers the command, each of its options and its risks; the risks
+// aliasctl finds itself are listed after it. With --save the first line of the
+// explanation becomes the alias description.
+// Example usage: aliasctl ai explain gpr --save
+var aiExplainCmd = &cobra.Command{
+	Use:   "explain [alias]",
+	Short: "Ask the AI what an alias does",
+	Long: `Ask the AI to explain the command an alias runs in the current shell: what it
+does, what each option and argument means, and what could go wrong. The risks
+aliasctl finds in the command itself are listed after the explanation.
+
+Use --save to keep the first line of the explanation as the alias description,
+which 'aliasctl list' shows and exports write next to the alias.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if aiExplainNoCache {
+			am.SkipAICache()
+		}
+
+		stream := aiStream(aiExplainNoStream)
+		ctx, stop := interruptContext(cmd)
+		explained, risks, err := am.ExplainAlias(ctx, args[0], aiExplainProvider, stream)
+		stop()
+		if stream != nil {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return aiRequestError("explain alias", err)
+		}
+
+		fmt.Printf("%s = %s\n\n", explained.Name, explained.Command)
+		fmt.Printf("Explanation (by %s%s):\n%s\n", explained.Provider, cachedNote(explained.Cached), explained.Explanation)
+		if len(risks) > 0 {
+			fmt.Println()
+			printRiskFindings(risks)
+		}
+
+		if aiExplainSave {
+			description, err := am.SaveAIExplanation(explained.Name, explained.Explanation)
+			if err != nil {
+				return fmt.Errorf("failed to save the description: %w", err)
+			}
+			fmt.Printf("\nDescription of %s set to: %s\n", explained.Name, description)
+		}
+		return nil
+	},
+}
+
+// aiAskCmd represents the ai ask command which asks the AI for a command that does what
+// the user describes, and an alias for it. The command is screened like the results of
+// generate, and since the AI wrote all of it, the alias always waits for review.
+// Example usage: aliasctl ai ask "find large files modified today"
+var aiAskCmd = &cobra.Command{
+	Use:   "ask [description]",
+	Short: "Ask the AI for a command that does what you describe",
+	Long: `Describe what you want to do and get a command for the current shell, with an
+alias name for it. The command is checked for risks like the results of generate;
+with 'aliasctl set-ai-safety block' any risky command is refused, since there is
+no original command to compare it with.
+
+A saved alias waits for review ('aliasctl review') before it reaches your shell
+files, with the description as its alias description.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := strings.Join(args, " ")
+
+		if !am.AIConfigured {
+			return fmt.Errorf("AI provider not configured\n\nPlease first configure an AI provider using:\n" +
+				"  aliasctl configure-ai <type> <endpoint> <model> [api-key]")
+		}
+		if aiAskNoCache {
+			am.SkipAICache()
+		}
+
+		stream := aiStream(aiAskNoStream)
+		ctx, stop := interruptContext(cmd)
+		proposed, risks, err := am.AskCommand(ctx, request, aiAskProvider, stream)
+		stop()
+		if stream != nil {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return aiRequestError("get a command", err)
+		}
+
+		fmt.Printf("Proposed alias (by %s%s): %s = %s\n", proposed.Provider, cachedNote(proposed.Cached), proposed.Name, proposed.Command)
+		if proposed.Explanation != "" {
+			fmt.Printf("  %s\n", proposed.Explanation)
+		}
+		printRiskFindings(risks)
+
+		aliasName, err := chooseAliasName(proposed.Name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print("Save this alias? [Y/n]: ")
+		var saveResponse string
+		fmt.Scanln(&saveResponse)
+		if saveResponse != "" && strings.ToLower(saveResponse) != "y" && strings.ToLower(saveResponse) != "yes" {
+			fmt.Println("Alias not saved")
+			return nil
+		}
+
+		pending := aliasctl.PendingCommand{
+			Name:        aliasName,
+			Shell:       am.Shell,
+			Command:     proposed.Command,
+			SourceShell: am.Shell,
+			Origin:      aliasctl.OriginAsk,
+			Description: request,
+			Risks:       risks,
+		}
+		if err := am.AddPendingCommands([]aliasctl.PendingCommand{pending}); err != nil {
+			return fmt.Errorf("failed to queue the new alias for review: %w", err)
+		}
+		fmt.Printf("The command was written by the AI, so the alias %s is waiting for review.\n", aliasName)
+		fmt.Println("Run 'aliasctl review' to approve, edit or reject it.")
+		return nil
+	},
+}
+
+func init() {
+	aiCmd.AddCommand(aiExplainCmd)
+	aiCmd.AddCommand(aiAskCmd)
+
+	aiExplainCmd.Flags().StringVarP(&aiExplainProvider, "provider", "p", "", "Specify AI provider for the explanation")
+	aiExplainCmd.Flags().BoolVar(&aiExplainSave, "save", false, "Save the first line of the explanation as the alias description")
+	aiExplainCmd.Flags().BoolVar(&aiExplainNoStream, "no-stream", false, "Wait for the complete response instead of showing it as it arrives")
+	aiExplainCmd.Flags().BoolVar(&aiExplainNoCache, "no-cache", false, "Ask the AI provider even if the answer is cached")
+
+	aiAskCmd.Flags().StringVarP(&aiAskProvider, "provider", "p", "", "Specify AI provider for the command")
+	aiAskCmd.Flags().BoolVar(&aiAskNoStream, "no-stream", false, "Wait for the complete response instead of showing it as it arrives")
+	aiAskCmd.Flags().BoolVar(&aiAskNoCache, "no-cache", false, "Ask the AI provider even if the answer is cached")
+}
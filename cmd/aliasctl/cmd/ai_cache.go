@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+var aiCacheStatsOutput string
+
+// aiCacheCmd represents the ai cache command group which manages the cached AI answers.
+// Conversions and generations are cached by provider, model, prompt version and input,
+// so asking the same thing again is answered from disk.
+// Example usage: aliasctl ai cache stats
+var aiCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect or clear the cached AI answers",
+	Long: `AI answers are cached in the config directory, so converting or generating the
+same thing again is instant, free and gives the same result. Cached answers
+expire after AICacheTTL in the config file (30 days by default), and the least
+recently used are dropped when the cache outgrows AICacheMaxSize (4 MiB by default).
+
+Use --no-cache on generate, convert or suggest to ask the provider again.`,
+}
+
+// aiCacheStatsCmd represents the ai cache stats command which describes the cached answers.
+// Example usage: aliasctl ai cache stats --output json
+var aiCacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how many AI answers are cached",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(aiCacheStatsOutput); err != nil {
+			return err
+		}
+		stats, err := am.AICacheStats()
+		if err != nil {
+			return err
+		}
+
+		rows := [][]string{{
+			strconv.Itoa(stats.Entries),
+			strconv.Itoa(stats.Expired),
+			formatBytes(stats.Size),
+			formatBytes(stats.MaxSize),
+			stats.TTL,
+			strconv.Itoa(stats.Hits),
+		}}
+		if err := writeOutput(os.Stdout, aiCacheStatsOutput, stats, []string{"ENTRIES", "EXPIRED", "SIZE", "LIMIT", "TTL", "HITS"}, rows); err != nil {
+			return err
+		}
+		if aiCacheStatsOutput == "table" {
+			fmt.Printf("\nCache directory: %s\n", stats.Dir)
+		}
+		return nil
+	},
+}
+
+// aiCacheClearCmd represents the ai cache clear command which removes every cached answer.
+// Example usage: aliasctl ai cache clear
+var aiCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached AI answer",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := am.ClearAICache()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d cached AI answer(s)\n", removed)
+		return nil
+	},
+}
+
+// formatBytes formats a size in bytes for people, such as "1.5 MiB".
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, suffix := float64(size)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
+
+func init() {
+	aiCmd.AddCommand(aiCacheCmd)
+	aiCacheCmd.AddCommand(aiCacheStatsCmd)
+	aiCacheCmd.AddCommand(aiCacheClearCmd)
+
+	addOutputFlag(aiCacheStatsCmd, &aiCacheStatsOutput)
+}
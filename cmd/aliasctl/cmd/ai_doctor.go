@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	aiDoctorOutput string
+	aiModelsOutput string
+)
+
+// aiDoctorCmd represents the ai doctor command which checks every configured AI provider.
+// Each provider is asked for its models, which needs a reachable endpoint and a valid API
+// key, and the configured model is looked up among them. The command fails if any
+// provider has a problem, so it can be used in scripts.
+// Example usage: aliasctl ai doctor
+var aiDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the configured AI providers work",
+	Long: `Check every configured AI provider: the endpoint must be reachable, the API key
+accepted and the configured model available. Providers are asked for their list of
+models, so no prompt is sent; Azure OpenAI deployments cannot be listed and are sent
+a short test request instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(aiDoctorOutput); err != nil {
+			return err
+		}
+		ctx, stop := interruptContext(cmd)
+		checks := am.CheckAIProviders(ctx)
+		stop()
+		if len(checks) == 0 {
+			return fmt.Errorf("no AI providers are configured\n\nTo configure a provider, use:\n  aliasctl configure-ai <type> <endpoint> <model> [api-key]")
+		}
+
+		failed := 0
+		rows := make([][]string, 0, len(checks))
+		for _, check := range checks {
+			if check.Status != aliasctl.CheckOK {
+				failed++
+			}
+			message, _, _ := strings.Cut(check.Message, "\n")
+			rows = append(rows, []string{check.Name, check.Type, check.Model, strings.ToUpper(check.Status), check.Latency, message})
+		}
+		data := struct {
+			Providers []aliasctl.ProviderCheck `json:"providers" yaml:"providers" toml:"providers"`
+		}{checks}
+		if err := writeOutput(os.Stdout, aiDoctorOutput, data, []string{"NAME", "TYPE", "MODEL", "STATUS", "LATENCY", "MESSAGE"}, rows); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d AI provider(s) failed the check", failed, len(checks))
+		}
+		return nil
+	},
+}
+
+// aiModelsCmd represents the ai models command which lists the models a provider offers.
+// Without a provider name the default provider is asked.
+// Example usage: aliasctl ai models ollama
+var aiModelsCmd = &cobra.Command{
+	Use:   "models [provider]",
+	Short: "List the models an AI provider offers",
+	Long: `List the models available from a configured AI provider: the models pulled into
+Ollama, or the models the API key can use for hosted services. The model the
+provider is configured with is marked. Without a provider the default one is used.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(aiModelsOutput); err != nil {
+			return err
+		}
+		providerName := ""
+		if len(args) == 1 {
+			providerName = args[0]
+		}
+
+		ctx, stop := interruptContext(cmd)
+		models, err := am.ListAIModels(ctx, providerName)
+		stop()
+		if err != nil {
+			return err
+		}
+
+		configured := ""
+		for _, provider := range am.ProviderDetails() {
+			if provider.Name == providerName || (providerName == "" && provider.Default) {
+				providerName, configured = provider.Name, provider.Model
+			}
+		}
+		rows := make([][]string, 0, len(models))
+		for _, model := range models {
+			current := ""
+			if aliasctl.AIModelAvailable([]string{model}, configured) {
+				current = "yes"
+			}
+			rows = append(rows, []string{model, current})
+		}
+		data := struct {
+			Provider string   `json:"provider" yaml:"provider" toml:"provider"`
+			Models   []string `json:"models" yaml:"models" toml:"models"`
+		}{providerName, models}
+		if err := writeOutput(os.Stdout, aiModelsOutput, data, []string{"MODEL", "CONFIGURED"}, rows); err != nil {
+			return err
+		}
+		if aiModelsOutput == "table" && !aliasctl.AIModelAvailable(models, configured) {
+			fmt.Printf("\nWarning: the configured model '%s' is not among the %d models listed\n", configured, len(models))
+		}
+		return nil
+	},
+}
+
+// chooseModel offers the models of a provider when the chosen model is not among them,
+// and returns the model to configure: a listed model picked by number or name, or model
+// itself if the user keeps it.
+func chooseModel(model string, models []string) string {
+	fmt.Printf("Model '%s' is not offered by this provider. Available models:\n", model)
+	for i, available := range models {
+		fmt.Printf("  %2d) %s\n", i+1, available)
+	}
+	fmt.Printf("Choose a model by number or name, or press Enter to keep '%s': ", model)
+	response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	response = strings.TrimSpace(response)
+	if number, err := strconv.Atoi(response); err == nil && number >= 1 && number <= len(models) {
+		return models[number-1]
+	}
+	if response != "" {
+		return response
+	}
+	return model
+}
+
+func init() {
+	aiCmd.AddCommand(aiDoctorCmd)
+	aiCmd.AddCommand(aiModelsCmd)
+
+	addOutputFlag(aiDoctorCmd, &aiDoctorOutput)
+	addOutputFlag(aiModelsCmd, &aiModelsOutput)
+}
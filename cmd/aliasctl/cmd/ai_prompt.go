@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	aiPromptShowCommand string
+	aiPromptShowTo      string
+)
+
+// aiPromptCmd represents the ai prompt command group which manages the prompt templates.
+// The prompts sent to AI providers are text/template templates; a template saved in the
+// prompts directory of the config replaces the built-in one.
+// Example usage: aliasctl ai prompt edit generate
+var aiPromptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Show, edit or reset the AI prompt templates",
+	Long: `The prompts sent to AI providers are Go text/template templates, one per task:
+generate names an alias for a command, convert rewrites a command for another shell,
+explain describes an alias and ask writes a command from a description. Templates can
+use .Shell, .FromShell (convert), .Command, .Name (explain), .Request (ask), .Aliases
+(the names of the existing aliases) and .Conventions (AINamingConventions in the
+config file), and may define a "system" template for the system prompt. The response
+format is added by aliasctl, so templates only describe the task.
+
+An edited template is saved in the prompts directory of the config and replaces the
+built-in one until it is reset. Cached answers are only reused for the same template.`,
+}
+
+// aiPromptShowCmd represents the ai prompt show command which prints a prompt template.
+// Without a task it lists the templates; with --command it renders the prompt instead.
+// Example usage: aliasctl ai prompt show generate --command "git status"
+var aiPromptShowCmd = &cobra.Command{
+	Use:   "show [task]",
+	Short: "Show a prompt template, or list them",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			rows := [][]string{}
+			for _, task := range aliasctl.AIPromptTasks() {
+				template, err := am.AIPromptTemplate(task)
+				if err != nil {
+					return err
+				}
+				source := "built-in"
+				if template.Custom {
+					source = template.Path
+				}
+				rows = append(rows, []string{task, source, template.Version})
+			}
+			return writeOutput(os.Stdout, "table", nil, []string{"TASK", "TEMPLATE", "VERSION"}, rows)
+		}
+
+		if aiPromptShowCommand != "" {
+			system, prompt, err := am.RenderAIPrompt(args[0], aiPromptShowCommand, aliasctl.ShellType(aiPromptShowTo))
+			if err != nil {
+				return err
+			}
+			if system != "" {
+				fmt.Printf("--- system ---\n%s\n\n", system)
+			}
+			fmt.Printf("--- prompt ---\n%s\n", prompt)
+			return nil
+		}
+
+		template, err := am.AIPromptTemplate(args[0])
+		if err != nil {
+			return err
+		}
+		if template.Custom {
+			fmt.Printf("# %s (version %s)\n", template.Path, template.Version)
+		} else {
+			fmt.Printf("# built-in template, edit it with 'aliasctl ai prompt edit %s'\n", template.Task)
+		}
+		fmt.Print(template.Source)
+		return nil
+	},
+}
+
+// aiPromptEditCmd represents the ai prompt edit command which opens a prompt template in
+// an editor. The edited template is checked before it is saved; if it is broken, the
+// edit is kept in a temporary file.
+// Example usage: aliasctl ai prompt edit convert
+var aiPromptEditCmd = &cobra.Command{
+	Use:   "edit [task]",
+	Short: "Edit a prompt template in your editor",
+	Long: `Open a prompt template in $VISUAL or $EDITOR. The built-in template is the
+starting point the first time. The template is checked when the editor exits and
+saved in the prompts directory of the config.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		template, err := am.AIPromptTemplate(args[0])
+		if err != nil {
+			return err
+		}
+
+		temp, err := os.CreateTemp("", "aliasctl-"+template.Task+"-*.tmpl")
+		if err != nil {
+			return fmt.Errorf("failed to create a file to edit the template in: %w", err)
+		}
+		_, err = temp.WriteString(template.Source)
+		temp.Close()
+		if err != nil {
+			os.Remove(temp.Name())
+			return fmt.Errorf("failed to create a file to edit the template in: %w", err)
+		}
+
+		if err := runEditor(temp.Name()); err != nil {
+			os.Remove(temp.Name())
+			return err
+		}
+		edited, err := os.ReadFile(temp.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read the edited template %s: %w", temp.Name(), err)
+		}
+		if string(edited) == template.Source {
+			os.Remove(temp.Name())
+			fmt.Printf("No changes to the %s prompt template\n", template.Task)
+			return nil
+		}
+
+		if err := am.SaveAIPromptTemplate(template.Task, string(edited)); err != nil {
+			return fmt.Errorf("%w\n\nYour changes are kept in %s", err, temp.Name())
+		}
+		os.Remove(temp.Name())
+		fmt.Printf("Saved the %s prompt template to %s\n", template.Task, template.Path)
+		return nil
+	},
+}
+
+// aiPromptResetCmd represents the ai prompt reset command which restores built-in prompt
+// templates. Without a task every template is restored.
+// Example usage: aliasctl ai prompt reset generate
+var aiPromptResetCmd = &cobra.Command{
+	Use:   "reset [task...]",
+	Short: "Restore the built-in prompt templates",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks := args
+		if len(tasks) == 0 {
+			tasks = aliasctl.AIPromptTasks()
+		}
+		for _, task := range tasks {
+			removed, err := am.ResetAIPromptTemplate(task)
+			if err != nil {
+				return err
+			}
+			if removed {
+				fmt.Printf("Restored the built-in %s prompt template\n", task)
+			} else if len(args) > 0 {
+				fmt.Printf("The %s prompt template is already the built-in one\n", task)
+			}
+		}
+		return nil
+	},
+}
+
+// runEditor opens file in the user's editor, $VISUAL or $EDITOR, and waits for it to exit.
+func runEditor(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	parts := strings.Fields(editor)
+	command := exec.Command(parts[0], append(parts[1:], file)...)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := command.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w (set $EDITOR to the editor to use)", editor, err)
+	}
+	return nil
+}
+
+func init() {
+	aiCmd.AddCommand(aiPromptCmd)
+	aiPromptCmd.AddCommand(aiPromptShowCmd)
+	aiPromptCmd.AddCommand(aiPromptEditCmd)
+	aiPromptCmd.AddCommand(aiPromptResetCmd)
+
+	aiPromptShowCmd.Flags().StringVar(&aiPromptShowCommand, "command", "", "Render the prompt for this command (an alias name for explain, a description for ask) instead of showing the template")
+	aiPromptShowCmd.Flags().StringVar(&aiPromptShowTo, "to", "fish", "The shell to convert to when rendering the convert prompt")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	aiUsageMonth  string
+	aiUsageBy     []string
+	aiUsageOutput string
+)
+
+// aiUsageCmd represents the ai usage command which shows the tokens the AI requests of a
+// month used and what they cost, from the usage the providers report.
+// Example usage: aliasctl ai usage --by command,model
+var aiUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show the tokens used by AI requests and their estimated cost",
+	Long: `Show the tokens used by the AI requests of a month, as reported by the
+providers, and their estimated cost. Requests are grouped by the command that
+sent them unless --by names other groupings: command, provider, model or day.
+
+Costs are estimated from the prices in AIPrices in the config file, in dollars
+per million tokens, so models without a price count as free. Answers from the
+AI cache cost nothing and are not counted. Set a monthly budget with
+'aliasctl set-ai-budget'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(aiUsageOutput); err != nil {
+			return err
+		}
+		if len(aiUsageBy) == 0 {
+			return fmt.Errorf("--by needs at least one of command, provider, model or day")
+		}
+		report, err := am.AIUsage(aiUsageMonth, aiUsageBy)
+		if err != nil {
+			return err
+		}
+
+		header := make([]string, 0, len(aiUsageBy)+4)
+		for _, group := range aiUsageBy {
+			header = append(header, strings.ToUpper(group))
+		}
+		header = append(header, "REQUESTS", "INPUT", "OUTPUT", "COST")
+		var rows [][]string
+		for _, entry := range report.Entries {
+			var row []string
+			for _, group := range aiUsageBy {
+				switch group {
+				case aliasctl.UsageByCommand:
+					row = append(row, entry.Command)
+				case aliasctl.UsageByProvider:
+					row = append(row, entry.Provider)
+				case aliasctl.UsageByModel:
+					row = append(row, entry.Model)
+				case aliasctl.UsageByDay:
+					row = append(row, entry.Day)
+				}
+			}
+			rows = append(rows, append(row, usageColumns(entry)...))
+		}
+		if aiUsageOutput != "table" {
+			return writeOutput(os.Stdout, aiUsageOutput, report, header, rows)
+		}
+
+		if len(rows) == 0 {
+			fmt.Printf("No AI requests in %s\n", report.Month)
+			return nil
+		}
+		total := make([]string, len(aiUsageBy))
+		total[0] = "TOTAL"
+		rows = append(rows, append(total, usageColumns(report.Total)...))
+		if err := writeOutput(os.Stdout, aiUsageOutput, report, header, rows); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		if report.Budget > 0 {
+			fmt.Printf("Budget for %s: %s, %s left\n", report.Month, aliasctl.FormatDollars(report.Budget), aliasctl.FormatDollars(max(report.Budget-report.Total.Cost, 0)))
+		}
+		if len(report.Unpriced) > 0 {
+			fmt.Printf("No price is set for %s, so their requests count as free.\n", strings.Join(report.Unpriced, ", "))
+			fmt.Println("Add prices to AIPrices in the config file to include them.")
+		}
+		return nil
+	},
+}
+
+// setAIBudgetCmd represents the set-ai-budget command which sets the monthly spending limit
+// for hosted AI providers.
+// Example usage: aliasctl set-ai-budget 5
+var setAIBudgetCmd = &cobra.Command{
+	Use:   "set-ai-budget [dollars]",
+	Short: "Set the monthly budget for hosted AI providers",
+	Long: `Set how many dollars a month the AI requests may cost. Once the estimated cost
+of the month's requests reaches the budget, hosted providers such as OpenAI and
+Anthropic are not asked until the next month; local providers such as Ollama
+and llama.cpp always are. Costs are estimated from the prices in AIPrices in the
+config file, see 'aliasctl ai usage'. Use 0 to remove the budget.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		budget, err := strconv.ParseFloat(strings.TrimPrefix(args[0], "$"), 64)
+		if err != nil {
+			return fmt.Errorf("invalid budget '%s': use an amount in dollars like 5 or 12.50", args[0])
+		}
+		if err := am.SetAIBudget(budget); err != nil {
+			return err
+		}
+		if budget == 0 {
+			fmt.Println("Monthly AI budget removed")
+		} else {
+			fmt.Printf("Monthly AI budget set to %s\n", aliasctl.FormatDollars(budget))
+		}
+		return nil
+	},
+}
+
+// usageColumns returns the request, token and cost columns of a usage table row.
+func usageColumns(entry aliasctl.AIUsageEntry) []string {
+	return []string{
+		strconv.Itoa(entry.Requests),
+		strconv.Itoa(entry.InputTokens),
+		strconv.Itoa(entry.OutputTokens),
+		aliasctl.FormatDollars(entry.Cost),
+	}
+}
+
+func init() {
+	aiCmd.AddCommand(aiUsageCmd)
+	rootCmd.AddCommand(setAIBudgetCmd)
+
+	aiUsageCmd.Flags().StringVar(&aiUsageMonth, "month", "", "Month to report, as 2006-01 (default: the current month)")
+	aiUsageCmd.Flags().StringSliceVar(&aiUsageBy, "by", []string{aliasctl.UsageByCommand}, "Group requests by command, provider, model or day (comma-separated)")
+	addOutputFlag(aiUsageCmd, &aiUsageOutput)
+}
@@ -4,22 +4,42 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
 	"github.com/spf13/cobra"
 )
 
+var applyVerify bool
+
 // applyCmd represents the apply command which writes aliases to the shell configuration file.
 // This command writes all managed aliases to the configured shell file, preserving any
 // other content that might be in the file. It adds a special section marked with
 // comments to identify the managed aliases section.
-// Example usage: aliasctl apply
+// With --verify, the generated aliases are first checked with the shell's own parser and
+// the configuration file is left untouched if the check fails.
+// Example usage: aliasctl apply --verify
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply aliases to shell configuration",
-	Long:  `Apply aliases to the current shell's configuration file.`,
+	Long: `Apply aliases to the current shell's configuration file.
+
+Use --verify to check the generated aliases with the shell's parser (bash -n, zsh -n,
+fish --no-execute, or the PowerShell parser) before anything is written.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Convert to absolute path for better error messages
 		absPath, _ := filepath.Abs(am.AliasFile)
 
+		if applyVerify {
+			if err := am.VerifyAliases(); err != nil {
+				if _, ok := err.(*aliasctl.SyntaxCheckUnavailableError); ok {
+					fmt.Printf("Skipping syntax verification: %v\n", err)
+				} else {
+					return fmt.Errorf("syntax verification failed, %s was not modified: %w\n\nFix the alias with 'aliasctl add' or remove it with 'aliasctl remove'", absPath, err)
+				}
+			} else {
+				fmt.Printf("Syntax verified with %s\n", am.Shell)
+			}
+		}
+
 		if err := am.ApplyAliases(); err != nil {
 			return fmt.Errorf("failed to apply aliases to shell configuration at %s: %w\n\nMake sure you have write permissions to this file or set a different alias file with 'aliasctl set-file'", absPath, err)
 		}
@@ -32,4 +52,6 @@ var applyCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().BoolVar(&applyVerify, "verify", false, "Check the generated aliases with the shell's parser before writing")
 }
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleAddPrefix      string
+	bundleAddChecksum    string
+	bundleAddSignature   string
+	bundleUpdateChecksum string
+	bundleListOutput     string
+	bundleKeysOutput     string
+)
+
+// bundleCmd represents the bundle command group which manages shared alias packs.
+// Bundles are read-only layers under the personal aliases: a personal alias with the
+// same name always wins, and later bundles override earlier ones.
+// Example usage: aliasctl bundle add team https://example.com/team-aliases.toml
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Subscribe to shared alias bundles",
+	Long: `Subscribe to alias bundles shared by your team, from a URL, a file or a directory.
+
+Bundle aliases are read-only and layered under your personal aliases, so your own
+alias with the same name always wins. Each bundle is pinned to the checksum of the
+content it was fetched with and only changes when you run 'aliasctl bundle update'.
+
+Bundles signed with minisign or 'ssh-keygen -Y sign -n aliasctl' are verified
+against the keys added with 'aliasctl bundle trust'. A bundle whose signature
+does not verify is never installed or updated.`,
+}
+
+// bundleAddCmd represents the bundle add command which subscribes to a bundle.
+// Example usage: aliasctl bundle add team ./team-aliases --prefix t-
+var bundleAddCmd = &cobra.Command{
+	Use:   "add [name] [path-or-url]",
+	Short: "Subscribe to an alias bundle",
+	Long: `Subscribe to an alias bundle from a URL, a file, or a directory containing
+bundle.toml or aliases.toml. Use --prefix to namespace the bundle's aliases.
+
+A detached signature next to the bundle file (bundle.toml.minisig or
+bundle.toml.sig) is verified automatically; use --signature if it lives elsewhere.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundle, err := am.AddBundle(args[0], args[1], bundleAddPrefix, bundleAddChecksum, bundleAddSignature)
+		if err != nil {
+			return fmt.Errorf("failed to add bundle: %w", err)
+		}
+
+		fmt.Printf("Added bundle '%s' from %s\n", bundle.Name, bundle.Source)
+		if bundle.Version != "" {
+			fmt.Printf("Version:  %s\n", bundle.Version)
+		}
+		fmt.Printf("Checksum: sha256:%s\n", bundle.Checksum)
+		if bundle.SignedBy != "" {
+			fmt.Printf("Signed by: %s\n", bundle.SignedBy)
+		} else {
+			fmt.Println("Warning: bundle is not signed")
+		}
+		fmt.Println("Run 'aliasctl apply' to use the bundle's aliases in your shell.")
+		return nil
+	},
+}
+
+// bundleUpdateCmd represents the bundle update command which refreshes bundles.
+// Example usage: aliasctl bundle update team
+var bundleUpdateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Fetch the latest version of bundles",
+	Long:  `Fetch the named bundles, or all bundles, again and pin them to the new content.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if bundleUpdateChecksum != "" && len(args) != 1 {
+			return fmt.Errorf("--checksum can only be used when updating a single bundle")
+		}
+
+		updates, err := am.UpdateBundles(args, bundleUpdateChecksum)
+		if err != nil {
+			return fmt.Errorf("failed to update bundles: %w", err)
+		}
+		if len(updates) == 0 {
+			fmt.Println("No bundles to update. Add one with 'aliasctl bundle add'.")
+			return nil
+		}
+
+		failed := 0
+		for _, update := range updates {
+			switch {
+			case update.Err != nil:
+				failed++
+				fmt.Printf("%s: update failed: %v\n", update.Name, update.Err)
+			case update.Changed():
+				fmt.Printf("%s: updated %s -> %s\n", update.Name, bundleVersionLabel(update.OldVersion, update.OldChecksum), bundleVersionLabel(update.NewVersion, update.NewChecksum))
+			default:
+				fmt.Printf("%s: already up to date (%s)\n", update.Name, bundleVersionLabel(update.NewVersion, update.NewChecksum))
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d bundle(s) failed to update", failed)
+		}
+		return nil
+	},
+}
+
+// bundleListCmd represents the bundle list command which shows subscribed bundles.
+// Example usage: aliasctl bundle list --output json
+var bundleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List subscribed bundles",
+	Long:  `List subscribed bundles in layer order, lowest first.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(bundleListOutput); err != nil {
+			return err
+		}
+
+		bundles, err := am.Bundles()
+		if err != nil {
+			return err
+		}
+		if bundleListOutput == "table" && len(bundles) == 0 {
+			fmt.Println("No bundles subscribed. Add one with 'aliasctl bundle add <name> <path-or-url>'.")
+			return nil
+		}
+
+		rows := make([][]string, 0, len(bundles))
+		for _, bundle := range bundles {
+			signedBy := bundle.SignedBy
+			if signedBy == "" {
+				signedBy = "-"
+			}
+			rows = append(rows, []string{bundle.Name, bundle.Prefix, bundleVersionLabel(bundle.Version, bundle.Checksum), strconv.Itoa(bundle.Aliases), signedBy, bundle.UpdatedAt.Format("2006-01-02 15:04"), bundle.Source})
+		}
+		data := struct {
+			Bundles []aliasctl.Bundle `json:"bundles" yaml:"bundles" toml:"bundles"`
+		}{bundles}
+		return writeOutput(os.Stdout, bundleListOutput, data, []string{"NAME", "PREFIX", "VERSION", "ALIASES", "SIGNED BY", "UPDATED", "SOURCE"}, rows)
+	},
+}
+
+// bundleRemoveCmd represents the bundle remove command which unsubscribes from a bundle.
+// Example usage: aliasctl bundle remove team
+var bundleRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Unsubscribe from a bundle",
+	Long:  `Unsubscribe from a bundle. Its aliases disappear the next time you apply.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := am.RemoveBundle(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Removed bundle: %s\n", args[0])
+		fmt.Println("Run 'aliasctl apply' to update your shell configuration.")
+		return nil
+	},
+}
+
+// bundleTrustCmd represents the bundle trust command which trusts a signing key.
+// Example usage: aliasctl bundle trust ./team.pub
+var bundleTrustCmd = &cobra.Command{
+	Use:   "trust [public-key-or-file]",
+	Short: "Trust a key to sign bundles",
+	Long: `Trust a minisign public key or an SSH public key to sign bundles.
+The key can be given directly or as the path of its .pub file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := am.TrustKey(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to trust key: %w", err)
+		}
+		fmt.Printf("Trusted %s key %s\n", key.Type, key.ID)
+		return nil
+	},
+}
+
+// bundleUntrustCmd represents the bundle untrust command which removes a trusted key.
+// Example usage: aliasctl bundle untrust A4D2B1C0E9F83761
+var bundleUntrustCmd = &cobra.Command{
+	Use:   "untrust [key-id]",
+	Short: "Stop trusting a bundle signing key",
+	Long:  `Remove a key from the keys trusted to sign bundles. Installed bundles are kept.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := am.UntrustKey(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Removed trusted key: %s\n", args[0])
+		return nil
+	},
+}
+
+// bundleKeysCmd represents the bundle keys command which lists trusted signing keys.
+// Example usage: aliasctl bundle keys
+var bundleKeysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "List keys trusted to sign bundles",
+	Long:  `List the minisign and SSH public keys trusted to sign bundles.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(bundleKeysOutput); err != nil {
+			return err
+		}
+
+		keys := am.TrustedKeys()
+		if bundleKeysOutput == "table" && len(keys) == 0 {
+			fmt.Println("No trusted keys. Add one with 'aliasctl bundle trust <public-key-or-file>'.")
+			return nil
+		}
+
+		rows := make([][]string, 0, len(keys))
+		for _, key := range keys {
+			rows = append(rows, []string{key.ID, key.Type, key.Key})
+		}
+		data := struct {
+			Keys []aliasctl.TrustedKey `json:"keys" yaml:"keys" toml:"keys"`
+		}{keys}
+		return writeOutput(os.Stdout, bundleKeysOutput, data, []string{"ID", "TYPE", "KEY"}, rows)
+	},
+}
+
+// bundleVersionLabel shows a bundle's declared version with a short checksum.
+func bundleVersionLabel(version, checksum string) string {
+	short := checksum
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	if version == "" {
+		return short
+	}
+	return fmt.Sprintf("%s (%s)", version, short)
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleAddCmd)
+	bundleCmd.AddCommand(bundleUpdateCmd)
+	bundleCmd.AddCommand(bundleListCmd)
+	bundleCmd.AddCommand(bundleRemoveCmd)
+	bundleCmd.AddCommand(bundleTrustCmd)
+	bundleCmd.AddCommand(bundleUntrustCmd)
+	bundleCmd.AddCommand(bundleKeysCmd)
+
+	bundleAddCmd.Flags().StringVar(&bundleAddPrefix, "prefix", "", "Prefix prepended to every alias name in the bundle")
+	bundleAddCmd.Flags().StringVar(&bundleAddChecksum, "checksum", "", "Expected SHA-256 of the bundle content (sha256:<hex>)")
+	bundleAddCmd.Flags().StringVar(&bundleAddSignature, "signature", "", "URL or file of the bundle's detached minisign or SSH signature")
+	bundleUpdateCmd.Flags().StringVar(&bundleUpdateChecksum, "checksum", "", "Expected SHA-256 of the new bundle content (sha256:<hex>)")
+	addOutputFlag(bundleListCmd, &bundleListOutput)
+	addOutputFlag(bundleKeysCmd, &bundleKeysOutput)
+}
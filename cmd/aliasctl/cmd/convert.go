@@ -1,24 +1,55 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
 	"github.com/spf13/cobra"
 )
 
-var providerFlag string
+var (
+	providerFlag       string
+	convertAll         bool
+	convertTo          []string
+	convertConcurrency int
+	convertNoCache     bool
+)
 
 // convertCmd represents the convert command which transforms an alias to another shell format.
 // It takes an existing alias name and a target shell type as arguments.
 // The command uses AI to perform the conversion, ensuring compatibility between different shells.
+// With --all it instead fills in every missing shell command of every alias and stores them.
 // Example usage: aliasctl convert dockerup fish --provider ollama
 var convertCmd = &cobra.Command{
 	Use:   "convert [name] [target-shell]",
 	Short: "Convert an alias to another shell",
-	Long:  `Convert an alias from the current shell format to another shell format.`,
-	Args:  cobra.ExactArgs(2),
+	Long: `Convert an alias from the current shell format to another shell format.
+
+With --all, every alias missing a command for the shells given with --to (all
+shells by default) is converted. The converted commands wait for your approval
+in 'aliasctl review' before they are applied or exported. If the run is interrupted, run it again to resume: finished
+conversions are kept and only the remaining ones are sent to the AI provider.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if convertAll {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if convertNoCache {
+			am.SkipAICache()
+		}
+		if convertAll {
+			return convertAllAliases(cmd)
+		}
+		if len(convertTo) > 0 {
+			return fmt.Errorf("--to can only be used with --all")
+		}
+
 		name := args[0]
 		targetShell := args[1]
 
@@ -43,8 +74,14 @@ var convertCmd = &cobra.Command{
 				"Example: aliasctl configure-ollama http://localhost:11434 llama2")
 		}
 
-		converted, err := am.ConvertAlias(name, targetShell, providerFlag)
+		ctx, stop := interruptContext(cmd)
+		converted, risks, err := am.ConvertAlias(ctx, name, targetShell, providerFlag, nil)
+		stop()
 		if err != nil {
+			var unsafe *aliasctl.UnsafeCommandError
+			if errors.As(err, &unsafe) {
+				return fmt.Errorf("%w\n\nRisky AI results are blocked by the safety policy. Run 'aliasctl set-ai-safety warn' to be warned instead", err)
+			}
 			if strings.Contains(err.Error(), "not found") {
 				return fmt.Errorf("alias '%s' not found\n\nRun 'aliasctl list' to see available aliases", name)
 			}
@@ -54,17 +91,121 @@ var convertCmd = &cobra.Command{
 				return fmt.Errorf("failed to connect to AI provider: %w\n\nMake sure the AI service is running and accessible. If using Ollama, ensure it's started with 'ollama serve'", err)
 			}
 
+			// A timeout or Ctrl-C says nothing about the API key
+			if strings.Contains(err.Error(), "timed out") || strings.Contains(err.Error(), "was canceled") {
+				return fmt.Errorf("failed to convert alias '%s' to %s format: %w", name, targetShell, err)
+			}
 			return fmt.Errorf("failed to convert alias '%s' to %s format: %w\n\nCheck that your API key is valid and the AI service is available", name, targetShell, err)
 		}
 
-		fmt.Printf("Successfully converted alias for %s: %s\n", targetShell, converted)
+		fmt.Printf("Successfully converted alias for %s (by %s%s): %s\n", targetShell, converted.Provider, cachedNote(converted.Cached), converted.Command)
+		if converted.Explanation != "" {
+			fmt.Printf("  %s\n", converted.Explanation)
+		}
+		printRiskFindings(risks)
 		return nil
 	},
 }
 
+// convertAllAliases runs 'aliasctl convert --all', showing progress on stderr.
+func convertAllAliases(cmd *cobra.Command) error {
+	targets := aliasctl.SupportedShells
+	if len(convertTo) > 0 {
+		targets = nil
+		for _, name := range convertTo {
+			shell, err := aliasctl.ParseShellType(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			targets = append(targets, shell)
+		}
+	}
+
+	interactive := stderrIsTerminal()
+	progress := func(done, total int, result aliasctl.ConversionResult) {
+		status := "ok (" + result.Provider + ")"
+		if result.Cached {
+			status = "cached (" + result.Provider + ")"
+		}
+		if result.Err != nil {
+			status = "failed"
+		} else if result.Resumed {
+			status = "resumed"
+		}
+		if interactive {
+			fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d] %s -> %s: %s", done, total, result.Name, result.Shell, status)
+			if done == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s -> %s: %s\n", done, total, result.Name, result.Shell, status)
+		}
+	}
+
+	ctx, stop := interruptContext(cmd)
+	defer stop()
+	results, err := am.ConvertAllAliases(ctx, targets, providerFlag, convertConcurrency, progress)
+	if err != nil {
+		if interactive && ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr)
+		}
+		return fmt.Errorf("failed to convert aliases: %w", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("Nothing to convert: every alias already has a command for the selected shells")
+		return nil
+	}
+
+	var failures [][]string
+	converted, resumed, risky, blocked := 0, 0, 0, 0
+	for _, result := range results {
+		var unsafe *aliasctl.UnsafeCommandError
+		if errors.As(result.Err, &unsafe) {
+			blocked++
+		}
+		switch {
+		case result.Err != nil:
+			failures = append(failures, []string{result.Name, string(result.Shell), result.Err.Error()})
+		case result.Resumed:
+			resumed++
+			converted++
+		default:
+			converted++
+		}
+		if result.Err == nil && slices.ContainsFunc(result.Risks, func(risk aliasctl.RiskFinding) bool { return risk.Introduced }) {
+			risky++
+		}
+	}
+
+	fmt.Printf("Converted %d command(s)", converted)
+	if resumed > 0 {
+		fmt.Printf(" (%d from an interrupted run)", resumed)
+	}
+	fmt.Println()
+	if converted > 0 {
+		fmt.Println("Run 'aliasctl review' to approve them; they are not applied until approved.")
+	}
+	if risky > 0 {
+		fmt.Printf("Warning: %d converted command(s) add risks their source command did not have; check them in 'aliasctl review'.\n", risky)
+	}
+	if len(failures) > 0 {
+		fmt.Println("\nFailed conversions:")
+		writeOutput(os.Stdout, "table", nil, []string{"ALIAS", "SHELL", "ERROR"}, failures)
+		if blocked > 0 {
+			fmt.Printf("%d conversion(s) were blocked by the AI safety policy ('aliasctl set-ai-safety').\n", blocked)
+		}
+		return fmt.Errorf("%d conversion(s) failed; run 'aliasctl convert --all' again to retry them", len(failures))
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(convertCmd)
 
 	// Add provider flag
 	convertCmd.Flags().StringVarP(&providerFlag, "provider", "p", "", "Specify AI provider for conversion")
+	convertCmd.Flags().BoolVar(&convertAll, "all", false, "Fill in the missing shell commands of every alias")
+	convertCmd.Flags().StringSliceVar(&convertTo, "to", nil, "Shells to fill in with --all, comma-separated (default: all shells)")
+	convertCmd.Flags().BoolVar(&convertNoCache, "no-cache", false, "Ask the AI provider even if the answer is cached")
+	convertCmd.Flags().IntVarP(&convertConcurrency, "concurrency", "j", aliasctl.DefaultConvertConcurrency, "Number of conversions to run at once with --all")
 }
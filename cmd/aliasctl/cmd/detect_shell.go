@@ -3,34 +3,52 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
 
-// detectShellCmd represents the detect-shell command
+var detectShellOutput string
+
+// detectShellCmd represents the detect-shell command which shows the detected shell environment.
+// The --output flag selects a table or a structured format for scripting.
+// Example usage: aliasctl detect-shell --output json
 var detectShellCmd = &cobra.Command{
 	Use:   "detect-shell",
 	Short: "Show detected shell and alias file",
 	Long:  `Display information about the detected shell type and alias file path.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("Detected shell: %s\n", am.Shell)
-		fmt.Printf("Alias file: %s\n", am.AliasFile)
-		fmt.Printf("Config directory: %s\n", am.ConfigDir)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(detectShellOutput); err != nil {
+			return err
+		}
+
+		info := am.ShellInfo()
+		if detectShellOutput != "table" {
+			rows := [][]string{{string(info.Shell), info.Platform, info.AliasFile, strconv.FormatBool(info.AliasFileExists), info.ConfigDir, strconv.FormatBool(info.ConfigDirExists)}}
+			return writeOutput(os.Stdout, detectShellOutput, info, []string{"SHELL", "PLATFORM", "ALIAS_FILE", "ALIAS_FILE_EXISTS", "CONFIG_DIR", "CONFIG_DIR_EXISTS"}, rows)
+		}
+
+		fmt.Printf("Detected shell: %s\n", info.Shell)
+		fmt.Printf("Alias file: %s\n", info.AliasFile)
+		fmt.Printf("Config directory: %s\n", info.ConfigDir)
 
 		// Add additional helpful information
-		if _, err := os.Stat(am.AliasFile); os.IsNotExist(err) {
+		if !info.AliasFileExists {
 			fmt.Printf("\nNote: The alias file does not exist yet. It will be created when you add aliases.\n")
 		}
 
-		if _, err := os.Stat(am.ConfigDir); os.IsNotExist(err) {
+		if !info.ConfigDirExists {
 			fmt.Printf("\nNote: The config directory doesn't exist yet. It will be created automatically.\n")
 		}
 
 		fmt.Printf("\nTo change shell type: aliasctl set-shell <shell-type>\n")
 		fmt.Printf("To change alias file: aliasctl set-file <file-path>\n")
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(detectShellCmd)
+
+	addOutputFlag(detectShellCmd, &detectShellOutput)
 }
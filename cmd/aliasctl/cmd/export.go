@@ -3,46 +3,62 @@ package cmd
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
 	"github.com/spf13/cobra"
 )
 
-// exportCmd represents the export command which outputs aliases to a file in the format for a specific shell.
-// This is useful for sharing aliases between different environments or systems.
-// Supported shell types include: bash, zsh, fish, ksh, powershell, pwsh, and cmd.
+// exportCmd represents the export command which outputs aliases to a file in the format for a specific shell
+// or another tool. This is useful for sharing aliases between different environments or systems.
+// Targets come from the exporter registry: the shell types bash, zsh, fish, ksh, powershell, pwsh
+// and cmd, plus structured and cross-tool formats such as json, powershell-module and nix.
 // Example usage: aliasctl export fish ~/.config/fish/aliases.fish
 var exportCmd = &cobra.Command{
-	Use:   "export [shell-type] [output-file]",
+	Use:   "export [target] [output-file]",
 	Short: "Export aliases to a file",
-	Long:  `Export aliases to a file for a specific shell type.`,
-	Args:  cobra.ExactArgs(2),
+	Long: `Export aliases to a file for a specific shell type or another tool.
+
+Supported targets:`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		shellType := args[0]
+		target := args[0]
 		outputFile := args[1]
 		absPath, _ := filepath.Abs(outputFile)
 
-		supportedShells := []string{"bash", "zsh", "fish", "ksh", "powershell", "pwsh", "cmd"}
-		validShell := false
-		for _, shell := range supportedShells {
-			if shellType == shell {
-				validShell = true
-				break
-			}
-		}
-
-		if !validShell {
-			return fmt.Errorf("unsupported shell type '%s'\n\nSupported shell types: bash, zsh, fish, ksh, powershell, pwsh, cmd", shellType)
+		exporter, err := aliasctl.GetExporter(target)
+		if err != nil {
+			return err
 		}
 
-		if err := am.ExportAliases(shellType, outputFile); err != nil {
+		if err := am.ExportAliases(target, outputFile); err != nil {
 			return fmt.Errorf("failed to export aliases to %s: %w\n\nEnsure the directory exists and you have write permissions", absPath, err)
 		}
 
-		fmt.Printf("Successfully exported aliases to %s in %s format\n", outputFile, shellType)
+		fmt.Printf("Successfully exported aliases to %s in %s format\n", outputFile, target)
+		if shellExporter, ok := exporter.(aliasctl.ShellExporter); ok {
+			missing, pending, err := am.AliasesWithoutShell(shellExporter.Shell())
+			if err != nil {
+				return err
+			}
+			if len(missing) > 0 {
+				fmt.Printf("\nSkipped %d alias(es) with no %s command: %s\n", len(missing), target, strings.Join(missing, ", "))
+				if len(pending) > 0 {
+					fmt.Printf("Commands for %s are waiting for review; approve them with 'aliasctl review' and export again.\n", strings.Join(pending, ", "))
+				}
+				if len(pending) < len(missing) {
+					fmt.Printf("Run 'aliasctl convert --all --to %s' to convert them, then 'aliasctl review' to approve the results.\n", target)
+				}
+			}
+		}
 		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
+
+	for _, exporter := range aliasctl.Exporters() {
+		exportCmd.Long += fmt.Sprintf("\n  %-18s %s", exporter.Name(), exporter.Description())
+	}
 }
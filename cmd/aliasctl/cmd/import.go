@@ -2,20 +2,46 @@ package cmd
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"strings"
 
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
 	"github.com/spf13/cobra"
 )
 
+var importFrom string
+
 // importCmd represents the import command which loads aliases from an existing shell configuration file.
 // It reads the current shell's format and extracts any alias definitions it can find.
+// Given a file, it imports from another alias manager or dotfile format instead, using
+// the importer named by --from or the one that recognizes the file.
 // The command will validate that the file exists before attempting to import.
-// Example usage: aliasctl import
+// Example usage: aliasctl import ~/.oh-my-zsh/plugins/git/git.plugin.zsh
 var importCmd = &cobra.Command{
-	Use:   "import",
-	Short: "Import aliases from shell configuration",
-	Long:  `Import aliases from the current shell's configuration file.`,
+	Use:   "import [file]",
+	Short: "Import aliases from shell configuration or another alias tool",
+	Long: `Import aliases from the current shell's configuration file, or from a file
+written by another alias manager or shell. The file's format is detected unless
+--from is given.
+
+Supported formats:`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		before := maps.Clone(am.Aliases)
+		if len(args) == 1 {
+			format, err := am.ImportAliasesFrom(importFrom, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to import aliases: %w", err)
+			}
+			fmt.Printf("Aliases successfully imported from %s (%s)\n", args[0], format)
+			printImportCounts(before)
+			return nil
+		}
+		if importFrom != "" {
+			return fmt.Errorf("--from requires a file to import")
+		}
+
 		// Check if the alias file exists first
 		if _, err := os.Stat(am.AliasFile); os.IsNotExist(err) {
 			return fmt.Errorf("shell configuration file not found at '%s'\n\nUse 'aliasctl set-file' to specify a different location or create the file manually", am.AliasFile)
@@ -26,10 +52,35 @@ var importCmd = &cobra.Command{
 		}
 
 		fmt.Println("Aliases successfully imported from shell configuration")
+		printImportCounts(before)
 		return nil
 	},
 }
 
+// printImportCounts reports how many aliases an import added and overwrote.
+func printImportCounts(before map[string]aliasctl.AliasCommands) {
+	added, overwritten := 0, 0
+	for name, commands := range am.Aliases {
+		if previous, existed := before[name]; !existed {
+			added++
+		} else if previous != commands {
+			overwritten++
+		}
+	}
+
+	fmt.Printf("%d alias(es) added, %d overwritten\n", added, overwritten)
+	if added+overwritten > 0 {
+		fmt.Println("Run 'aliasctl undo' to revert the import")
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(importCmd)
+
+	var formats []string
+	for _, importer := range aliasctl.Importers() {
+		formats = append(formats, importer.Name())
+		importCmd.Long += fmt.Sprintf("\n  %-16s %s", importer.Name(), importer.Description())
+	}
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Format of the file to import ("+strings.Join(formats, ", ")+")")
 }
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyOutput string
+	historyLimit  int
+	undoForce     bool
+	redoForce     bool
+)
+
+// historyCmd represents the history command which lists the operations recorded in the journal.
+// Every change to the alias store (add, remove, import, AI generation, prune, undo, redo)
+// is recorded with the aliases it changed. With an operation number, the command shows
+// the before and after state of each alias changed by that operation.
+// Example usage: aliasctl history 12
+var historyCmd = &cobra.Command{
+	Use:   "history [id]",
+	Short: "Show the history of alias changes",
+	Long: `Show the operations recorded in the alias journal, most recent first.
+
+Pass an operation number to see exactly how each alias was changed.
+Use 'aliasctl undo' and 'aliasctl redo' to revert or reapply operations.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(historyOutput); err != nil {
+			return err
+		}
+
+		entries, err := am.Journal()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 1 {
+			id, err := parseOperationID(args[0])
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if entry.ID == id {
+					return writeJournalEntry(entry)
+				}
+			}
+			return fmt.Errorf("operation #%d not found in the journal (run 'aliasctl history' to list operations)", id)
+		}
+
+		// Most recent first
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[:historyLimit]
+		}
+
+		if historyOutput == "table" && len(entries) == 0 {
+			fmt.Println("No changes recorded yet.")
+			return nil
+		}
+
+		rows := make([][]string, 0, len(entries))
+		for _, entry := range entries {
+			status := ""
+			if entry.Undone {
+				status = "undone"
+			}
+			rows = append(rows, []string{strconv.Itoa(entry.ID), entry.Time.Format("2006-01-02 15:04:05"), entry.Operation, entry.Summary(), status})
+		}
+		data := struct {
+			Operations []aliasctl.JournalEntry `json:"operations" yaml:"operations" toml:"operations"`
+		}{entries}
+		return writeOutput(os.Stdout, historyOutput, data, []string{"ID", "TIME", "OPERATION", "CHANGES", "STATUS"}, rows)
+	},
+}
+
+// undoCmd represents the undo command which reverts an operation recorded in the journal.
+// Without an argument it reverts the most recent operation that has not been undone.
+// Operations whose aliases have been changed since are refused unless --force is given.
+// Example usage: aliasctl undo 12
+var undoCmd = &cobra.Command{
+	Use:   "undo [id]",
+	Short: "Revert a change to your aliases",
+	Long: `Revert the most recent change to your aliases, or the operation with the given
+number from 'aliasctl history'.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := 0
+		if len(args) == 1 {
+			parsed, err := parseOperationID(args[0])
+			if err != nil {
+				return err
+			}
+			id = parsed
+		}
+
+		entry, err := am.UndoOperation(id, undoForce)
+		if err != nil {
+			return journalError(err)
+		}
+		fmt.Printf("Undid operation #%d (%s): %s\n", entry.ID, entry.Operation, entry.Summary())
+		fmt.Println("Run 'aliasctl apply' to update your shell configuration.")
+		return nil
+	},
+}
+
+// redoCmd represents the redo command which reapplies an operation reverted by undo.
+// Without an argument it reapplies the most recently undone operation, as long as no
+// other change has been made since.
+// Example usage: aliasctl redo
+var redoCmd = &cobra.Command{
+	Use:   "redo [id]",
+	Short: "Reapply a change reverted by undo",
+	Long: `Reapply the most recently undone change to your aliases, or the undone operation
+with the given number from 'aliasctl history'.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := 0
+		if len(args) == 1 {
+			parsed, err := parseOperationID(args[0])
+			if err != nil {
+				return err
+			}
+			id = parsed
+		}
+
+		entry, err := am.RedoOperation(id, redoForce)
+		if err != nil {
+			return journalError(err)
+		}
+		fmt.Printf("Redid operation #%d (%s): %s\n", entry.ID, entry.Operation, entry.Summary())
+		fmt.Println("Run 'aliasctl apply' to update your shell configuration.")
+		return nil
+	},
+}
+
+// parseOperationID parses an operation number given on the command line.
+func parseOperationID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid operation number: %s (run 'aliasctl history' to list operations)", arg)
+	}
+	return id, nil
+}
+
+// journalError adds guidance to errors returned by undo and redo.
+func journalError(err error) error {
+	var conflict *aliasctl.AliasConflictError
+	if errors.As(err, &conflict) {
+		return fmt.Errorf("%w\n\nThe aliases have been modified after this operation. Use --force to overwrite them anyway", err)
+	}
+	return err
+}
+
+// writeJournalEntry prints the changes of a single operation.
+func writeJournalEntry(entry aliasctl.JournalEntry) error {
+	header := []string{"ALIAS", "SHELL", "BEFORE", "AFTER"}
+	var rows [][]string
+	for _, change := range entry.Changes {
+		var before, after map[string]string
+		if change.Before != nil {
+			before = change.Before.Shells()
+		}
+		if change.After != nil {
+			after = change.After.Shells()
+		}
+		for _, shell := range aliasctl.SupportedShells {
+			if before[string(shell)] != after[string(shell)] {
+				rows = append(rows, []string{change.Name, string(shell), before[string(shell)], after[string(shell)]})
+			}
+		}
+		if change.Before != nil && change.After != nil && change.Before.Description != change.After.Description {
+			rows = append(rows, []string{change.Name, "description", change.Before.Description, change.After.Description})
+		}
+	}
+
+	if historyOutput == "table" {
+		status := ""
+		if entry.Undone {
+			status = " (undone)"
+		}
+		fmt.Printf("Operation #%d: %s at %s%s\n", entry.ID, entry.Operation, entry.Time.Format("2006-01-02 15:04:05"), status)
+		if entry.Description != "" {
+			fmt.Printf("%s\n", entry.Description)
+		}
+		fmt.Println()
+	}
+	return writeOutput(os.Stdout, historyOutput, entry, header, rows)
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(undoCmd)
+	rootCmd.AddCommand(redoCmd)
+
+	addOutputFlag(historyCmd, &historyOutput)
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Maximum number of operations to show (0 for all)")
+	undoCmd.Flags().BoolVarP(&undoForce, "force", "f", false, "Revert even if the aliases have been changed since")
+	redoCmd.Flags().BoolVarP(&redoForce, "force", "f", false, "Reapply even if the aliases have been changed since")
+}
@@ -1,19 +1,75 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
 	"github.com/spf13/cobra"
 )
 
-// listCmd represents the list command
+var (
+	listOutput string
+	listShell  string
+)
+
+// listCmd represents the list command which shows the stored aliases.
+// By default it lists the aliases defined for the current shell; --shell selects
+// another shell, or "all" to include every alias. The --output flag selects a
+// table or a structured format (json, yaml, toml, csv) for scripting.
+// Example usage: aliasctl list --shell fish --output json
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all aliases",
-	Long:  `List all aliases defined in the system.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		am.ListAliases()
+	Long: `List all aliases defined in the system.
+
+Structured formats include every per-shell command and the alias metadata, for example:
+  aliasctl list --output json
+  aliasctl list --shell all --output csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(listOutput); err != nil {
+			return err
+		}
+
+		shell := am.Shell
+		switch listShell {
+		case "":
+		case "all":
+			shell = ""
+		default:
+			parsed, err := aliasctl.ParseShellType(listShell)
+			if err != nil {
+				return fmt.Errorf("invalid --shell value: %w\n\nUse 'all' to list aliases for every shell", err)
+			}
+			shell = parsed
+		}
+
+		list := am.ListAliases(shell)
+		if listOutput == "table" && len(list.Aliases) == 0 {
+			fmt.Println("No aliases defined.")
+			return nil
+		}
+
+		// The layer column is only useful once bundles are in play
+		header := []string{"NAME", "COMMAND", "DESCRIPTION"}
+		if am.HasBundles() {
+			header = append(header, "SOURCE")
+		}
+		rows := make([][]string, 0, len(list.Aliases))
+		for _, entry := range list.Aliases {
+			row := []string{entry.Name, entry.Command, entry.Description}
+			if am.HasBundles() {
+				row = append(row, entry.Source)
+			}
+			rows = append(rows, row)
+		}
+		return writeOutput(os.Stdout, listOutput, list, header, rows)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+
+	addOutputFlag(listCmd, &listOutput)
+	listCmd.Flags().StringVar(&listShell, "shell", "", "Only list aliases defined for this shell, or 'all' (default: current shell)")
 }
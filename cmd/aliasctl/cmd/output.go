@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormats lists the values accepted by the --output flag.
+var outputFormats = []string{"table", "json", "yaml", "toml", "csv"}
+
+// addOutputFlag registers the --output flag on a read command, storing the selection in target.
+func addOutputFlag(cmd *cobra.Command, target *string) {
+	cmd.Flags().StringVarP(target, "output", "o", "table", "Output format: "+strings.Join(outputFormats, "|"))
+}
+
+// writeOutput renders a command result in the requested format.
+// Structured formats encode data directly, while table and csv render the given
+// header and rows. TOML requires data to be a struct or map at the top level.
+func writeOutput(w io.Writer, format string, data any, header []string, rows [][]string) error {
+	switch format {
+	case "", "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "toml":
+		return toml.NewEncoder(w).Encode(data)
+	case "csv":
+		writer := csv.NewWriter(w)
+		lowered := make([]string, len(header))
+		for i, column := range header {
+			lowered[i] = strings.ToLower(column)
+		}
+		if err := writer.Write(lowered); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	default:
+		return validateOutputFormat(format)
+	}
+}
+
+// validateOutputFormat returns an error if format is not a supported --output value.
+func validateOutputFormat(format string) error {
+	for _, supported := range outputFormats {
+		if format == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format '%s'\n\nSupported formats: %s", format, strings.Join(outputFormats, ", "))
+}
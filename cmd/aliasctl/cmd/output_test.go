@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// outputList is the listing rendered by TestWriteOutput, with values that need quoting
+// or escaping in some formats.
+var outputList = aliasctl.AliasList{
+	SchemaVersion: aliasctl.AliasListSchemaVersion,
+	Platform:      "linux",
+	Shell:         aliasctl.ShellBash,
+	Aliases: []aliasctl.AliasEntry{
+		{
+			Name:        "gl",
+			Command:     `git log --format="%h %s"`,
+			Commands:    map[string]string{"bash": `git log --format="%h %s"`, "fish": `git log --format="%h %s"`},
+			Description: "Log, one line per commit",
+			Source:      "personal",
+			AIGenerated: []string{"fish"},
+		},
+		{
+			Name:     "gs",
+			Command:  "git status",
+			Commands: map[string]string{"bash": "git status"},
+			Source:   "bundle:team",
+		},
+	},
+}
+
+func TestWriteOutput(t *testing.T) {
+	header := []string{"NAME", "COMMAND", "DESCRIPTION", "SOURCE"}
+	var rows [][]string
+	for _, alias := range outputList.Aliases {
+		rows = append(rows, []string{alias.Name, alias.Command, alias.Description, alias.Source})
+	}
+
+	for _, format := range outputFormats {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeOutput(&out, format, outputList, header, rows); err != nil {
+				t.Fatalf("writeOutput: %v", err)
+			}
+			golden := filepath.Join("testdata", "list."+format)
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test with -update to create it)", err)
+			}
+			if out.String() != string(want) {
+				t.Errorf("%s output differs from %s:\n%s\nwant:\n%s", format, golden, out.String(), want)
+			}
+		})
+	}
+
+	// No format is written as a table
+	var table, empty bytes.Buffer
+	writeOutput(&table, "table", outputList, header, rows)
+	if err := writeOutput(&empty, "", outputList, header, rows); err != nil || empty.String() != table.String() {
+		t.Errorf("empty format = %q, %v; want the table", empty.String(), err)
+	}
+}
+
+func TestWriteOutputUnsupportedFormat(t *testing.T) {
+	var out bytes.Buffer
+	err := writeOutput(&out, "xml", outputList, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported output format 'xml'") {
+		t.Errorf("writeOutput(xml) error = %v, want an unsupported format error", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("writeOutput(xml) wrote %q, want nothing", out.String())
+	}
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +21,7 @@ var removeCmd = &cobra.Command{
 		name := args[0]
 
 		if am.RemoveAlias(name) {
+			am.SetOperation(aliasctl.OpRemove, fmt.Sprintf("remove %s", name))
 			if err := am.SaveAliases(); err != nil {
 				return fmt.Errorf("alias '%s' was removed from memory but could not be saved to disk: %w\n\nTry checking if you have write permissions to %s", name, err, am.AliasStore)
 			}
@@ -26,6 +29,9 @@ var removeCmd = &cobra.Command{
 			return nil
 		}
 
+		if source := am.AliasSource(name); source != "" {
+			return fmt.Errorf("alias '%s' comes from %s and is read-only\n\nAdd a personal alias with the same name to override it, or remove the bundle with 'aliasctl bundle remove'", name, source)
+		}
 		return fmt.Errorf("alias '%s' not found. Run 'aliasctl list' to see all available aliases", name)
 	},
 }
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewList      bool
+	reviewOutput    string
+	reviewAcceptAll bool
+	reviewRejectAll bool
+)
+
+// reviewCmd represents the review command which approves AI-produced commands.
+// Commands from 'aliasctl generate' and 'aliasctl convert --all' are held as pending
+// and never applied or exported until approved here.
+// Example usage: aliasctl review
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Approve, edit or reject AI-produced commands",
+	Long: `Review commands produced by AI before they reach your shell configuration.
+
+Each pending command is shown next to the command it was produced from, with
+the words that changed. Accept it, edit it, or reject it. Only approved
+commands are applied and exported.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(reviewOutput); err != nil {
+			return err
+		}
+		if reviewAcceptAll && reviewRejectAll {
+			return fmt.Errorf("--accept-all and --reject-all cannot be used together")
+		}
+
+		pending, err := am.PendingCommands()
+		if err != nil {
+			return err
+		}
+
+		if reviewList {
+			if reviewOutput == "table" && len(pending) == 0 {
+				fmt.Println("No AI-produced commands waiting for review")
+				return nil
+			}
+			rows := make([][]string, 0, len(pending))
+			for _, command := range pending {
+				var rules []string
+				for _, risk := range command.Risks {
+					if !slices.Contains(rules, risk.Rule) {
+						rules = append(rules, risk.Rule)
+					}
+				}
+				source := fmt.Sprintf("%s: %s", command.SourceShell, command.SourceCommand)
+				if command.SourceCommand == "" {
+					source = fmt.Sprintf("%q", command.Description)
+				}
+				rows = append(rows, []string{command.Name, string(command.Shell), command.Command, source, command.Origin, strings.Join(rules, ", ")})
+			}
+			data := struct {
+				Pending []aliasctl.PendingCommand `json:"pending" yaml:"pending" toml:"pending"`
+			}{pending}
+			return writeOutput(os.Stdout, reviewOutput, data, []string{"NAME", "SHELL", "PROPOSED", "SOURCE", "ORIGIN", "RISKS"}, rows)
+		}
+
+		if len(pending) == 0 {
+			fmt.Println("No AI-produced commands waiting for review")
+			return nil
+		}
+
+		var decisions []aliasctl.ReviewDecision
+		switch {
+		case reviewAcceptAll, reviewRejectAll:
+			action := aliasctl.ReviewApprove
+			if reviewRejectAll {
+				action = aliasctl.ReviewReject
+			}
+			for _, command := range pending {
+				decisions = append(decisions, aliasctl.ReviewDecision{Name: command.Name, Shell: command.Shell, Action: action})
+			}
+		default:
+			decisions = reviewInteractively(pending)
+		}
+
+		if err := am.ResolvePending(decisions); err != nil {
+			return fmt.Errorf("failed to save review: %w", err)
+		}
+
+		approved, rejected := 0, 0
+		for _, decision := range decisions {
+			if decision.Action == aliasctl.ReviewApprove {
+				approved++
+			} else {
+				rejected++
+			}
+		}
+		fmt.Printf("%d approved, %d rejected, %d still pending\n", approved, rejected, len(pending)-len(decisions))
+		if approved > 0 {
+			fmt.Println("Run 'aliasctl apply' to update your shell configuration, or 'aliasctl undo' to revert.")
+		}
+		return nil
+	},
+}
+
+// reviewInteractively asks for a decision on each pending command until the user quits.
+// Skipped commands stay pending.
+func reviewInteractively(pending []aliasctl.PendingCommand) []aliasctl.ReviewDecision {
+	reader := bufio.NewReader(os.Stdin)
+	var decisions []aliasctl.ReviewDecision
+	for i, command := range pending {
+		current := am.Aliases[command.Name].ForShell(command.Shell)
+
+		fmt.Printf("\n[%d/%d] %s for %s (from %s)\n", i+1, len(pending), command.Name, command.Shell, command.Origin)
+		if command.SourceCommand != "" {
+			fmt.Printf("  %-18s %s\n", "source ("+string(command.SourceShell)+"):", command.SourceCommand)
+		} else {
+			fmt.Printf("  %-18s %s\n", "asked for:", command.Description)
+		}
+		fmt.Printf("  %-18s %s\n", "proposed ("+string(command.Shell)+"):", command.Command)
+		if current != "" {
+			fmt.Printf("  %-18s %s\n", "current:", current)
+		}
+		if command.SourceCommand != "" {
+			fmt.Printf("  %-18s %s\n", "changes:", aliasctl.ExplainCommandDiff(command.SourceCommand, command.Command))
+		}
+		for _, risk := range command.Risks {
+			fmt.Printf("  %-18s %s\n", "RISK:", risk)
+		}
+
+		for {
+			fmt.Print("Accept, edit, reject or skip? [a/e/r/S/q(uit)]: ")
+			response, err := reader.ReadString('\n')
+			if err != nil && response == "" {
+				return decisions
+			}
+
+			decision := aliasctl.ReviewDecision{Name: command.Name, Shell: command.Shell}
+			switch strings.ToLower(strings.TrimSpace(response)) {
+			case "a", "accept", "y", "yes":
+				decision.Action = aliasctl.ReviewApprove
+			case "e", "edit":
+				fmt.Printf("Command for %s: ", command.Shell)
+				edited, _ := reader.ReadString('\n')
+				if edited = strings.TrimSpace(edited); edited == "" {
+					fmt.Println("Empty command, try again")
+					continue
+				}
+				decision.Action, decision.Command = aliasctl.ReviewApprove, edited
+			case "r", "reject", "n", "no":
+				decision.Action = aliasctl.ReviewReject
+			case "", "s", "skip":
+			case "q", "quit":
+				return decisions
+			default:
+				continue
+			}
+			if decision.Action != "" {
+				decisions = append(decisions, decision)
+			}
+			break
+		}
+	}
+	return decisions
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+
+	reviewCmd.Flags().BoolVarP(&reviewList, "list", "l", false, "List pending commands without reviewing them")
+	reviewCmd.Flags().BoolVar(&reviewAcceptAll, "accept-all", false, "Approve every pending command")
+	reviewCmd.Flags().BoolVar(&reviewRejectAll, "reject-all", false, "Reject every pending command")
+	addOutputFlag(reviewCmd, &reviewOutput)
+}
@@ -44,14 +44,26 @@ var rootCmd = &cobra.Command{
 			}
 		}
 
+		// Bundles are optional layers, so a broken bundle never blocks a command
+		if err := am.LoadBundles(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+
 		return nil
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Warnings the alias manager collected along the way are printed to stderr.
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+	if am != nil {
+		for _, warning := range am.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", warning)
+		}
+	}
+	return err
 }
 
 func init() {
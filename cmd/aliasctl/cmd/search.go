@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchOutput      string
+	searchLimit       int
+	searchInteractive bool
+	searchNoFzf       bool
+	searchPrintName   bool
+	searchWidget      string
+)
+
+// searchCmd represents the search command which fuzzy-finds aliases by name, command or description.
+// Results are ranked by relevance. With --interactive, the user picks a match (through fzf
+// when it is installed, or a built-in picker otherwise) and the selected alias's command
+// is printed, which lets shell keybinding widgets insert it into the command line.
+// The --widget flag prints such a widget for the given shell.
+// Example usage: aliasctl search kube pods
+var searchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "Fuzzy search aliases",
+	Long: `Fuzzy search aliases by name, command and description.
+
+Use --interactive to pick a match and print its command. To insert a picked command
+into your command line with Ctrl-X Ctrl-A, add the widget to your shell configuration:
+  eval "$(aliasctl search --widget bash)"      # bash
+  eval "$(aliasctl search --widget zsh)"       # zsh
+  aliasctl search --widget fish | source       # fish
+  aliasctl search --widget pwsh | Invoke-Expression  # PowerShell`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if searchWidget != "" {
+			shell, err := aliasctl.ParseShellType(searchWidget)
+			if err != nil {
+				return err
+			}
+			script, err := aliasctl.SearchWidgetScript(shell)
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		}
+
+		query := strings.Join(args, " ")
+		if !searchInteractive && query == "" {
+			return fmt.Errorf("a search query is required\n\nUsage: aliasctl search <query>\nUse --interactive to browse all aliases")
+		}
+
+		results := am.SearchAliases(query)
+		if searchInteractive {
+			selected, err := pickSearchResult(results, query)
+			if err != nil {
+				return err
+			}
+			if searchPrintName {
+				fmt.Println(selected.Name)
+			} else {
+				fmt.Println(selected.Command)
+			}
+			return nil
+		}
+
+		if err := validateOutputFormat(searchOutput); err != nil {
+			return err
+		}
+		if searchLimit > 0 && len(results) > searchLimit {
+			results = results[:searchLimit]
+		}
+		if searchOutput == "table" && len(results) == 0 {
+			return fmt.Errorf("no aliases match '%s'\n\nRun 'aliasctl list' to see all available aliases", query)
+		}
+
+		rows := make([][]string, 0, len(results))
+		for _, result := range results {
+			rows = append(rows, []string{result.Name, result.Command, result.Description, strconv.Itoa(result.Score)})
+		}
+		data := struct {
+			Query   string                  `json:"query" yaml:"query" toml:"query"`
+			Results []aliasctl.SearchResult `json:"results" yaml:"results" toml:"results"`
+		}{query, results}
+		return writeOutput(os.Stdout, searchOutput, data, []string{"NAME", "COMMAND", "DESCRIPTION", "SCORE"}, rows)
+	},
+}
+
+// pickSearchResult lets the user choose one of the results.
+// It pipes the candidates through fzf when available, and otherwise falls back to
+// the built-in picker.
+func pickSearchResult(results []aliasctl.SearchResult, query string) (aliasctl.SearchResult, error) {
+	if len(results) == 0 {
+		return aliasctl.SearchResult{}, fmt.Errorf("no aliases match '%s'", query)
+	}
+
+	if !searchNoFzf {
+		if fzfPath, err := exec.LookPath("fzf"); err == nil {
+			return pickWithFzf(fzfPath, results, query)
+		}
+	}
+	return pickWithPrompt(results)
+}
+
+// pickWithFzf runs fzf over tab-separated candidates and returns the chosen result.
+func pickWithFzf(fzfPath string, results []aliasctl.SearchResult, query string) (aliasctl.SearchResult, error) {
+	var input bytes.Buffer
+	for _, result := range results {
+		fmt.Fprintf(&input, "%s\t%s\t%s\n", result.Name, result.Command, result.Description)
+	}
+
+	var output bytes.Buffer
+	fzf := exec.Command(fzfPath, "--delimiter", "\t", "--with-nth", "1..", "--tabstop", "4", "--query", query, "--prompt", "alias> ")
+	fzf.Stdin = &input
+	fzf.Stdout = &output
+	fzf.Stderr = os.Stderr
+	if err := fzf.Run(); err != nil {
+		return aliasctl.SearchResult{}, fmt.Errorf("no alias selected")
+	}
+
+	name := strings.SplitN(strings.TrimSpace(output.String()), "\t", 2)[0]
+	for _, result := range results {
+		if result.Name == name {
+			return result, nil
+		}
+	}
+	return aliasctl.SearchResult{}, fmt.Errorf("fzf returned an unknown selection: %s", name)
+}
+
+// pickWithPrompt is the built-in picker. It shows a numbered menu on stderr, so the
+// selection printed on stdout can be captured by shell widgets. Typing a number selects
+// an entry, typing text narrows the list shown further, and an empty line cancels.
+func pickWithPrompt(results []aliasctl.SearchResult) (aliasctl.SearchResult, error) {
+	reader := bufio.NewReader(os.Stdin)
+	current := results
+	for {
+		shown := current
+		if len(shown) > 20 {
+			shown = shown[:20]
+		}
+		fmt.Fprintln(os.Stderr)
+		for i, result := range shown {
+			fmt.Fprintf(os.Stderr, "%3d) %-16s %s\n", i+1, result.Name, result.Command)
+		}
+		if len(current) > len(shown) {
+			fmt.Fprintf(os.Stderr, "     ... %d more, type to narrow the list\n", len(current)-len(shown))
+		}
+		fmt.Fprint(os.Stderr, "Select a number, type to filter, or press Enter to cancel: ")
+
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return aliasctl.SearchResult{}, fmt.Errorf("no alias selected")
+		}
+		if index, convErr := strconv.Atoi(line); convErr == nil {
+			if index >= 1 && index <= len(shown) {
+				return shown[index-1], nil
+			}
+			fmt.Fprintf(os.Stderr, "Invalid selection: %d\n", index)
+			continue
+		}
+
+		filtered := am.NarrowSearch(current, line)
+		if len(filtered) == 0 {
+			fmt.Fprintf(os.Stderr, "No aliases in the list match '%s'\n", line)
+		} else {
+			current = filtered
+		}
+		if err != nil {
+			return aliasctl.SearchResult{}, fmt.Errorf("no alias selected")
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	addOutputFlag(searchCmd, &searchOutput)
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "n", 20, "Maximum number of results to show (0 for all)")
+	searchCmd.Flags().BoolVarP(&searchInteractive, "interactive", "i", false, "Pick a result interactively and print its command")
+	searchCmd.Flags().BoolVar(&searchNoFzf, "no-fzf", false, "Use the built-in picker even if fzf is installed")
+	searchCmd.Flags().BoolVar(&searchPrintName, "print-name", false, "Print the selected alias name instead of its command")
+	searchCmd.Flags().StringVar(&searchWidget, "widget", "", "Print a keybinding widget for the given shell (bash, zsh, fish, powershell, pwsh)")
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsOutput      string
+	statsHistoryFile string
+	statsShell       string
+
+	pruneDays        int
+	pruneHistoryFile string
+	pruneShell       string
+	pruneYes         bool
+	pruneDryRun      bool
+	pruneRestore     bool
+)
+
+// statsCmd represents the stats command which shows how often each alias is used.
+// It cross-references the aliases of the current shell against the shell history and
+// reports each alias's use count and, when the history has timestamps, its last use.
+// Example usage: aliasctl stats --output json
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show alias usage from shell history",
+	Long:  `Show how often and how recently each alias was used, based on your shell history.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(statsOutput); err != nil {
+			return err
+		}
+
+		history, historyFile, err := loadHistory(statsShell, statsHistoryFile)
+		if err != nil {
+			return err
+		}
+
+		usage := am.AliasUsageStats(history)
+		if statsOutput == "table" && len(usage) == 0 {
+			fmt.Printf("No aliases defined for %s.\n", am.Shell)
+			return nil
+		}
+
+		rows := make([][]string, 0, len(usage))
+		for _, stats := range usage {
+			rows = append(rows, []string{stats.Name, strconv.Itoa(stats.Count), formatLastUsed(stats), stats.Command})
+		}
+		data := struct {
+			HistoryFile string                `json:"history_file" yaml:"history_file" toml:"history_file"`
+			Aliases     []aliasctl.AliasUsage `json:"aliases" yaml:"aliases" toml:"aliases"`
+		}{historyFile, usage}
+		return writeOutput(os.Stdout, statsOutput, data, []string{"NAME", "USES", "LAST_USED", "COMMAND"}, rows)
+	},
+}
+
+// pruneCmd represents the prune command which removes aliases that are no longer used.
+// Aliases not used within --days days (or never used) are proposed for removal, one by
+// one unless --yes is given. Removed aliases are backed up in the config directory, and
+// 'aliasctl prune --restore' brings back the most recently pruned set.
+// Example usage: aliasctl prune --days 180
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove aliases unused for a number of days",
+	Long: `Propose removing aliases that have not been used for a number of days, based on
+your shell history. Each removal is confirmed interactively unless --yes is given.
+
+Pruned aliases are backed up; run 'aliasctl prune --restore' to undo the last prune.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if pruneRestore {
+			backupFile, err := am.LatestPruneBackup()
+			if err != nil {
+				return err
+			}
+			restored, skipped, err := am.RestorePrunedAliases(backupFile)
+			if err != nil {
+				return fmt.Errorf("failed to restore pruned aliases: %w", err)
+			}
+			fmt.Printf("Restored %d alias(es): %s\n", len(restored), strings.Join(restored, ", "))
+			if len(skipped) > 0 {
+				fmt.Printf("Skipped %d alias(es) that have been redefined since: %s\n", len(skipped), strings.Join(skipped, ", "))
+			}
+			return nil
+		}
+
+		history, historyFile, err := loadHistory(pruneShell, pruneHistoryFile)
+		if err != nil {
+			return err
+		}
+
+		stale := aliasctl.StaleAliases(am.AliasUsageStats(history), pruneDays, time.Now())
+		if len(stale) == 0 {
+			fmt.Printf("No aliases unused for %d days found in %s\n", pruneDays, historyFile)
+			return nil
+		}
+
+		fmt.Printf("Aliases unused for %d days (based on %s):\n", pruneDays, historyFile)
+		for _, stats := range stale {
+			fmt.Printf("  %-16s last used: %-20s %s\n", stats.Name, formatLastUsed(stats), stats.Command)
+		}
+		if pruneDryRun {
+			return nil
+		}
+
+		selected := make([]string, 0, len(stale))
+		if pruneYes {
+			for _, stats := range stale {
+				selected = append(selected, stats.Name)
+			}
+		} else {
+			reader := bufio.NewReader(os.Stdin)
+			removeAll := false
+			for _, stats := range stale {
+				if removeAll {
+					selected = append(selected, stats.Name)
+					continue
+				}
+				fmt.Printf("Remove '%s'? [y/N/a(ll)/q(uit)]: ", stats.Name)
+				response, _ := reader.ReadString('\n')
+				switch strings.ToLower(strings.TrimSpace(response)) {
+				case "y", "yes":
+					selected = append(selected, stats.Name)
+				case "a", "all":
+					removeAll = true
+					selected = append(selected, stats.Name)
+				case "q", "quit":
+					fmt.Println("Prune cancelled")
+					return nil
+				}
+			}
+		}
+
+		if len(selected) == 0 {
+			fmt.Println("No aliases removed")
+			return nil
+		}
+
+		backupFile, err := am.PruneAliases(selected)
+		if err != nil {
+			return fmt.Errorf("failed to prune aliases: %w", err)
+		}
+		fmt.Printf("Removed %d alias(es): %s\n", len(selected), strings.Join(selected, ", "))
+		fmt.Printf("Backup saved to %s\n", backupFile)
+		fmt.Println("Run 'aliasctl prune --restore' to undo, and 'aliasctl apply' to update your shell configuration.")
+		return nil
+	},
+}
+
+// formatLastUsed renders the last use of an alias for table output.
+func formatLastUsed(stats aliasctl.AliasUsage) string {
+	switch {
+	case stats.Count == 0:
+		return "never"
+	case stats.LastUsed == nil:
+		return "unknown"
+	default:
+		return stats.LastUsed.Format("2006-01-02 15:04")
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(pruneCmd)
+
+	addOutputFlag(statsCmd, &statsOutput)
+	statsCmd.Flags().StringVar(&statsHistoryFile, "history-file", "", "Path to the shell history file (default: the shell's history file)")
+	statsCmd.Flags().StringVar(&statsShell, "shell", "", "History format to read: bash, zsh or fish (default: current shell)")
+
+	pruneCmd.Flags().IntVar(&pruneDays, "days", 90, "Propose aliases not used for this many days")
+	pruneCmd.Flags().StringVar(&pruneHistoryFile, "history-file", "", "Path to the shell history file (default: the shell's history file)")
+	pruneCmd.Flags().StringVar(&pruneShell, "shell", "", "History format to read: bash, zsh or fish (default: current shell)")
+	pruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "Remove all proposed aliases without asking")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Only show the aliases that would be proposed")
+	pruneCmd.Flags().BoolVar(&pruneRestore, "restore", false, "Restore the aliases removed by the last prune")
+}
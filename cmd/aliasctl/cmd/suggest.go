@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	suggestOutput      string
+	suggestHistoryFile string
+	suggestShell       string
+	suggestMinCount    int
+	suggestMinLength   int
+	suggestLimit       int
+	suggestAI          bool
+	suggestAITop       int
+	suggestProvider    string
+	suggestNoCache     bool
+)
+
+// suggestCmd represents the suggest command which recommends aliases from shell history.
+// It parses the bash, zsh or fish history file, finds frequently typed long commands and
+// command prefixes not yet covered by an alias, and ranks them by keystrokes saved.
+// Names are generated offline; with --ai the top suggestions are named by the AI provider.
+// Example usage: aliasctl suggest --limit 5 --ai
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest aliases from shell history",
+	Long: `Analyze your shell history and suggest aliases for frequently typed commands.
+
+Suggestions are ranked by the number of keystrokes they would save. Commands that are
+already covered by an alias are skipped. Use --ai to let the configured AI provider
+name the top suggestions; otherwise names are derived from the command's initials.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(suggestOutput); err != nil {
+			return err
+		}
+
+		history, historyFile, err := loadHistory(suggestShell, suggestHistoryFile)
+		if err != nil {
+			return err
+		}
+
+		suggestions := am.SuggestAliases(history, aliasctl.SuggestOptions{
+			MinCount:  suggestMinCount,
+			MinLength: suggestMinLength,
+			Limit:     suggestLimit,
+		})
+
+		if suggestAI {
+			if !am.AIConfigured {
+				return fmt.Errorf("AI provider not configured\n\nRun without --ai to use offline naming, or configure a provider with 'aliasctl configure-ai'")
+			}
+			if suggestNoCache {
+				am.SkipAICache()
+			}
+			ctx, stop := interruptContext(cmd)
+			names := make(map[int]string)
+			for i := range suggestions {
+				if i >= suggestAITop || ctx.Err() != nil {
+					break
+				}
+				generated, _, err := am.GenerateAlias(ctx, suggestions[i].Command, suggestProvider, nil)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: AI naming failed for '%s': %v\n", suggestions[i].Command, err)
+					continue
+				}
+				names[i] = generated.Name
+			}
+			stop()
+			suggestions = am.NameSuggestions(suggestions, names)
+		}
+
+		if suggestOutput == "table" && len(suggestions) == 0 {
+			fmt.Printf("No suggestions found in %s (%d commands analyzed)\n", historyFile, len(history))
+			return nil
+		}
+
+		rows := make([][]string, 0, len(suggestions))
+		for _, suggestion := range suggestions {
+			rows = append(rows, []string{suggestion.Name, suggestion.Command, strconv.Itoa(suggestion.Count), strconv.Itoa(suggestion.KeystrokesSaved)})
+		}
+		data := struct {
+			HistoryFile string                     `json:"history_file" yaml:"history_file" toml:"history_file"`
+			Suggestions []aliasctl.AliasSuggestion `json:"suggestions" yaml:"suggestions" toml:"suggestions"`
+		}{historyFile, suggestions}
+		if err := writeOutput(os.Stdout, suggestOutput, data, []string{"NAME", "COMMAND", "COUNT", "SAVED"}, rows); err != nil {
+			return err
+		}
+
+		if suggestOutput == "table" {
+			fmt.Println("\nAdd a suggestion with: aliasctl add <name> \"<command>\"")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(suggestCmd)
+
+	addOutputFlag(suggestCmd, &suggestOutput)
+	suggestCmd.Flags().StringVar(&suggestHistoryFile, "history-file", "", "Path to the shell history file (default: the shell's history file)")
+	suggestCmd.Flags().StringVar(&suggestShell, "shell", "", "History format to read: bash, zsh or fish (default: current shell)")
+	suggestCmd.Flags().IntVar(&suggestMinCount, "min-count", 3, "Minimum number of times a command must have been typed")
+	suggestCmd.Flags().IntVar(&suggestMinLength, "min-length", 8, "Minimum command length worth aliasing")
+	suggestCmd.Flags().IntVarP(&suggestLimit, "limit", "n", 10, "Maximum number of suggestions (0 for all)")
+	suggestCmd.Flags().BoolVar(&suggestAI, "ai", false, "Use the configured AI provider to name the top suggestions")
+	suggestCmd.Flags().IntVar(&suggestAITop, "ai-top", 5, "Number of top suggestions to name with AI")
+	suggestCmd.Flags().StringVarP(&suggestProvider, "provider", "p", "", "Specify AI provider for naming")
+	suggestCmd.Flags().BoolVar(&suggestNoCache, "no-cache", false, "Ask the AI provider even if the answer is cached")
+}
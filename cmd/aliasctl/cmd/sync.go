@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncStatusOutput string
+	syncPullOurs     bool
+	syncPullTheirs   bool
+)
+
+// syncCmd represents the sync command group which shares aliases between machines via git.
+// The alias store and the non-secret AI settings are kept in a git repository in the
+// config directory and pushed to and pulled from a remote with the local git binary.
+// Example usage: aliasctl sync init git@github.com:me/aliases.git
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync aliases across machines with git",
+	Long: `Keep your aliases in a git repository and share them between machines.
+
+API keys, the shell type and the alias file location are never synced.
+Pulling merges the remote aliases with yours alias by alias, so changes made
+on different machines combine cleanly; only an alias changed differently on
+both sides is reported as a conflict.`,
+}
+
+// syncInitCmd represents the sync init command which creates the sync repository.
+// Example usage: aliasctl sync init git@github.com:me/aliases.git
+var syncInitCmd = &cobra.Command{
+	Use:   "init [remote-url]",
+	Short: "Set up the sync repository",
+	Long:  `Create the sync repository in the config directory and optionally set its remote.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remote := ""
+		if len(args) == 1 {
+			remote = args[0]
+		}
+
+		if err := am.SyncInit(remote); err != nil {
+			return fmt.Errorf("failed to set up sync: %w", err)
+		}
+
+		fmt.Printf("Sync repository ready at %s\n", am.SyncDir())
+		if remote != "" {
+			fmt.Printf("Remote: %s\n", remote)
+			fmt.Println("Run 'aliasctl sync pull' to merge aliases already on the remote, then 'aliasctl sync push'.")
+		} else {
+			fmt.Println("Add a remote with 'aliasctl sync init <remote-url>' to share your aliases.")
+		}
+		return nil
+	},
+}
+
+// syncPushCmd represents the sync push command which publishes local aliases.
+// Example usage: aliasctl sync push
+var syncPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push your aliases to the sync remote",
+	Long:  `Commit your current aliases and push them to the sync remote.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := am.SyncPush(); err != nil {
+			return fmt.Errorf("failed to push aliases: %w", err)
+		}
+		fmt.Println("Aliases pushed")
+		return nil
+	},
+}
+
+// syncPullCmd represents the sync pull command which merges remote aliases.
+// Conflicting changes abort the pull unless --ours or --theirs picks a side.
+// Example usage: aliasctl sync pull --theirs
+var syncPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Merge aliases from the sync remote",
+	Long: `Fetch the sync remote and merge its aliases into yours.
+
+If the same alias was changed differently on both sides, nothing is changed
+and the conflicts are listed. Run again with --ours to keep your versions or
+--theirs to take the remote versions. Use 'aliasctl undo' to revert a pull.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if syncPullOurs && syncPullTheirs {
+			return fmt.Errorf("--ours and --theirs cannot be used together")
+		}
+		strategy := ""
+		if syncPullOurs {
+			strategy = "ours"
+		} else if syncPullTheirs {
+			strategy = "theirs"
+		}
+
+		result, err := am.SyncPull(strategy)
+		if err != nil {
+			var conflictErr *aliasctl.SyncConflictError
+			if errors.As(err, &conflictErr) {
+				fmt.Println("Conflicting changes:")
+				printSyncConflicts(conflictErr.Conflicts)
+				return fmt.Errorf("%w\n\nNothing was changed. Run 'aliasctl sync pull --ours' to keep your versions or '--theirs' to take the remote ones", err)
+			}
+			return fmt.Errorf("failed to pull aliases: %w", err)
+		}
+
+		if result.UpToDate {
+			fmt.Println("Already up to date")
+			return nil
+		}
+		if len(result.Conflicts) > 0 {
+			fmt.Printf("Resolved %d conflict(s) using %s versions:\n", len(result.Conflicts), strategy)
+			printSyncConflicts(result.Conflicts)
+		}
+		if len(result.Changes) == 0 && !result.ConfigChanged {
+			fmt.Println("Merged remote changes; your aliases are unchanged")
+			return nil
+		}
+		if len(result.Changes) > 0 {
+			fmt.Printf("Updated %d alias(es): %s\n", len(result.Changes), aliasctl.JournalEntry{Changes: result.Changes}.Summary())
+		}
+		if result.ConfigChanged {
+			fmt.Println("Updated shared AI settings")
+		}
+		fmt.Println("Run 'aliasctl apply' to update your shell configuration, then 'aliasctl sync push' to share the merge.")
+		return nil
+	},
+}
+
+// syncStatusCmd represents the sync status command which shows what is out of sync.
+// Example usage: aliasctl sync status
+var syncStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the sync state of your aliases",
+	Long:  `Show the sync remote, local changes not yet pushed and remote changes not yet pulled.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(syncStatusOutput); err != nil {
+			return err
+		}
+
+		status, err := am.SyncStatus()
+		if err != nil {
+			return fmt.Errorf("failed to get sync status: %w", err)
+		}
+
+		if syncStatusOutput != "table" {
+			header := []string{"REMOTE", "BRANCH", "LOCAL_CHANGES", "AHEAD", "BEHIND"}
+			rows := [][]string{{status.Remote, status.Branch, status.LocalChanges, strconv.Itoa(status.Ahead), strconv.Itoa(status.Behind)}}
+			return writeOutput(os.Stdout, syncStatusOutput, status, header, rows)
+		}
+		if !status.Initialized {
+			fmt.Println("Sync is not set up. Run 'aliasctl sync init <remote-url>' to start.")
+			return nil
+		}
+
+		remote := status.Remote
+		if remote == "" {
+			remote = "(none)"
+		}
+		localChanges := status.LocalChanges
+		if localChanges == "" {
+			localChanges = "none"
+		}
+		fmt.Printf("Repository:    %s\n", status.Dir)
+		fmt.Printf("Remote:        %s\n", remote)
+		fmt.Printf("Branch:        %s\n", status.Branch)
+		fmt.Printf("Last sync:     %s\n", status.LastCommit)
+		fmt.Printf("Local changes: %s\n", localChanges)
+		if status.Remote != "" {
+			fmt.Printf("Not pushed:    %d commit(s)\n", status.Ahead)
+			fmt.Printf("Not pulled:    %d commit(s)\n", status.Behind)
+		}
+		if status.FetchError != "" {
+			fmt.Printf("Warning: could not reach the remote: %s\n", status.FetchError)
+		}
+		return nil
+	},
+}
+
+// printSyncConflicts lists sync conflicts with the local and remote values.
+func printSyncConflicts(conflicts []aliasctl.SyncConflict) {
+	header := []string{"ALIAS", "FIELD", "LOCAL", "REMOTE"}
+	rows := make([][]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		field := conflict.Field
+		if field == "" {
+			field = "-"
+		}
+		rows = append(rows, []string{conflict.Name, field, conflict.Ours, conflict.Theirs})
+	}
+	writeOutput(os.Stdout, "table", nil, header, rows)
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.AddCommand(syncInitCmd)
+	syncCmd.AddCommand(syncPushCmd)
+	syncCmd.AddCommand(syncPullCmd)
+	syncCmd.AddCommand(syncStatusCmd)
+
+	syncPullCmd.Flags().BoolVar(&syncPullOurs, "ours", false, "Resolve conflicts by keeping your versions")
+	syncPullCmd.Flags().BoolVar(&syncPullTheirs, "theirs", false, "Resolve conflicts by taking the remote versions")
+	addOutputFlag(syncStatusCmd, &syncStatusOutput)
+}
@@ -1,61 +1,58 @@
 package cmd
 
 import (
-	"strings"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl"
+	"github.com/spf13/cobra"
 )
 
-// parseAliasDefinition attempts to extract the alias name and command from a definition
-func parseAliasDefinition(definition, shellType string) (name string, command string) {
-	definition = strings.TrimSpace(definition)
-
-	switch shellType {
-	case "bash", "zsh", "fish", "ksh":
-		if strings.HasPrefix(definition, "alias ") {
-			parts := strings.SplitN(strings.TrimPrefix(definition, "alias "), "=", 2)
-			if len(parts) == 2 {
-				name = strings.TrimSpace(parts[0])
-				// Remove surrounding quotes
-				command = strings.Trim(strings.TrimSpace(parts[1]), "'\"")
-				return
-			}
-		}
-	case "powershell", "pwsh":
-		if strings.HasPrefix(definition, "Set-Alias ") {
-			parts := strings.Fields(strings.TrimPrefix(definition, "Set-Alias "))
-			if len(parts) >= 2 {
-				name = parts[0]
-				command = parts[1]
-				return
-			}
-		} else if strings.HasPrefix(definition, "function ") {
-			parts := strings.SplitN(strings.TrimPrefix(definition, "function "), " {", 2)
-			if len(parts) == 2 {
-				name = strings.TrimSpace(parts[0])
-				command = strings.TrimSpace(strings.TrimSuffix(parts[1], "}"))
-				return
-			}
-		}
-	case "cmd":
-		if strings.HasPrefix(definition, "doskey ") {
-			parts := strings.SplitN(strings.TrimPrefix(definition, "doskey "), "=", 2)
-			if len(parts) == 2 {
-				name = strings.TrimSpace(parts[0])
-				command = strings.TrimSpace(parts[1])
-				return
-			}
+// interruptContext returns the command's context, canceled on Ctrl-C so AI requests can
+// be aborted. Call stop as soon as the request is done, so Ctrl-C at a later prompt
+// exits as usual.
+func interruptContext(cmd *cobra.Command) (ctx context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(cmd.Context(), os.Interrupt)
+}
+
+// stdinIsTerminal reports whether stdin is a terminal, so the user can be asked questions.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// stderrIsTerminal reports whether stderr is a terminal, for progress and streamed output.
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// loadHistory reads the shell history used by the suggest, stats and prune commands.
+// shellName selects the history format and defaults to the current shell; historyFile
+// defaults to that shell's history file. Returns the entries and the file that was read.
+func loadHistory(shellName, historyFile string) ([]aliasctl.HistoryEntry, string, error) {
+	shell := am.Shell
+	if shellName != "" {
+		parsed, err := aliasctl.ParseShellType(shellName)
+		if err != nil {
+			return nil, "", err
 		}
+		shell = parsed
 	}
 
-	// Fallback: if we couldn't parse with the specific shell format,
-	// try to use a generic approach - look for the first space or equals
-	if strings.Contains(definition, "=") {
-		parts := strings.SplitN(definition, "=", 2)
-		if len(parts) == 2 {
-			name = strings.TrimSpace(parts[0])
-			command = strings.TrimSpace(parts[1])
-			return
+	if historyFile == "" {
+		defaultFile, err := aliasctl.DefaultHistoryFile(shell)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w\n\nUse --shell to pick a supported history format and --history-file to point to the file", err)
 		}
+		historyFile = defaultFile
 	}
 
-	return "", ""
+	history, err := aliasctl.ReadShellHistory(shell, historyFile)
+	if err != nil {
+		return nil, "", err
+	}
+	return history, historyFile, nil
 }
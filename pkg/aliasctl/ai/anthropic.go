@@ -1,71 +1,100 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 )
 
+// anthropicAliasTool is the tool Claude is made to call with the AliasResult.
+const anthropicAliasTool = "record_alias"
+
 // AnthropicProvider implements Provider for Anthropic Claude.
 type AnthropicProvider struct {
-	Endpoint string // The Anthropic endpoint URL
-	APIKey   string // The Anthropic API key
-	Model    string // The Anthropic model name
+	Endpoint  string            // The Anthropic endpoint URL
+	APIKey    string            // The Anthropic API key
+	Model     string            // The Anthropic model name
+	Timeout   time.Duration     // The time allowed for a request, DefaultTimeout if zero
+	Headers   map[string]string // Extra headers sent with every request
+	Transport Transport         // How requests reach the endpoint, such as through a proxy
+	Client    *http.Client      // The HTTP client requests are sent with, the shared client if nil
+}
+
+// Info returns the configuration of the provider.
+func (ap *AnthropicProvider) Info() ProviderInfo {
+	return ProviderInfo{Type: "anthropic", Endpoint: ap.Endpoint, APIKey: ap.APIKey, Model: ap.Model, Timeout: ap.Timeout, Headers: ap.Headers, Transport: ap.Transport, Client: ap.Client}
 }
 
-// GenerateAlias generates an alias using Anthropic Claude
-func (ap *AnthropicProvider) GenerateAlias(command, shellType string) (string, error) {
+// Complete sends a prompt and forces Claude to answer through the record_alias tool, whose
+// input is the AliasResult. A text answer is used as a fallback. With a stream function
+// the answer is streamed as server-sent events.
+func (ap *AnthropicProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
 	if err := ValidateEndpoint(ap.Endpoint); err != nil {
-		return "", err
+		return AliasResult{}, err
 	}
+	ctx, cancel := withTimeout(ctx, ap.Timeout, DefaultTimeout)
+	defer cancel()
 
 	// Check API key
 	if ap.APIKey == "" {
-		return "", fmt.Errorf("anthropic API key is empty: please configure a valid API key with 'aliasctl configure-anthropic'")
+		return AliasResult{}, fmt.Errorf("anthropic API key is empty: please configure a valid API key with 'aliasctl configure-anthropic'")
 	}
 
 	// Build the request payload
-	requestBody, err := json.Marshal(map[string]any{
+	request := map[string]any{
 		"model": ap.Model,
 		"messages": []map[string]string{
 			{
 				"role":    "user",
-				"content": GenerationPrompt(command, shellType),
+				"content": prompt.User,
 			},
 		},
+		"tools": []map[string]any{
+			{
+				"name":         anthropicAliasTool,
+				"description":  "Record the alias or converted command.",
+				"input_schema": aliasResultSchema,
+			},
+		},
+		"tool_choice": map[string]string{"type": "tool", "name": anthropicAliasTool},
 		"max_tokens":  300,
-		"temperature": 0.3, // Moderate creativity
-	})
+		"temperature": prompt.Temperature,
+		"stream":      stream != nil,
+	}
+	if prompt.System != "" {
+		request["system"] = prompt.System
+	}
+	requestBody, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to create Anthropic request: %w", err)
+		return AliasResult{}, fmt.Errorf("failed to create Anthropic request: %w", err)
 	}
 
 	// Prepare headers
-	headers := map[string]string{
+	headers := requestHeaders(map[string]string{
 		"x-api-key":         ap.APIKey,
 		"anthropic-version": "2023-06-01", // Use appropriate API version
+	}, ap.Headers)
+
+	if stream != nil {
+		return ap.streamMessage(ctx, headers, requestBody, stream)
 	}
 
-	respBody, err := MakeAPIRequest("POST", ap.Endpoint+"/v1/messages", headers, requestBody)
+	respBody, err := MakeAPIRequest(ctx, ap.Client, "POST", ap.Endpoint+"/v1/messages", headers, requestBody)
 	if err != nil {
-		// Check for authentication errors
-		if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "403") {
-			return "", fmt.Errorf("anthropic API authentication error: invalid API key. Check your API key or regenerate it in the Anthropic dashboard")
-		}
-
-		// Check for model errors
-		if strings.Contains(err.Error(), "model") && strings.Contains(strings.ToLower(err.Error()), "not found") {
-			return "", fmt.Errorf("anthropic model '%s' not found: check available models in your Anthropic account", ap.Model)
-		}
-
-		return "", fmt.Errorf("anthropic request failed: %w", err)
+		return AliasResult{}, ap.requestError(err)
 	}
 
 	var result struct {
 		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text"`
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			Name  string          `json:"name"`
+			Input json.RawMessage `json:"input"`
 		} `json:"content"`
+		Usage anthropicUsage `json:"usage"`
 		Error struct {
 			Type    string `json:"type"`
 			Message string `json:"message"`
@@ -73,110 +102,128 @@ func (ap *AnthropicProvider) GenerateAlias(command, shellType string) (string, e
 	}
 
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("failed to parse Anthropic response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
+		return AliasResult{}, fmt.Errorf("failed to parse Anthropic response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
 	}
 
 	// Check if there's an error in the response
 	if result.Error.Message != "" {
-		return "", fmt.Errorf("anthropic API error: %s", result.Error.Message)
+		return AliasResult{}, fmt.Errorf("anthropic API error: %s", result.Error.Message)
 	}
 
-	// Process the response content
+	// Prefer the tool call, falling back to the first text block
+	usage := Usage{InputTokens: result.Usage.InputTokens, OutputTokens: result.Usage.OutputTokens}
 	var responseText string
 	for _, content := range result.Content {
-		if content.Type == "text" {
+		if content.Type == "tool_use" && content.Name == anthropicAliasTool {
+			answer := ParseAliasResult(string(content.Input))
+			answer.Usage = usage
+			return answer, nil
+		}
+		if content.Type == "text" && responseText == "" {
 			responseText = content.Text
-			break
 		}
 	}
 
 	if responseText == "" {
-		return "", fmt.Errorf("no text response found in anthropic Claude reply\n\nRaw response: %s", limitResponseText(string(respBody), 200))
+		return AliasResult{}, fmt.Errorf("no text response found in anthropic Claude reply\n\nRaw response: %s", limitResponseText(string(respBody), 200))
 	}
 
-	return ExtractAliasDefinition(responseText), nil
+	answer := ParseAliasResult(responseText)
+	answer.Usage = usage
+	return answer, nil
 }
 
-// ConvertAlias converts an alias using the Anthropic Claude API.
-func (ap *AnthropicProvider) ConvertAlias(alias, fromShell, toShell string) (string, error) {
-	if err := ValidateEndpoint(ap.Endpoint); err != nil {
-		return "", err
-	}
-
-	// Check API key
-	if ap.APIKey == "" {
-		return "", fmt.Errorf("anthropic API key is empty: please configure a valid API key with 'aliasctl configure-anthropic'")
-	}
-
-	// Build the request payload
-	requestBody, err := json.Marshal(map[string]any{
-		"model": ap.Model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": ConversionPrompt(alias, fromShell, toShell),
-			},
-		},
-		"max_tokens":  300,
-		"temperature": 0.1, // Lower temperature for more deterministic results
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to create Anthropic request: %w", err)
-	}
-
-	// Prepare headers
-	headers := map[string]string{
-		"x-api-key":         ap.APIKey,
-		"anthropic-version": "2023-06-01", // Use appropriate API version
-	}
+// anthropicUsage is the usage of a message. Streams report the input tokens when the
+// message starts and the output tokens when it ends.
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
 
-	respBody, err := MakeAPIRequest("POST", ap.Endpoint+"/v1/messages", headers, requestBody)
-	if err != nil {
-		// Check for authentication errors
-		if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "403") {
-			return "", fmt.Errorf("anthropic API authentication error: invalid API key. Check your API key or regenerate it in the Anthropic dashboard")
+// streamMessage sends a streaming message request and assembles the tool input, or the
+// text as a fallback, from the content block deltas. The decoded fields of each are shown
+// on stream as they arrive (see decodedStream).
+func (ap *AnthropicProvider) streamMessage(ctx context.Context, headers map[string]string, requestBody []byte, stream StreamFunc) (AliasResult, error) {
+	var toolInput, text strings.Builder
+	toolStream, textStream := decodedStream(stream), decodedStream(stream)
+	var usage Usage
+	var responseErr error // An error reported in the stream rather than by the request
+	err := MakeStreamingRequest(ctx, ap.Client, "POST", ap.Endpoint+"/v1/messages", headers, requestBody, func(line string) error {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return nil
 		}
-
-		// Check for model errors
-		if strings.Contains(err.Error(), "model") && strings.Contains(strings.ToLower(err.Error()), "not found") {
-			return "", fmt.Errorf("anthropic model '%s' not found: check available models in your Anthropic account", ap.Model)
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+			} `json:"delta"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Usage anthropicUsage `json:"usage"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
 		}
-
-		return "", fmt.Errorf("anthropic request failed: %w", err)
-	}
-
-	var result struct {
-		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text"`
-		} `json:"content"`
-		Error struct {
-			Type    string `json:"type"`
-			Message string `json:"message"`
-		} `json:"error"`
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			responseErr = fmt.Errorf("failed to parse Anthropic response: %w\n\nRaw response: %s", err, limitResponseText(data, 200))
+			return responseErr
+		}
+		switch event.Type {
+		case "error":
+			responseErr = fmt.Errorf("anthropic API error: %s", event.Error.Message)
+			return responseErr
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "input_json_delta":
+				toolInput.WriteString(event.Delta.PartialJSON)
+				toolStream(event.Delta.PartialJSON)
+			case "text_delta":
+				text.WriteString(event.Delta.Text)
+				textStream(event.Delta.Text)
+			}
+		}
+		return nil
+	})
+	if responseErr != nil {
+		return AliasResult{}, responseErr
 	}
-
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("failed to parse Anthropic response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
+	if err != nil {
+		return AliasResult{}, ap.requestError(err)
 	}
 
-	// Check if there's an error in the response
-	if result.Error.Message != "" {
-		return "", fmt.Errorf("anthropic API error: %s", result.Error.Message)
+	// Prefer the tool call, falling back to the text
+	var result AliasResult
+	switch {
+	case toolInput.Len() > 0:
+		result = ParseAliasResult(toolInput.String())
+	case text.Len() > 0:
+		result = ParseAliasResult(text.String())
+	default:
+		return AliasResult{}, fmt.Errorf("no text response found in anthropic Claude reply")
 	}
+	result.Usage = usage
+	return result, nil
+}
 
-	// Process the response content
-	var responseText string
-	for _, content := range result.Content {
-		if content.Type == "text" {
-			responseText = content.Text
-			break
-		}
+// requestError explains a failed Anthropic request.
+func (ap *AnthropicProvider) requestError(err error) error {
+	// Check for authentication errors
+	if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "403") {
+		return fmt.Errorf("anthropic API authentication error: invalid API key. Check your API key or regenerate it in the Anthropic dashboard")
 	}
 
-	if responseText == "" {
-		return "", fmt.Errorf("no text response found in anthropic Claude reply\n\nRaw response: %s", limitResponseText(string(respBody), 200))
+	// Check for model errors
+	if strings.Contains(err.Error(), "model") && strings.Contains(strings.ToLower(err.Error()), "not found") {
+		return fmt.Errorf("anthropic model '%s' not found: check available models in your Anthropic account", ap.Model)
 	}
 
-	return ExtractAliasDefinition(responseText), nil
+	return fmt.Errorf("anthropic request failed: %w", err)
 }
@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is configured.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// AzureOpenAIProvider implements Provider for Azure OpenAI. Requests go to a deployment
+// of a model rather than to a model, and are authenticated with an api-key header.
+type AzureOpenAIProvider struct {
+	Endpoint   string            // The resource endpoint URL, such as https://NAME.openai.azure.com
+	APIKey     string            // The Azure OpenAI API key
+	Deployment string            // The name of the model deployment
+	APIVersion string            // The API version, DefaultAzureAPIVersion if empty
+	Timeout    time.Duration     // The time allowed for a request, DefaultTimeout if zero
+	Headers    map[string]string // Extra headers sent with every request
+	Transport  Transport         // How requests reach the endpoint, such as through a proxy
+	Client     *http.Client      // The HTTP client requests are sent with, the shared client if nil
+}
+
+// Info returns the configuration of the provider. The deployment is reported as the model.
+func (az *AzureOpenAIProvider) Info() ProviderInfo {
+	info := ProviderInfo{Type: "azure-openai", Endpoint: az.Endpoint, APIKey: az.APIKey, Model: az.Deployment, Timeout: az.Timeout, Headers: az.Headers, Transport: az.Transport, Client: az.Client}
+	if az.APIVersion != "" {
+		info.Options = map[string]string{"api_version": az.APIVersion}
+	}
+	return info
+}
+
+// service returns where and how chat completion requests are sent.
+func (az *AzureOpenAIProvider) service() chatService {
+	version := az.APIVersion
+	if version == "" {
+		version = DefaultAzureAPIVersion
+	}
+	return chatService{
+		name:      "azure OpenAI",
+		keyHelp:   "regenerate it in the Azure portal",
+		configure: "aliasctl configure-ai azure-openai",
+		endpoint:  az.Endpoint,
+		url: strings.TrimSuffix(az.Endpoint, "/") + "/openai/deployments/" + url.PathEscape(az.Deployment) +
+			"/chat/completions?api-version=" + url.QueryEscape(version),
+		headers:     requestHeaders(map[string]string{"api-key": az.APIKey}, az.Headers),
+		client:      az.Client,
+		apiKey:      az.APIKey,
+		needsKey:    true,
+		timeout:     az.Timeout,
+		streamUsage: true,
+	}
+}
+
+// Complete answers a prompt using Azure OpenAI.
+func (az *AzureOpenAIProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
+	return az.service().complete(ctx, prompt, stream)
+}
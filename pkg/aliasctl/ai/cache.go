@@ -0,0 +1,262 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PromptVersion identifies the response formats aliasctl adds to the prompt templates.
+// It is part of every cache key, with the version of the template (see Prompts.Version),
+// so it must be increased whenever a response format or a built-in template changes, or
+// answers to the old prompt would still be reused.
+const PromptVersion = 2
+
+// Default limits of the response cache.
+const (
+	DefaultCacheTTL     = 30 * 24 * time.Hour
+	DefaultCacheMaxSize = 4 << 20 // 4 MiB
+)
+
+// Cache stores provider answers on disk, one file per request, so repeating a conversion
+// or generation is answered without asking the provider again. Answers are keyed by
+// provider type, model, PromptVersion, the prompt template version and the rendered
+// prompt, so a prompt that changes with the existing aliases or the naming conventions
+// is asked again.
+type Cache struct {
+	Dir     string        // The directory holding the cached answers
+	TTL     time.Duration // How long an answer is reused, DefaultCacheTTL if zero
+	MaxSize int64         // The total size of the cached answers in bytes, DefaultCacheMaxSize if zero
+	Refresh bool          // Whether cached answers are ignored; new answers are still stored
+
+	mu sync.Mutex // Serializes pruning
+}
+
+// CacheStats describes the contents of the response cache.
+type CacheStats struct {
+	Dir        string         `json:"dir" yaml:"dir" toml:"dir"`                         // The directory holding the cached answers
+	Entries    int            `json:"entries" yaml:"entries" toml:"entries"`             // The number of cached answers, including expired ones
+	Expired    int            `json:"expired" yaml:"expired" toml:"expired"`             // The number of answers past the TTL
+	Size       int64          `json:"size" yaml:"size" toml:"size"`                      // The total size of the cached answers in bytes
+	MaxSize    int64          `json:"max_size" yaml:"max_size" toml:"max_size"`          // The size limit in bytes
+	TTL        string         `json:"ttl" yaml:"ttl" toml:"ttl"`                         // How long an answer is reused
+	Hits       int            `json:"hits" yaml:"hits" toml:"hits"`                      // Requests answered from the cache
+	ByProvider map[string]int `json:"by_provider" yaml:"by_provider" toml:"by_provider"` // The number of cached answers per provider type
+}
+
+// cacheEntry is a cached answer.
+type cacheEntry struct {
+	Provider  string      `json:"provider"`      // The provider type
+	Model     string      `json:"model"`         // The model that answered
+	Request   string      `json:"request"`       // The request, such as "convert bash fish: ls -la"
+	Template  string      `json:"template"`      // The version of the prompt template, see Prompts.Version
+	Result    AliasResult `json:"result"`        // The answer
+	Raw       string      `json:"raw,omitempty"` // The answer's Raw text, which AliasResult does not encode
+	CreatedAt time.Time   `json:"created_at"`    // When the answer was received
+	Hits      int         `json:"hits"`          // How often the answer was reused
+}
+
+// ttl returns how long an answer is reused.
+func (c *Cache) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return DefaultCacheTTL
+}
+
+// maxSize returns the size limit of the cache in bytes.
+func (c *Cache) maxSize() int64 {
+	if c.MaxSize > 0 {
+		return c.MaxSize
+	}
+	return DefaultCacheMaxSize
+}
+
+// path returns the file of the answer to prompt, rendered from the given version of its
+// template, from the provider described by info.
+func (c *Cache) path(info ProviderInfo, template string, prompt Prompt) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%g", PromptVersion, template, info.Type, info.Model, prompt.System, prompt.User, prompt.Text, prompt.Temperature)))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached answer to prompt, rendered from the given version of its
+// template, from the provider described by info.
+// It reports false if there is none, it expired, or Refresh is set.
+func (c *Cache) Get(info ProviderInfo, template string, prompt Prompt) (AliasResult, bool) {
+	if c.Refresh {
+		return AliasResult{}, false
+	}
+	path := c.path(info, template, prompt)
+	entry, err := readCacheEntry(path)
+	if err != nil || time.Since(entry.CreatedAt) > c.ttl() {
+		return AliasResult{}, false
+	}
+
+	entry.Hits++
+	writeCacheEntry(path, entry)
+	result := entry.Result
+	result.Raw = entry.Raw
+	return result, true
+}
+
+// Put stores the answer to prompt, rendered from the given version of its template, from
+// the provider described by info, then removes expired answers and, if the cache is over
+// its size limit, the least recently used ones. request describes the prompt in the
+// cache entry, such as "convert bash fish: ls -la".
+func (c *Cache) Put(info ProviderInfo, template string, prompt Prompt, request string, result AliasResult) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create AI cache directory %s: %w", c.Dir, err)
+	}
+	entry := cacheEntry{
+		Provider:  info.Type,
+		Model:     info.Model,
+		Request:   request,
+		Template:  template,
+		Result:    result,
+		Raw:       result.Raw,
+		CreatedAt: time.Now(),
+	}
+	if err := writeCacheEntry(c.path(info, template, prompt), entry); err != nil {
+		return err
+	}
+	return c.prune()
+}
+
+// prune removes answers not used within the TTL, then the least recently used answers
+// until the cache fits its size limit.
+func (c *Cache) prune() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modified.Before(files[j].modified) })
+
+	var size int64
+	for _, file := range files {
+		size += file.size
+	}
+	for _, file := range files {
+		if time.Since(file.modified) <= c.ttl() && size <= c.maxSize() {
+			continue
+		}
+		if err := os.Remove(file.path); err == nil {
+			size -= file.size
+		}
+	}
+	return nil
+}
+
+// Clear removes every cached answer and returns how many there were.
+func (c *Cache) Clear() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, file := range files {
+		if err := os.Remove(file.path); err != nil {
+			return removed, fmt.Errorf("failed to remove cached answer %s: %w", file.path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Stats describes the contents of the cache.
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.Dir, MaxSize: c.maxSize(), TTL: c.ttl().String(), ByProvider: make(map[string]int)}
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+	for _, file := range files {
+		entry, err := readCacheEntry(file.path)
+		if err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Size += file.size
+		stats.Hits += entry.Hits
+		stats.ByProvider[entry.Provider]++
+		if time.Since(entry.CreatedAt) > c.ttl() {
+			stats.Expired++
+		}
+	}
+	return stats, nil
+}
+
+// cacheFile is a cached answer on disk.
+type cacheFile struct {
+	path     string
+	size     int64
+	modified time.Time
+}
+
+// files lists the cached answers. A missing cache directory has none.
+func (c *Cache) files() ([]cacheFile, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read AI cache directory %s: %w", c.Dir, err)
+	}
+	var files []cacheFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			files = append(files, cacheFile{path: filepath.Join(c.Dir, entry.Name()), size: info.Size(), modified: info.ModTime()})
+		}
+	}
+	return files, nil
+}
+
+// readCacheEntry reads a cached answer.
+func readCacheEntry(path string) (cacheEntry, error) {
+	var entry cacheEntry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+// writeCacheEntry writes a cached answer through a temporary file, so concurrent readers
+// never see a partial answer.
+func writeCacheEntry(path string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached answer: %w", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cached answer: %w", err)
+	}
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write cached answer: %w", err)
+	}
+	temp.Close()
+	if err := os.Rename(temp.Name(), path); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to write cached answer: %w", err)
+	}
+	return nil
+}
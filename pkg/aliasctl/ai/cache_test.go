@@ -0,0 +1,183 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var cacheInfo = ProviderInfo{Type: "ollama", Model: "llama3.2"}
+
+// cachePrompt returns a prompt that differs from the other cache prompts only by n.
+func cachePrompt(n int) Prompt {
+	return Prompt{System: "system", User: fmt.Sprintf("convert alias %d", n)}
+}
+
+// mustPut stores an answer to the n-th cache prompt or fails the test.
+func mustPut(t *testing.T, cache *Cache, n int) {
+	t.Helper()
+	if err := cache.Put(cacheInfo, "v1", cachePrompt(n), fmt.Sprintf("request %d", n), AliasResult{Command: fmt.Sprintf("command %d", n), Raw: "raw"}); err != nil {
+		t.Fatalf("Put(%d): %v", n, err)
+	}
+}
+
+// cached reports whether the cache holds an answer to the n-th cache prompt, reading it.
+func cached(cache *Cache, n int) bool {
+	_, ok := cache.Get(cacheInfo, "v1", cachePrompt(n))
+	return ok
+}
+
+// age sets when the answer to the n-th cache prompt was received and last used.
+func age(t *testing.T, cache *Cache, n int, ago time.Duration) {
+	t.Helper()
+	path := cache.path(cacheInfo, "v1", cachePrompt(n))
+	entry, err := readCacheEntry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	when := time.Now().Add(-ago)
+	entry.CreatedAt = when
+	if err := writeCacheEntry(path, entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, when, when); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheGetPut(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	if cached(cache, 1) {
+		t.Fatal("empty cache has an answer")
+	}
+	mustPut(t, cache, 1)
+	result, ok := cache.Get(cacheInfo, "v1", cachePrompt(1))
+	if !ok || result.Command != "command 1" || result.Raw != "raw" {
+		t.Fatalf("Get = %+v, %v; want the stored answer with its raw text", result, ok)
+	}
+
+	other := []struct {
+		name     string
+		info     ProviderInfo
+		template string
+		prompt   Prompt
+	}{
+		{name: "other model", info: ProviderInfo{Type: "ollama", Model: "mistral"}, template: "v1", prompt: cachePrompt(1)},
+		{name: "other provider type", info: ProviderInfo{Type: "openai", Model: "llama3.2"}, template: "v1", prompt: cachePrompt(1)},
+		{name: "other template version", info: cacheInfo, template: "v2", prompt: cachePrompt(1)},
+		{name: "other system prompt", info: cacheInfo, template: "v1", prompt: Prompt{System: "other", User: cachePrompt(1).User}},
+		{name: "other temperature", info: cacheInfo, template: "v1", prompt: Prompt{System: "system", User: cachePrompt(1).User, Temperature: 0.7}},
+	}
+	for _, tt := range other {
+		if _, ok := cache.Get(tt.info, tt.template, tt.prompt); ok {
+			t.Errorf("%s: answered from the cache", tt.name)
+		}
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1 || stats.Hits != 1 || stats.ByProvider["ollama"] != 1 || stats.Size == 0 {
+		t.Errorf("Stats = %+v, want 1 entry used once", stats)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir(), TTL: time.Hour}
+	mustPut(t, cache, 1)
+	mustPut(t, cache, 2)
+	age(t, cache, 1, 2*time.Hour)
+	age(t, cache, 2, 30*time.Minute)
+
+	if cached(cache, 1) {
+		t.Error("answer older than the TTL reused")
+	}
+	if !cached(cache, 2) {
+		t.Error("answer within the TTL not reused")
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 2 || stats.Expired != 1 {
+		t.Errorf("Stats = %+v, want 2 entries with 1 expired", stats)
+	}
+
+	// Storing an answer removes the expired ones
+	mustPut(t, cache, 3)
+	if _, err := os.Stat(cache.path(cacheInfo, "v1", cachePrompt(1))); !os.IsNotExist(err) {
+		t.Errorf("expired answer kept after Put: %v", err)
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 2 || stats.Expired != 0 {
+		t.Errorf("Stats = %+v, want 2 entries none expired", stats)
+	}
+}
+
+func TestCachePrunesLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	mustPut(t, &Cache{Dir: dir}, 0)
+	info, err := os.Stat((&Cache{Dir: dir}).path(cacheInfo, "v1", cachePrompt(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Room for three answers of the same size, with some slack for the hit counts
+	cache := &Cache{Dir: dir, MaxSize: 3*info.Size() + info.Size()/2}
+	mustPut(t, cache, 1)
+	mustPut(t, cache, 2)
+	age(t, cache, 0, 3*time.Minute)
+	age(t, cache, 1, 2*time.Minute)
+	age(t, cache, 2, time.Minute)
+	if !cached(cache, 0) { // Using the oldest answer makes it the most recent
+		t.Fatal("answer 0 missing before the cache is full")
+	}
+
+	mustPut(t, cache, 3)
+	for n, want := range []bool{true, false, true, true} {
+		if got := cached(cache, n); got != want {
+			t.Errorf("answer %d cached = %v, want %v", n, got, want)
+		}
+	}
+	if stats, _ := cache.Stats(); stats.Size > cache.MaxSize {
+		t.Errorf("cache size %d over its limit %d", stats.Size, cache.MaxSize)
+	}
+}
+
+func TestCacheRefresh(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	mustPut(t, cache, 1)
+	cache.Refresh = true
+	if cached(cache, 1) {
+		t.Error("answer reused with Refresh set")
+	}
+
+	// New answers are still stored, for the next run without Refresh
+	if err := cache.Put(cacheInfo, "v1", cachePrompt(1), "request 1", AliasResult{Command: "fresh"}); err != nil {
+		t.Fatal(err)
+	}
+	cache.Refresh = false
+	if result, ok := cache.Get(cacheInfo, "v1", cachePrompt(1)); !ok || result.Command != "fresh" {
+		t.Errorf("Get = %+v, %v; want the answer stored with Refresh set", result, ok)
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	cache := &Cache{Dir: t.TempDir()}
+	for n := range 3 {
+		mustPut(t, cache, n)
+	}
+	removed, err := cache.Clear()
+	if err != nil || removed != 3 {
+		t.Fatalf("Clear = %d, %v; want 3 answers removed", removed, err)
+	}
+	if cached(cache, 0) {
+		t.Error("answer reused after Clear")
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 0 || stats.Size != 0 {
+		t.Errorf("Stats after Clear = %+v, want an empty cache", stats)
+	}
+
+	missing := &Cache{Dir: filepath.Join(t.TempDir(), "missing")}
+	if removed, err := missing.Clear(); err != nil || removed != 0 {
+		t.Errorf("Clear of a missing directory = %d, %v; want nothing removed", removed, err)
+	}
+}
@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GeminiProvider implements Provider for Google Gemini.
+type GeminiProvider struct {
+	Endpoint  string            // The Gemini endpoint URL
+	APIKey    string            // The Gemini API key
+	Model     string            // The Gemini model name, such as gemini-2.0-flash
+	Timeout   time.Duration     // The time allowed for a request, DefaultTimeout if zero
+	Headers   map[string]string // Extra headers sent with every request
+	Transport Transport         // How requests reach the endpoint, such as through a proxy
+	Client    *http.Client      // The HTTP client requests are sent with, the shared client if nil
+}
+
+// geminiResponse is a response, or an event of a streamed response, from generateContent.
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// usage returns the usage reported with the response. Every event of a stream reports
+// the usage so far.
+func (gr geminiResponse) usage() Usage {
+	return Usage{InputTokens: gr.UsageMetadata.PromptTokenCount, OutputTokens: gr.UsageMetadata.CandidatesTokenCount}
+}
+
+// text returns the text of the first candidate.
+func (gr geminiResponse) text() string {
+	if len(gr.Candidates) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range gr.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
+
+// Info returns the configuration of the provider.
+func (gp *GeminiProvider) Info() ProviderInfo {
+	return ProviderInfo{Type: "gemini", Endpoint: gp.Endpoint, APIKey: gp.APIKey, Model: gp.Model, Timeout: gp.Timeout, Headers: gp.Headers, Transport: gp.Transport, Client: gp.Client}
+}
+
+// Complete sends a prompt with a response schema, so the answer is the AliasResult as
+// JSON. A text answer is scraped as a fallback. With a stream function the answer is
+// streamed as server-sent events and its decoded fields shown as they arrive.
+func (gp *GeminiProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
+	if err := ValidateEndpoint(gp.Endpoint); err != nil {
+		return AliasResult{}, err
+	}
+	ctx, cancel := withTimeout(ctx, gp.Timeout, DefaultTimeout)
+	defer cancel()
+
+	// Check API key
+	if gp.APIKey == "" {
+		return AliasResult{}, fmt.Errorf("gemini API key is empty: please configure a valid API key with 'aliasctl configure-ai gemini'")
+	}
+
+	// Gemini's response schema is an OpenAPI subset without additionalProperties
+	schema := maps.Clone(aliasResultSchema)
+	delete(schema, "additionalProperties")
+
+	request := map[string]any{
+		"contents": []map[string]any{
+			{
+				"role":  "user",
+				"parts": []map[string]string{{"text": prompt.User}},
+			},
+		},
+		"generationConfig": map[string]any{
+			"temperature":      prompt.Temperature,
+			"responseMimeType": "application/json",
+			"responseSchema":   schema,
+		},
+	}
+	if prompt.System != "" {
+		request["systemInstruction"] = map[string]any{"parts": []map[string]string{{"text": prompt.System}}}
+	}
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return AliasResult{}, fmt.Errorf("failed to create Gemini request: %w", err)
+	}
+
+	headers := requestHeaders(map[string]string{"x-goog-api-key": gp.APIKey}, gp.Headers)
+	modelURL := strings.TrimSuffix(gp.Endpoint, "/") + "/v1beta/models/" + url.PathEscape(gp.Model)
+
+	if stream != nil {
+		stream = decodedStream(stream)
+		var text strings.Builder
+		var usage Usage
+		var responseErr error // An error reported in the stream rather than by the request
+		err := MakeStreamingRequest(ctx, gp.Client, "POST", modelURL+":streamGenerateContent?alt=sse", headers, requestBody, func(line string) error {
+			data, ok := strings.CutPrefix(line, "data:")
+			if !ok {
+				return nil
+			}
+			var event geminiResponse
+			if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+				responseErr = fmt.Errorf("failed to parse Gemini response: %w\n\nRaw response: %s", err, limitResponseText(data, 200))
+				return responseErr
+			}
+			if event.Error != nil {
+				responseErr = fmt.Errorf("gemini API error: %s", event.Error.Message)
+				return responseErr
+			}
+			if chunk := event.text(); chunk != "" {
+				text.WriteString(chunk)
+				stream(chunk)
+			}
+			if reported := event.usage(); reported != (Usage{}) {
+				usage = reported
+			}
+			return nil
+		})
+		if responseErr != nil {
+			return AliasResult{}, responseErr
+		}
+		if err != nil {
+			return AliasResult{}, gp.requestError(err)
+		}
+		if text.Len() == 0 {
+			return AliasResult{}, fmt.Errorf("no text response found in Gemini reply")
+		}
+		result := ParseAliasResult(text.String())
+		result.Usage = usage
+		return result, nil
+	}
+
+	respBody, err := MakeAPIRequest(ctx, gp.Client, "POST", modelURL+":generateContent", headers, requestBody)
+	if err != nil {
+		return AliasResult{}, gp.requestError(err)
+	}
+
+	var result geminiResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return AliasResult{}, fmt.Errorf("failed to parse Gemini response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
+	}
+	if result.Error != nil {
+		return AliasResult{}, fmt.Errorf("gemini API error: %s", result.Error.Message)
+	}
+
+	text := result.text()
+	if text == "" {
+		return AliasResult{}, fmt.Errorf("no text response found in Gemini reply\n\nRaw response: %s", limitResponseText(string(respBody), 200))
+	}
+	answer := ParseAliasResult(text)
+	answer.Usage = result.usage()
+	return answer, nil
+}
+
+// requestError explains a failed Gemini request.
+func (gp *GeminiProvider) requestError(err error) error {
+	// Check for authentication errors
+	if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "API_KEY_INVALID") {
+		return fmt.Errorf("gemini API authentication error: invalid API key. Check your API key or create one in Google AI Studio")
+	}
+
+	// Check for model errors
+	if strings.Contains(err.Error(), "404") {
+		return fmt.Errorf("gemini model '%s' not found: check the models available to your API key", gp.Model)
+	}
+
+	return fmt.Errorf("gemini request failed: %w", err)
+}
@@ -0,0 +1,50 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// LlamaCppProvider implements Provider for the llama.cpp server and LM Studio, which
+// serve local models through an OpenAI-compatible API. The API key is only needed if the
+// server was started with one.
+type LlamaCppProvider struct {
+	Endpoint  string            // The server URL
+	APIKey    string            // The server's API key, empty if it has none
+	Model     string            // The model name; servers with a single model ignore it
+	Timeout   time.Duration     // The time allowed for a request, DefaultLocalTimeout if zero
+	Headers   map[string]string // Extra headers sent with every request
+	Transport Transport         // How requests reach the endpoint, such as through a proxy
+	Client    *http.Client      // The HTTP client requests are sent with, the shared client if nil
+}
+
+// Info returns the configuration of the provider.
+func (lp *LlamaCppProvider) Info() ProviderInfo {
+	return ProviderInfo{Type: "llamacpp", Endpoint: lp.Endpoint, APIKey: lp.APIKey, Model: lp.Model, Timeout: lp.Timeout, Headers: lp.Headers, Transport: lp.Transport, Client: lp.Client}
+}
+
+// service returns where and how chat completion requests are sent.
+func (lp *LlamaCppProvider) service() chatService {
+	headers := map[string]string{}
+	if lp.APIKey != "" {
+		headers["Authorization"] = "Bearer " + lp.APIKey
+	}
+	return chatService{
+		name:           "llama.cpp",
+		keyHelp:        "compare it with the server's --api-key option",
+		configure:      "aliasctl configure-ai llamacpp",
+		endpoint:       lp.Endpoint,
+		url:            lp.Endpoint + "/v1/chat/completions",
+		headers:        requestHeaders(headers, lp.Headers),
+		client:         lp.Client,
+		model:          lp.Model,
+		timeout:        lp.Timeout,
+		defaultTimeout: DefaultLocalTimeout,
+	}
+}
+
+// Complete answers a prompt using the llama.cpp server.
+func (lp *LlamaCppProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
+	return lp.service().complete(ctx, prompt, stream)
+}
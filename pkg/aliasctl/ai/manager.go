@@ -1,15 +1,28 @@
 package ai
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 )
 
 // Manager handles interactions with AI providers.
 // It maintains a registry of available providers and handles provider selection.
+// Requests that name no provider go to the providers of the fallback chain in order
+// until one answers, or to the default provider if there is no chain. With a Cache,
+// answers are stored and reused instead of asking a provider the same thing again.
+// Prompts are rendered from the templates of Prompts. With a Usage ledger, the tokens of
+// every answer are recorded, and hosted providers are skipped once the budget is used up.
 type Manager struct {
 	Providers map[string]Provider // Map of provider name to provider implementation
 	Default   Provider            // The default provider to use when none is specified
+	Fallback  []string            // Provider names tried in order when none is specified
+	Cache     *Cache              // The response cache, nil to always ask the provider
+	Prompts   *Prompts            // The prompt templates, nil for the built-in templates
+	Usage     *UsageLedger        // The token usage ledger, nil to keep no ledger
 }
 
 // NewManager creates a new AI provider manager.
@@ -21,12 +34,13 @@ func NewManager() *Manager {
 }
 
 // AddProvider adds an AI provider to the manager.
-// If this is the first provider added, it becomes the default.
+// If this is the first provider added, or it replaces the default provider, it becomes
+// the default.
 func (m *Manager) AddProvider(name string, provider Provider) {
-	m.Providers[name] = provider
-	if m.Default == nil {
+	if m.Default == nil || m.Default == m.Providers[name] {
 		m.Default = provider
 	}
+	m.Providers[name] = provider
 }
 
 // SetDefaultProvider sets the default AI provider.
@@ -69,40 +83,194 @@ func (m *Manager) ListProviders() []string {
 	for name := range m.Providers {
 		providers = append(providers, name)
 	}
+	sort.Strings(providers)
 	return providers
 }
 
-// ConvertAlias converts an alias from one shell to another using the specified provider.
-// It automatically selects the default provider if none is specified.
-// Returns the converted alias or an error if the conversion fails.
-func (m *Manager) ConvertAlias(alias, fromShell, toShell, providerName string) (string, error) {
-	provider, err := m.GetProvider(providerName)
+// DefaultName returns the name the default provider is registered under.
+// It returns an empty string if no default provider is set.
+func (m *Manager) DefaultName() string {
+	for _, name := range m.ListProviders() {
+		if m.Providers[name] == m.Default {
+			return name
+		}
+	}
+	return ""
+}
+
+// SetFallbackChain sets the providers tried in order when a request names no provider.
+// An empty chain sends requests to the default provider only.
+// It returns an error if a provider is not registered or listed twice.
+func (m *Manager) SetFallbackChain(names []string) error {
+	for i, name := range names {
+		if _, exists := m.Providers[name]; !exists {
+			return fmt.Errorf("provider '%s' not configured. Available providers: %s", name, strings.Join(m.ListProviders(), ", "))
+		}
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("provider '%s' is listed more than once", name)
+		}
+	}
+	m.Fallback = names
+	return nil
+}
+
+// providerChain returns the names of the providers to try for a request, in order: the
+// named provider alone, otherwise the configured providers of the fallback chain,
+// otherwise the default provider.
+func (m *Manager) providerChain(providerName string) ([]string, error) {
+	if providerName == "" && len(m.Fallback) > 0 {
+		var names []string
+		for _, name := range m.Fallback {
+			if _, exists := m.Providers[name]; exists {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			return names, nil
+		}
+	}
+
+	if _, err := m.GetProvider(providerName); err != nil {
+		return nil, err
+	}
+	if providerName == "" {
+		providerName = m.DefaultName()
+	}
+	return []string{providerName}, nil
+}
+
+// request renders the prompt template of task with data and sends the prompt to each
+// provider of the chain for providerName until one answers, and records the provider's
+// name in the result. A cached answer to the same prompt from any provider of the chain
+// is used first; request describes the prompt in the cache. A provider that fails after streaming
+// part of its answer is followed by a newline on stream before the next one is tried.
+// A provider the budget does not allow counts as failed. No further providers are tried
+// once ctx is canceled.
+func (m *Manager) request(ctx context.Context, providerName, task string, data PromptData, request string, stream StreamFunc) (AliasResult, error) {
+	names, err := m.providerChain(providerName)
+	if err != nil {
+		return AliasResult{}, err
+	}
+	prompt, err := m.Prompts.Render(task, data)
 	if err != nil {
-		return "", err
+		return AliasResult{}, err
+	}
+	template := m.Prompts.Version(task)
+
+	if m.Cache != nil {
+		for _, name := range names {
+			if result, ok := m.Cache.Get(m.Providers[name].Info(), template, prompt); ok {
+				result.Provider, result.Cached = name, true
+				return result, nil
+			}
+		}
+	}
+
+	var failures []error
+	for _, name := range names {
+		streamed := false
+		var providerStream StreamFunc
+		if stream != nil {
+			providerStream = func(text string) {
+				streamed = true
+				stream(text)
+			}
+		}
+
+		info := m.Providers[name].Info()
+		var result AliasResult
+		var err error
+		if m.Usage != nil {
+			err = m.Usage.CheckBudget(info)
+		}
+		if err == nil {
+			result, err = m.Providers[name].Complete(ctx, prompt, providerStream)
+		}
+		if err == nil {
+			if m.Cache != nil {
+				// A cache that cannot be written only costs a request next time
+				m.Cache.Put(info, template, prompt, request, result)
+			}
+			if m.Usage != nil {
+				// A ledger that cannot be written only leaves the request out of the totals
+				m.Usage.Record(name, info, task, result.Usage)
+			}
+			result.Provider = name
+			return result, nil
+		}
+		if len(names) == 1 {
+			return AliasResult{}, err
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", name, err))
+		if ctx.Err() != nil {
+			break
+		}
+		if streamed {
+			stream("\n")
+		}
 	}
+	return AliasResult{}, fmt.Errorf("no provider in the fallback chain answered:\n%w", errors.Join(failures...))
+}
 
-	result, err := provider.ConvertAlias(alias, fromShell, toShell)
+// ConvertAlias converts a command from data.FromShell to data.Shell using the specified
+// provider, with the prompt rendered from the convert template.
+// It uses the fallback chain, or the default provider, if none is specified.
+// If stream is not nil, the response text is passed to it as it arrives.
+// Returns the converted alias or an error if the conversion fails.
+func (m *Manager) ConvertAlias(ctx context.Context, data PromptData, providerName string, stream StreamFunc) (AliasResult, error) {
+	request := fmt.Sprintf("convert %s %s: %s", data.FromShell, data.Shell, data.Command)
+	result, err := m.request(ctx, providerName, TaskConvert, data, request, stream)
 	if err != nil {
 		// Add more context to the error
-		return "", fmt.Errorf("failed to convert alias from %s to %s: %w", fromShell, toShell, err)
+		return AliasResult{}, fmt.Errorf("failed to convert alias from %s to %s: %w", data.FromShell, data.Shell, err)
 	}
 
 	return result, nil
 }
 
-// GenerateAlias generates an alias suggestion for a command using the specified provider.
-// It automatically selects the default provider if none is specified.
+// GenerateAlias generates an alias suggestion for data.Command in data.Shell using the
+// specified provider, with the prompt rendered from the generate template.
+// It uses the fallback chain, or the default provider, if none is specified.
+// If stream is not nil, the response text is passed to it as it arrives.
 // Returns the generated alias suggestion or an error if the generation fails.
-func (m *Manager) GenerateAlias(command, shellType, providerName string) (string, error) {
-	provider, err := m.GetProvider(providerName)
+func (m *Manager) GenerateAlias(ctx context.Context, data PromptData, providerName string, stream StreamFunc) (AliasResult, error) {
+	request := fmt.Sprintf("generate %s: %s", data.Shell, data.Command)
+	result, err := m.request(ctx, providerName, TaskGenerate, data, request, stream)
 	if err != nil {
-		return "", err
+		// Add more context to the error
+		return AliasResult{}, fmt.Errorf("failed to generate alias suggestion for %s shell: %w", data.Shell, err)
 	}
 
-	result, err := provider.GenerateAlias(command, shellType)
+	return result, nil
+}
+
+// ExplainAlias asks what the alias data.Name, which runs data.Command in data.Shell, does,
+// using the specified provider, with the prompt rendered from the explain template. The
+// explanation is returned in the Explanation of the result, or in Raw for a text answer.
+// It uses the fallback chain, or the default provider, if none is specified.
+// If stream is not nil, the response text is passed to it as it arrives.
+// Returns an error if the request fails.
+func (m *Manager) ExplainAlias(ctx context.Context, data PromptData, providerName string, stream StreamFunc) (AliasResult, error) {
+	request := fmt.Sprintf("explain %s %s: %s", data.Shell, data.Name, data.Command)
+	result, err := m.request(ctx, providerName, TaskExplain, data, request, stream)
 	if err != nil {
-		// Add more context to the error
-		return "", fmt.Errorf("failed to generate alias suggestion for %s shell: %w", shellType, err)
+		return AliasResult{}, fmt.Errorf("failed to explain alias %s: %w", data.Name, err)
+	}
+
+	return result, nil
+}
+
+// AskCommand asks for a command in data.Shell that does what data.Request describes, and
+// an alias for it, using the specified provider, with the prompt rendered from the ask
+// template.
+// It uses the fallback chain, or the default provider, if none is specified.
+// If stream is not nil, the response text is passed to it as it arrives.
+// Returns the proposed alias or an error if the request fails.
+func (m *Manager) AskCommand(ctx context.Context, data PromptData, providerName string, stream StreamFunc) (AliasResult, error) {
+	request := fmt.Sprintf("ask %s: %s", data.Shell, data.Request)
+	result, err := m.request(ctx, providerName, TaskAsk, data, request, stream)
+	if err != nil {
+		return AliasResult{}, fmt.Errorf("failed to ask for a %s command: %w", data.Shell, err)
 	}
 
 	return result, nil
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// stubProvider answers every prompt with the same result and counts the prompts.
+type stubProvider struct {
+	info   ProviderInfo
+	result AliasResult
+	err    error
+
+	mu      sync.Mutex
+	prompts []Prompt
+}
+
+func (p *stubProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompts = append(p.prompts, prompt)
+	return p.result, p.err
+}
+
+func (p *stubProvider) Info() ProviderInfo {
+	return p.info
+}
+
+// calls returns the number of prompts the provider was sent.
+func (p *stubProvider) calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.prompts)
+}
+
+// newStubManager returns a manager whose only provider is a stub, with a cache in a
+// temporary directory.
+func newStubManager(t *testing.T) (*Manager, *stubProvider) {
+	t.Helper()
+	provider := &stubProvider{info: ProviderInfo{Type: "ollama", Model: "llama3.2"}, result: wantGS}
+	m := NewManager()
+	m.AddProvider("local", provider)
+	m.Cache = &Cache{Dir: t.TempDir()}
+	return m, provider
+}
+
+func TestRequestCacheKeyedByPrompt(t *testing.T) {
+	m, provider := newStubManager(t)
+	ctx := context.Background()
+	data := PromptData{Shell: "bash", Command: "git status", Aliases: []string{"ll"}}
+
+	steps := []struct {
+		name       string
+		change     func(data *PromptData)
+		wantCached bool
+	}{
+		{name: "first request", change: func(data *PromptData) {}},
+		{name: "same prompt", change: func(data *PromptData) {}, wantCached: true},
+		{name: "new existing alias", change: func(data *PromptData) { data.Aliases = append(data.Aliases, "gs") }},
+		{name: "same aliases again", change: func(data *PromptData) {}, wantCached: true},
+		{name: "naming conventions", change: func(data *PromptData) { data.Conventions = "Prefix git aliases with g." }},
+		{name: "other shell", change: func(data *PromptData) { data.Shell = "zsh" }},
+	}
+	calls := 0
+	for _, step := range steps {
+		step.change(&data)
+		result, err := m.GenerateAlias(ctx, data, "", nil)
+		if err != nil {
+			t.Fatalf("%s: GenerateAlias: %v", step.name, err)
+		}
+		if !step.wantCached {
+			calls++
+		}
+		if result.Cached != step.wantCached || provider.calls() != calls {
+			t.Errorf("%s: cached = %v after %d provider calls, want cached = %v after %d", step.name, result.Cached, provider.calls(), step.wantCached, calls)
+		}
+	}
+
+	// The same request as a different task is not answered from the cache
+	if result, err := m.AskCommand(ctx, PromptData{Shell: "bash", Request: "git status"}, "", nil); err != nil || result.Cached {
+		t.Errorf("AskCommand = %+v, %v; want a provider answer", result, err)
+	}
+}
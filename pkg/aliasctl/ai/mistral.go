@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// MistralProvider implements Provider for Mistral AI, whose chat API is compatible
+// with OpenAI's.
+type MistralProvider struct {
+	Endpoint  string            // The Mistral endpoint URL
+	APIKey    string            // The Mistral API key
+	Model     string            // The Mistral model name
+	Timeout   time.Duration     // The time allowed for a request, DefaultTimeout if zero
+	Headers   map[string]string // Extra headers sent with every request
+	Transport Transport         // How requests reach the endpoint, such as through a proxy
+	Client    *http.Client      // The HTTP client requests are sent with, the shared client if nil
+}
+
+// Info returns the configuration of the provider.
+func (mp *MistralProvider) Info() ProviderInfo {
+	return ProviderInfo{Type: "mistral", Endpoint: mp.Endpoint, APIKey: mp.APIKey, Model: mp.Model, Timeout: mp.Timeout, Headers: mp.Headers, Transport: mp.Transport, Client: mp.Client}
+}
+
+// service returns where and how chat completion requests are sent.
+func (mp *MistralProvider) service() chatService {
+	return chatService{
+		name:      "mistral",
+		keyHelp:   "regenerate it in the Mistral console",
+		configure: "aliasctl configure-ai mistral",
+		endpoint:  mp.Endpoint,
+		url:       mp.Endpoint + "/v1/chat/completions",
+		headers:   requestHeaders(map[string]string{"Authorization": "Bearer " + mp.APIKey}, mp.Headers),
+		client:    mp.Client,
+		apiKey:    mp.APIKey,
+		needsKey:  true,
+		model:     mp.Model,
+		timeout:   mp.Timeout,
+	}
+}
+
+// Complete answers a prompt using Mistral.
+func (mp *MistralProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
+	return mp.service().complete(ctx, prompt, stream)
+}
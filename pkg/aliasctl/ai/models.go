@@ -0,0 +1,160 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrModelsUnsupported is returned by ListModels for providers whose type cannot list
+// its models.
+var ErrModelsUnsupported = errors.New("cannot list their models")
+
+// ModelLister is implemented by providers that can list the models their endpoint serves.
+// Listing models needs a reachable endpoint and a valid API key, so it is also how a
+// provider's configuration is checked without sending a prompt.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error) // Returns the available model names, sorted
+}
+
+// modelList is the response of a model listing endpoint. OpenAI-compatible APIs and
+// Anthropic return data, Ollama and Gemini return models.
+type modelList struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// ListModels returns the models available to provider, sorted.
+// Returns an error wrapping ErrModelsUnsupported if the provider type cannot list its
+// models, or an error if the request fails.
+func ListModels(ctx context.Context, provider Provider) ([]string, error) {
+	lister, ok := provider.(ModelLister)
+	if !ok {
+		return nil, fmt.Errorf("%s providers %w", provider.Info().Type, ErrModelsUnsupported)
+	}
+	return lister.ListModels(ctx)
+}
+
+// HasModel reports whether model is one of models. A model name without a tag matches
+// the "latest" tag, as it does in Ollama.
+func HasModel(models []string, model string) bool {
+	for _, available := range models {
+		if available == model || (!strings.Contains(model, ":") && available == model+":latest") {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchModels gets a model list from url and returns the model names, sorted. The prefix,
+// such as Gemini's "models/", is removed from every name.
+func fetchModels(ctx context.Context, client *http.Client, url string, headers map[string]string, prefix string) ([]string, error) {
+	if err := ValidateEndpoint(url); err != nil {
+		return nil, err
+	}
+	respBody, err := MakeAPIRequest(ctx, client, "GET", url, headers, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var list modelList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse model list from %s: %w\n\nRaw response: %s", url, err, limitResponseText(string(respBody), 200))
+	}
+	models := make([]string, 0, len(list.Data)+len(list.Models))
+	for _, model := range list.Data {
+		models = append(models, model.ID)
+	}
+	for _, model := range list.Models {
+		models = append(models, strings.TrimPrefix(model.Name, prefix))
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// ListModels returns the models pulled into the Ollama server, from /api/tags.
+func (op *OllamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, op.Timeout, DefaultLocalTimeout)
+	defer cancel()
+	return fetchModels(ctx, op.Client, strings.TrimSuffix(op.Endpoint, "/")+"/api/tags", requestHeaders(nil, op.Headers), "")
+}
+
+// ListModels returns the models available to the API key, from /v1/models.
+func (op *OpenAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	return op.service().models(ctx)
+}
+
+// ListModels returns the models available to the API key, from /v1/models.
+func (mp *MistralProvider) ListModels(ctx context.Context) ([]string, error) {
+	return mp.service().models(ctx)
+}
+
+// ListModels returns the models loaded by the server, from /v1/models.
+func (lp *LlamaCppProvider) ListModels(ctx context.Context) ([]string, error) {
+	return lp.service().models(ctx)
+}
+
+// models lists the models of an OpenAI-compatible API, from /v1/models.
+func (cs chatService) models(ctx context.Context) ([]string, error) {
+	if cs.defaultTimeout == 0 {
+		cs.defaultTimeout = DefaultTimeout
+	}
+	ctx, cancel := withTimeout(ctx, cs.timeout, cs.defaultTimeout)
+	defer cancel()
+
+	if cs.needsKey && cs.apiKey == "" {
+		return nil, fmt.Errorf("%s API key is empty: please configure a valid API key with '%s'", cs.name, cs.configure)
+	}
+	models, err := fetchModels(ctx, cs.client, strings.TrimSuffix(cs.endpoint, "/")+"/v1/models", cs.headers, "")
+	if err != nil && strings.Contains(err.Error(), "401") {
+		return nil, fmt.Errorf("%s API authentication error: invalid API key. Check your API key or %s", cs.name, cs.keyHelp)
+	}
+	return models, err
+}
+
+// ListModels returns the models available to the API key, from /v1/models.
+func (ap *AnthropicProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, ap.Timeout, DefaultTimeout)
+	defer cancel()
+
+	if ap.APIKey == "" {
+		return nil, fmt.Errorf("anthropic API key is empty: please configure a valid API key with 'aliasctl configure-anthropic'")
+	}
+	headers := requestHeaders(map[string]string{
+		"x-api-key":         ap.APIKey,
+		"anthropic-version": "2023-06-01",
+	}, ap.Headers)
+	models, err := fetchModels(ctx, ap.Client, strings.TrimSuffix(ap.Endpoint, "/")+"/v1/models?limit=1000", headers, "")
+	if err != nil {
+		return nil, ap.requestError(err)
+	}
+	return models, nil
+}
+
+// ListModels returns the models available to the API key, from /v1beta/models.
+func (gp *GeminiProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, gp.Timeout, DefaultTimeout)
+	defer cancel()
+
+	if gp.APIKey == "" {
+		return nil, fmt.Errorf("gemini API key is empty: please configure a valid API key with 'aliasctl configure-ai gemini'")
+	}
+	headers := requestHeaders(map[string]string{"x-goog-api-key": gp.APIKey}, gp.Headers)
+	models, err := fetchModels(ctx, gp.Client, strings.TrimSuffix(gp.Endpoint, "/")+"/v1beta/models?pageSize=1000", headers, "models/")
+	if err != nil {
+		// A missing model list means a wrong endpoint, not a wrong model
+		if strings.Contains(err.Error(), "404") {
+			return nil, fmt.Errorf("gemini request failed: %w", err)
+		}
+		return nil, gp.requestError(err)
+	}
+	return models, nil
+}
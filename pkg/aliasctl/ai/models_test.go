@@ -0,0 +1,39 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestListModelsUnsupported(t *testing.T) {
+	provider := &stubProvider{info: ProviderInfo{Type: "stub", Model: "m"}}
+	models, err := ListModels(context.Background(), provider)
+	if !errors.Is(err, ErrModelsUnsupported) {
+		t.Fatalf("ListModels = %v, %v; want ErrModelsUnsupported", models, err)
+	}
+	if err.Error() != "stub providers cannot list their models" {
+		t.Errorf("error = %q, want the provider type named", err)
+	}
+}
+
+func TestHasModel(t *testing.T) {
+	models := []string{"gpt-4o", "llama3.2:latest", "qwen2.5-coder:7b"}
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"gpt-4o", true},
+		{"gpt-4", false},
+		{"llama3.2", true},
+		{"llama3.2:latest", true},
+		{"llama3.2:1b", false},
+		{"qwen2.5-coder", false},
+		{"qwen2.5-coder:7b", true},
+	}
+	for _, tt := range tests {
+		if got := HasModel(models, tt.model); got != tt.want {
+			t.Errorf("HasModel(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
@@ -1,112 +1,117 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 )
 
 // OllamaProvider implements Provider for Ollama.
 type OllamaProvider struct {
-	Endpoint string // The Ollama endpoint URL
-	Model    string // The Ollama model name
+	Endpoint  string            // The Ollama endpoint URL
+	Model     string            // The Ollama model name
+	Timeout   time.Duration     // The time allowed for a request, DefaultLocalTimeout if zero
+	Headers   map[string]string // Extra headers sent with every request
+	Transport Transport         // How requests reach the endpoint, such as through a proxy
+	Client    *http.Client      // The HTTP client requests are sent with, the shared client if nil
 }
 
-// GenerateAlias generates an alias using Ollama AI
-func (op *OllamaProvider) GenerateAlias(command, shellType string) (string, error) {
-	if err := ValidateEndpoint(op.Endpoint); err != nil {
-		return "", err
-	}
-
-	prompt := GenerationPrompt(command, shellType)
-
-	requestBody, err := json.Marshal(map[string]any{
-		"model":  op.Model,
-		"prompt": prompt,
-		"stream": false, // Disable streaming to get full response
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to create Ollama request: %w", err)
-	}
-
-	respBody, err := MakeAPIRequest("POST", op.Endpoint+"/api/generate", nil, requestBody)
-	if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			return "", fmt.Errorf("failed to connect to Ollama at %s: make sure Ollama is running with 'ollama serve'", op.Endpoint)
-		}
-		return "", fmt.Errorf("ollama request failed: %w", err)
-	}
-
-	// Define a struct to match the Ollama response
-	var ollamaResponse struct {
-		Response string `json:"response"`
-		Done     bool   `json:"done"`
-		Error    string `json:"error"`
-	}
-
-	err = json.Unmarshal(respBody, &ollamaResponse)
-	if err != nil {
-		return "", fmt.Errorf("error parsing ollama response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
-	}
-
-	// Check if Ollama returned an error
-	if ollamaResponse.Error != "" {
-		// Check for model-related errors
-		if strings.Contains(ollamaResponse.Error, "model") && strings.Contains(ollamaResponse.Error, "not found") {
-			return "", fmt.Errorf("ollama model '%s' not found: run 'ollama pull %s' to download it first", op.Model, op.Model)
-		}
-		return "", fmt.Errorf("ollama error: %s", ollamaResponse.Error)
-	}
+// ollamaResponse is a response, or a line of a streamed response, from /api/generate.
+type ollamaResponse struct {
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"` // The input tokens, set when done
+	EvalCount       int    `json:"eval_count"`        // The output tokens, set when done
+}
 
-	// Parse the alias from the response
-	return ExtractAliasDefinition(ollamaResponse.Response), nil
+// Info returns the configuration of the provider.
+func (op *OllamaProvider) Info() ProviderInfo {
+	return ProviderInfo{Type: "ollama", Endpoint: op.Endpoint, Model: op.Model, Timeout: op.Timeout, Headers: op.Headers, Transport: op.Transport, Client: op.Client}
 }
 
-// ConvertAlias converts an alias using the Ollama AI service.
-func (op *OllamaProvider) ConvertAlias(alias, fromShell, toShell string) (string, error) {
+// Complete sends a prompt in JSON mode (format: json) and decodes the AliasResult.
+// Models that ignore JSON mode fall back to text scraping. With a stream function the
+// response is streamed and its decoded fields shown as they arrive (see decodedStream).
+func (op *OllamaProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
 	if err := ValidateEndpoint(op.Endpoint); err != nil {
-		return "", err
+		return AliasResult{}, err
 	}
-
-	prompt := ConversionPrompt(alias, fromShell, toShell)
+	ctx, cancel := withTimeout(ctx, op.Timeout, DefaultLocalTimeout)
+	defer cancel()
 
 	requestBody, err := json.Marshal(map[string]any{
-		"model":  op.Model,
-		"prompt": prompt,
-		"stream": false, // Disable streaming to get full response
+		"model":   op.Model,
+		"system":  prompt.System,
+		"prompt":  prompt.User,
+		"format":  "json",
+		"stream":  stream != nil,
+		"options": map[string]any{"temperature": prompt.Temperature},
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to create Ollama request: %w", err)
+		return AliasResult{}, fmt.Errorf("failed to create Ollama request: %w", err)
 	}
 
-	respBody, err := MakeAPIRequest("POST", op.Endpoint+"/api/generate", nil, requestBody)
-	if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			return "", fmt.Errorf("failed to connect to Ollama at %s: make sure Ollama is running with 'ollama serve'", op.Endpoint)
+	var response strings.Builder
+	var usage Usage
+	var responseErr error // An error reported in the response rather than by the request
+	if stream != nil {
+		// Each line of the stream is a JSON object with the next piece of the response
+		stream = decodedStream(stream)
+		err = MakeStreamingRequest(ctx, op.Client, "POST", op.Endpoint+"/api/generate", requestHeaders(nil, op.Headers), requestBody, func(line string) error {
+			var chunk ollamaResponse
+			if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+				responseErr = fmt.Errorf("error parsing ollama response: %w\n\nRaw response: %s", err, limitResponseText(line, 200))
+				return responseErr
+			}
+			if chunk.Error != "" {
+				responseErr = op.responseError(chunk.Error)
+				return responseErr
+			}
+			response.WriteString(chunk.Response)
+			stream(chunk.Response)
+			if chunk.Done {
+				usage = Usage{InputTokens: chunk.PromptEvalCount, OutputTokens: chunk.EvalCount}
+			}
+			return nil
+		})
+	} else {
+		var respBody []byte
+		if respBody, err = MakeAPIRequest(ctx, op.Client, "POST", op.Endpoint+"/api/generate", requestHeaders(nil, op.Headers), requestBody); err == nil {
+			var result ollamaResponse
+			if err := json.Unmarshal(respBody, &result); err != nil {
+				return AliasResult{}, fmt.Errorf("error parsing ollama response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
+			}
+			if result.Error != "" {
+				return AliasResult{}, op.responseError(result.Error)
+			}
+			response.WriteString(result.Response)
+			usage = Usage{InputTokens: result.PromptEvalCount, OutputTokens: result.EvalCount}
 		}
-		return "", fmt.Errorf("ollama request failed: %w", err)
 	}
-
-	// Define a struct to match the Ollama response
-	var ollamaResponse struct {
-		Response string `json:"response"`
-		Done     bool   `json:"done"`
-		Error    string `json:"error"`
+	if responseErr != nil {
+		return AliasResult{}, responseErr
 	}
-
-	err = json.Unmarshal(respBody, &ollamaResponse)
 	if err != nil {
-		return "", fmt.Errorf("error parsing ollama response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
-	}
-
-	// Check if Ollama returned an error
-	if ollamaResponse.Error != "" {
-		// Check for model-related errors
-		if strings.Contains(ollamaResponse.Error, "model") && strings.Contains(ollamaResponse.Error, "not found") {
-			return "", fmt.Errorf("ollama model '%s' not found: run 'ollama pull %s' to download it first", op.Model, op.Model)
+		if strings.Contains(err.Error(), "connection refused") {
+			return AliasResult{}, fmt.Errorf("failed to connect to Ollama at %s: make sure Ollama is running with 'ollama serve'", op.Endpoint)
 		}
-		return "", fmt.Errorf("ollama error: %s", ollamaResponse.Error)
+		return AliasResult{}, fmt.Errorf("ollama request failed: %w", err)
 	}
 
-	return ExtractAliasDefinition(ollamaResponse.Response), nil
+	result := ParseAliasResult(response.String())
+	result.Usage = usage
+	return result, nil
+}
+
+// responseError explains an error reported by Ollama in its response.
+func (op *OllamaProvider) responseError(message string) error {
+	// Check for model-related errors
+	if strings.Contains(message, "model") && strings.Contains(message, "not found") {
+		return fmt.Errorf("ollama model '%s' not found: run 'ollama pull %s' to download it first", op.Model, op.Model)
+	}
+	return fmt.Errorf("ollama error: %s", message)
 }
@@ -1,146 +1,216 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 )
 
 // OpenAIProvider implements Provider for OpenAI-compatible APIs.
 type OpenAIProvider struct {
-	Endpoint string // The OpenAI endpoint URL
-	APIKey   string // The OpenAI API key
-	Model    string // The OpenAI model name
+	Endpoint  string            // The OpenAI endpoint URL
+	APIKey    string            // The OpenAI API key
+	Model     string            // The OpenAI model name
+	Timeout   time.Duration     // The time allowed for a request, DefaultTimeout if zero
+	Headers   map[string]string // Extra headers sent with every request
+	Transport Transport         // How requests reach the endpoint, such as through a proxy
+	Client    *http.Client      // The HTTP client requests are sent with, the shared client if nil
 }
 
-// GenerateAlias generates an alias using OpenAI
-func (op *OpenAIProvider) GenerateAlias(command, shellType string) (string, error) {
-	if err := ValidateEndpoint(op.Endpoint); err != nil {
-		return "", err
+// Info returns the configuration of the provider.
+func (op *OpenAIProvider) Info() ProviderInfo {
+	return ProviderInfo{Type: "openai", Endpoint: op.Endpoint, APIKey: op.APIKey, Model: op.Model, Timeout: op.Timeout, Headers: op.Headers, Transport: op.Transport, Client: op.Client}
+}
+
+// service returns where and how chat completion requests are sent.
+func (op *OpenAIProvider) service() chatService {
+	return chatService{
+		name:        "openAI",
+		keyHelp:     "regenerate it in the OpenAI dashboard",
+		configure:   "aliasctl configure-openai",
+		endpoint:    op.Endpoint,
+		url:         op.Endpoint + "/v1/chat/completions",
+		headers:     requestHeaders(map[string]string{"Authorization": "Bearer " + op.APIKey}, op.Headers),
+		client:      op.Client,
+		apiKey:      op.APIKey,
+		needsKey:    true,
+		model:       op.Model,
+		timeout:     op.Timeout,
+		streamUsage: true,
 	}
+}
 
-	// Check API key
-	if op.APIKey == "" {
-		return "", fmt.Errorf("openAI API key is empty: please configure a valid API key with 'aliasctl configure-openai'")
+// Complete answers a prompt using the OpenAI-compatible API.
+func (op *OpenAIProvider) Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
+	return op.service().complete(ctx, prompt, stream)
+}
+
+// chatService is an API that accepts OpenAI chat completion requests. Besides OpenAI, it
+// is used by the providers of compatible services.
+type chatService struct {
+	name           string            // The service name used in error messages, such as "openAI"
+	keyHelp        string            // How to fix a rejected API key, for error messages
+	configure      string            // The command that configures the provider, for error messages
+	endpoint       string            // The configured endpoint URL
+	url            string            // The chat completions URL
+	headers        map[string]string // Request headers, such as the authorization
+	client         *http.Client      // The HTTP client requests are sent with, the shared client if nil
+	apiKey         string            // The API key, checked when needsKey is set
+	needsKey       bool              // Whether requests fail without an API key
+	model          string            // The model sent with requests; empty to leave it out
+	timeout        time.Duration     // The time allowed for a request
+	defaultTimeout time.Duration     // The time allowed when timeout is zero, DefaultTimeout if zero
+	streamUsage    bool              // Whether streamed answers are asked to end with the usage (stream_options)
+}
+
+// complete requests an AliasResult with a JSON schema response_format. Compatible servers
+// that reject response_format (status 400) are asked again with the text prompt, and the
+// answer is scraped. With a stream function the answer is streamed as server-sent events.
+func (cs chatService) complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) {
+	if err := ValidateEndpoint(cs.endpoint); err != nil {
+		return AliasResult{}, err
 	}
+	if cs.defaultTimeout == 0 {
+		cs.defaultTimeout = DefaultTimeout
+	}
+	ctx, cancel := withTimeout(ctx, cs.timeout, cs.defaultTimeout)
+	defer cancel()
 
-	requestBody, err := json.Marshal(map[string]any{
-		"model": op.Model,
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": fmt.Sprintf("You are a shell alias creation expert for %s shell. Create concise, memorable aliases with proper syntax.", shellType),
-			},
-			{
-				"role":    "user",
-				"content": GenerationPrompt(command, shellType),
+	// Check API key
+	if cs.needsKey && cs.apiKey == "" {
+		return AliasResult{}, fmt.Errorf("%s API key is empty: please configure a valid API key with '%s'", cs.name, cs.configure)
+	}
+
+	var messages []map[string]string
+	if prompt.System != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": prompt.System})
+	}
+	user := map[string]string{"role": "user", "content": prompt.User}
+	messages = append(messages, user)
+	request := map[string]any{
+		"messages":    messages,
+		"temperature": prompt.Temperature,
+		"response_format": map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "alias",
+				"strict": true,
+				"schema": aliasResultSchema,
 			},
 		},
-		"temperature": 0.3, // Moderate creativity
-	})
-	if err != nil {
-		return "", fmt.Errorf("failed to create OpenAI request: %w", err)
 	}
-
-	// Prepare headers
-	headers := map[string]string{
-		"Authorization": "Bearer " + op.APIKey,
+	if cs.model != "" {
+		request["model"] = cs.model
 	}
-
-	respBody, err := MakeAPIRequest("POST", op.Endpoint+"/v1/chat/completions", headers, requestBody)
-	if err != nil {
-		// Check for authentication errors
-		if strings.Contains(err.Error(), "401") {
-			return "", fmt.Errorf("openAI API authentication error: invalid API key. Check your API key or regenerate it in the OpenAI dashboard")
-		}
-
-		// Check for model errors
-		if strings.Contains(err.Error(), "model") && strings.Contains(err.Error(), "does not exist") {
-			return "", fmt.Errorf("openAI model '%s' not found: check available models in your OpenAI account", op.Model)
+	if stream != nil {
+		request["stream"] = true
+		if cs.streamUsage {
+			request["stream_options"] = map[string]any{"include_usage": true}
 		}
-
-		return "", fmt.Errorf("openAI request failed: %w", err)
 	}
 
-	var result map[string]any
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("failed to parse OpenAI response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
-	}
-
-	// Check for error in response
-	if errObj, hasErr := result["error"].(map[string]any); hasErr {
-		errMsg := "unknown error"
-		if msg, ok := errObj["message"].(string); ok {
-			errMsg = msg
-		}
-		return "", fmt.Errorf("openAI API error: %s", errMsg)
+	content, usage, err := cs.chat(ctx, request, stream)
+	var status *StatusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusBadRequest {
+		delete(request, "response_format")
+		user["content"] = prompt.Text
+		content, usage, err = cs.chat(ctx, request, stream)
 	}
-
-	if choices, ok := result["choices"].([]any); ok && len(choices) > 0 {
-		if choice, ok := choices[0].(map[string]any); ok {
-			if message, ok := choice["message"].(map[string]any); ok {
-				if content, ok := message["content"].(string); ok {
-					return ExtractAliasDefinition(content), nil
-				}
-			}
-		}
+	if err != nil {
+		return AliasResult{}, err
 	}
-
-	return "", fmt.Errorf("unexpected response format from OpenAI: couldn't extract content from response\n\nResponse: %s", limitResponseText(string(respBody), 200))
+	result := ParseAliasResult(content)
+	result.Usage = usage
+	return result, nil
 }
 
-// ConvertAlias converts an alias using the OpenAI-compatible API.
-func (op *OpenAIProvider) ConvertAlias(alias, fromShell, toShell string) (string, error) {
-	if err := ValidateEndpoint(op.Endpoint); err != nil {
-		return "", err
-	}
-
-	// Check API key
-	if op.APIKey == "" {
-		return "", fmt.Errorf("openAI API key is empty: please configure a valid API key with 'aliasctl configure-openai'")
-	}
+// chatUsage is the usage of a chat completion, in a response or the last streamed chunk.
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
 
-	requestBody, err := json.Marshal(map[string]any{
-		"model": op.Model,
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": "You are a utility that converts command line aliases between different shells. Respond only with the converted command, no explanation.",
-			},
-			{
-				"role":    "user",
-				"content": ConversionPrompt(alias, fromShell, toShell),
-			},
-		},
-		"temperature": 0.2, // Lower temperature for more deterministic results
-	})
+// chat sends a chat completion request and returns the content of the first choice and
+// the usage, if the service reports it. With a stream function the content is read from
+// the streamed deltas, and its decoded fields are shown on stream (see decodedStream).
+func (cs chatService) chat(ctx context.Context, request map[string]any, stream StreamFunc) (string, Usage, error) {
+	stream = decodedStream(stream)
+	requestBody, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to create OpenAI request: %w", err)
+		return "", Usage{}, fmt.Errorf("failed to create %s request: %w", cs.name, err)
+	}
+
+	var respBody []byte
+	var content strings.Builder
+	var usage Usage
+	var responseErr error // An error reported in the stream rather than by the request
+	if stream != nil {
+		err = MakeStreamingRequest(ctx, cs.client, "POST", cs.url, cs.headers, requestBody, func(line string) error {
+			data, ok := strings.CutPrefix(line, "data:")
+			if !ok {
+				return nil
+			}
+			if data = strings.TrimSpace(data); data == "[DONE]" {
+				return nil
+			}
+			var chunk struct {
+				Choices []struct {
+					Delta struct {
+						Content string `json:"content"`
+					} `json:"delta"`
+				} `json:"choices"`
+				Usage *chatUsage `json:"usage"`
+				Error *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				responseErr = fmt.Errorf("failed to parse %s response: %w\n\nRaw response: %s", cs.name, err, limitResponseText(data, 200))
+				return responseErr
+			}
+			if chunk.Error != nil {
+				responseErr = fmt.Errorf("%s API error: %s", cs.name, chunk.Error.Message)
+				return responseErr
+			}
+			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+				content.WriteString(chunk.Choices[0].Delta.Content)
+				stream(chunk.Choices[0].Delta.Content)
+			}
+			if chunk.Usage != nil {
+				usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+			}
+			return nil
+		})
+	} else {
+		respBody, err = MakeAPIRequest(ctx, cs.client, "POST", cs.url, cs.headers, requestBody)
 	}
-
-	// Prepare headers
-	headers := map[string]string{
-		"Authorization": "Bearer " + op.APIKey,
+	if responseErr != nil {
+		return "", Usage{}, responseErr
 	}
-
-	respBody, err := MakeAPIRequest("POST", op.Endpoint+"/v1/chat/completions", headers, requestBody)
 	if err != nil {
 		// Check for authentication errors
 		if strings.Contains(err.Error(), "401") {
-			return "", fmt.Errorf("openAI API authentication error: invalid API key. Check your API key or regenerate it in the OpenAI dashboard")
+			return "", Usage{}, fmt.Errorf("%s API authentication error: invalid API key. Check your API key or %s", cs.name, cs.keyHelp)
 		}
 
 		// Check for model errors
 		if strings.Contains(err.Error(), "model") && strings.Contains(err.Error(), "does not exist") {
-			return "", fmt.Errorf("openAI model '%s' not found: check available models in your OpenAI account", op.Model)
+			return "", Usage{}, fmt.Errorf("%s model '%s' not found: check the models available to your account", cs.name, cs.model)
 		}
 
-		return "", fmt.Errorf("openAI request failed: %w", err)
+		return "", Usage{}, fmt.Errorf("%s request failed: %w", cs.name, err)
+	}
+	if stream != nil {
+		return content.String(), usage, nil
 	}
 
 	var result map[string]any
 	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", fmt.Errorf("failed to parse OpenAI response: %w\n\nRaw response: %s", err, limitResponseText(string(respBody), 200))
+		return "", Usage{}, fmt.Errorf("failed to parse %s response: %w\n\nRaw response: %s", cs.name, err, limitResponseText(string(respBody), 200))
 	}
 
 	// Check for error in response
@@ -149,18 +219,24 @@ func (op *OpenAIProvider) ConvertAlias(alias, fromShell, toShell string) (string
 		if msg, ok := errObj["message"].(string); ok {
 			errMsg = msg
 		}
-		return "", fmt.Errorf("openAI API error: %s", errMsg)
+		return "", Usage{}, fmt.Errorf("%s API error: %s", cs.name, errMsg)
+	}
+
+	if reported, ok := result["usage"].(map[string]any); ok {
+		prompt, _ := reported["prompt_tokens"].(float64)
+		completion, _ := reported["completion_tokens"].(float64)
+		usage = Usage{InputTokens: int(prompt), OutputTokens: int(completion)}
 	}
 
 	if choices, ok := result["choices"].([]any); ok && len(choices) > 0 {
 		if choice, ok := choices[0].(map[string]any); ok {
 			if message, ok := choice["message"].(map[string]any); ok {
 				if content, ok := message["content"].(string); ok {
-					return ExtractAliasDefinition(content), nil
+					return content, usage, nil
 				}
 			}
 		}
 	}
 
-	return "", fmt.Errorf("unexpected response format from OpenAI: couldn't extract content from response\n\nResponse: %s", limitResponseText(string(respBody), 200))
+	return "", Usage{}, fmt.Errorf("unexpected response format from %s: couldn't extract content from response\n\nResponse: %s", cs.name, limitResponseText(string(respBody), 200))
 }
@@ -0,0 +1,273 @@
+package ai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Prompt tasks. Each task has a text/template prompt template of the same name.
+const (
+	TaskGenerate = "generate"
+	TaskConvert  = "convert"
+	TaskExplain  = "explain"
+	TaskAsk      = "ask"
+)
+
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+// Prompt is a request for an AliasResult, rendered from a prompt template.
+type Prompt struct {
+	System      string  // Instructions sent as the system prompt, where the API has one
+	User        string  // The request, asking for the AliasResult as a JSON object
+	Text        string  // The request asking for a plain text answer, for servers without structured output
+	Temperature float64 // The sampling temperature
+}
+
+// PromptData holds the values a prompt template is executed with.
+type PromptData struct {
+	Shell       string   // The shell the answer is for; for conversions, the target shell
+	FromShell   string   // The shell the command is written for, set for conversions
+	Command     string   // The command to create an alias for, convert or explain
+	Name        string   // The name of the alias to explain
+	Request     string   // What the command should do, in the user's words, for ask
+	Aliases     []string // The names of the existing aliases, which a new alias must not reuse
+	Conventions string   // The team's alias naming conventions, empty if there are none
+}
+
+// promptTask is what a task adds to its template: the response format and temperature.
+type promptTask struct {
+	temperature float64
+	format      func(data PromptData) string // Asks for the AliasResult as a JSON object
+	textFormat  func(data PromptData) string // Asks for a plain text answer
+}
+
+// promptTasks holds the tasks by name.
+var promptTasks = map[string]promptTask{
+	TaskGenerate: {
+		temperature: 0.3, // Moderate creativity
+		format: func(data PromptData) string {
+			return fmt.Sprintf(`Response format:
+Respond with a JSON object with these fields:
+- "name": the alias name
+- "command": the command the alias runs in %s syntax, without the alias definition around it
+- "shell": "%s"
+- "explanation": one sentence on why the name fits the command
+
+Do not include anything outside the JSON object.`, data.Shell, data.Shell)
+		},
+		textFormat: aliasDefinitionFormat,
+	},
+	TaskConvert: {
+		temperature: 0.2, // Lower temperature for more deterministic results
+		format: func(data PromptData) string {
+			return fmt.Sprintf(`Respond with a JSON object with these fields:
+- "name": an empty string
+- "command": the converted command in %s syntax, without an alias or function definition around it
+- "shell": "%s"
+- "explanation": one sentence on what had to change
+
+Do not include anything outside the JSON object.`, data.Shell, data.Shell)
+		},
+		textFormat: func(data PromptData) string {
+			return "Provide only the final command without explanation."
+		},
+	},
+	TaskExplain: {
+		temperature: 0.2,
+		format: func(data PromptData) string {
+			return fmt.Sprintf(`Respond with a JSON object with these fields:
+- "name": "%s"
+- "command": the command exactly as given
+- "shell": "%s"
+- "explanation": the explanation, in the layout below
+
+%s
+
+Do not include anything outside the JSON object.`, data.Name, data.Shell, explanationLayout)
+		},
+		textFormat: func(data PromptData) string {
+			return explanationLayout + "\n\nAnswer in plain text and do not repeat the alias definition."
+		},
+	},
+	TaskAsk: {
+		temperature: 0.2,
+		format: func(data PromptData) string {
+			return fmt.Sprintf(`Response format:
+Respond with a JSON object with these fields:
+- "name": the alias name
+- "command": the command in %s syntax, without the alias definition around it
+- "shell": "%s"
+- "explanation": one sentence on what the command does
+
+Do not include anything outside the JSON object.`, data.Shell, data.Shell)
+		},
+		textFormat: aliasDefinitionFormat,
+	},
+}
+
+// aliasDefinitionFormat asks for a plain text alias definition.
+func aliasDefinitionFormat(data PromptData) string {
+	return fmt.Sprintf(`Response format:
+Provide ONLY the complete alias definition in the correct syntax for %s shell.
+- For bash/zsh: alias name='command'
+- For PowerShell: Set-Alias name command or function name { command }
+- For CMD: doskey name=command
+- For fish: alias name 'command' or function name\n    command\nend
+
+Do not include any explanations, preambles, or additional text.`, data.Shell)
+}
+
+// explanationLayout is the layout asked for in explanations. Its first line is short
+// enough to serve as the alias description.
+const explanationLayout = `Explanation layout:
+- First line: one sentence on what the alias does, without the alias name
+- Then "Flags:" followed by one line per option or argument, as "- option: meaning"
+- Then "Risks:" followed by one line per risk, such as deleted data or network access, or "- none"`
+
+// templateFuncs are the functions available in prompt templates.
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// PromptTasks returns the names of the tasks that have a prompt template, sorted.
+func PromptTasks() []string {
+	tasks := make([]string, 0, len(promptTasks))
+	for task := range promptTasks {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+	return tasks
+}
+
+// Prompts renders the prompts sent to providers. Each task has a built-in template,
+// which a file named <task>.tmpl in Dir replaces. A replacement that does not define
+// the "system" template keeps the built-in system prompt.
+// A nil *Prompts uses the built-in templates.
+type Prompts struct {
+	Dir string // The directory holding the replacement templates, empty for none
+}
+
+// Path returns the file that replaces the built-in template of task.
+func (p *Prompts) Path(task string) string {
+	if p == nil || p.Dir == "" {
+		return ""
+	}
+	return filepath.Join(p.Dir, task+".tmpl")
+}
+
+// DefaultTemplate returns the built-in template of task.
+func DefaultTemplate(task string) (string, error) {
+	if _, ok := promptTasks[task]; !ok {
+		return "", fmt.Errorf("unknown prompt template '%s' (available: %s)", task, strings.Join(PromptTasks(), ", "))
+	}
+	source, err := builtinPrompts.ReadFile("prompts/" + task + ".tmpl")
+	if err != nil {
+		return "", err
+	}
+	return string(source), nil
+}
+
+// Source returns the template of task: the replacement in Dir if there is one, otherwise
+// the built-in template. custom reports whether it is a replacement.
+func (p *Prompts) Source(task string) (source string, custom bool, err error) {
+	builtin, err := DefaultTemplate(task)
+	if err != nil {
+		return "", false, err
+	}
+	path := p.Path(task)
+	if path == "" {
+		return builtin, false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return builtin, false, nil
+		}
+		return "", false, fmt.Errorf("failed to read prompt template %s: %w", path, err)
+	}
+	return string(data), true, nil
+}
+
+// Version identifies the template of task, so answers to prompts from an earlier
+// template are not taken from the cache: "builtin" for the built-in template, otherwise
+// a hash of the replacement.
+func (p *Prompts) Version(task string) string {
+	source, custom, err := p.Source(task)
+	if err != nil || !custom {
+		return "builtin"
+	}
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Render executes the template of task with data and returns the prompt to send.
+// Returns an error if the template cannot be read, parsed or executed.
+func (p *Prompts) Render(task string, data PromptData) (Prompt, error) {
+	source, custom, err := p.Source(task)
+	if err != nil {
+		return Prompt{}, err
+	}
+	prompt, err := renderPrompt(task, source, data)
+	if err != nil && custom {
+		return Prompt{}, fmt.Errorf("%w (fix %s with 'aliasctl ai prompt edit %s', or restore the built-in template with 'aliasctl ai prompt reset %s')", err, p.Path(task), task, task)
+	}
+	return prompt, err
+}
+
+// CheckTemplate reports whether source can replace the template of task, by parsing it
+// and rendering it with sample values.
+func CheckTemplate(task, source string) error {
+	_, err := renderPrompt(task, source, PromptData{
+		Shell:       "bash",
+		FromShell:   "zsh",
+		Command:     "ls -la",
+		Name:        "ll",
+		Request:     "list all files with details",
+		Aliases:     []string{"ll", "gs"},
+		Conventions: "Prefix git aliases with g.",
+	})
+	return err
+}
+
+// renderPrompt executes source, on top of the built-in template of task, with data.
+func renderPrompt(task, source string, data PromptData) (Prompt, error) {
+	builtin, err := DefaultTemplate(task)
+	if err != nil {
+		return Prompt{}, err
+	}
+	tmpl, err := template.New(task).Funcs(templateFuncs).Parse(builtin)
+	if err != nil {
+		return Prompt{}, fmt.Errorf("invalid built-in %s prompt template: %w", task, err)
+	}
+	if source != builtin {
+		if tmpl, err = tmpl.Parse(source); err != nil {
+			return Prompt{}, fmt.Errorf("invalid %s prompt template: %w", task, err)
+		}
+	}
+
+	var body, system bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return Prompt{}, fmt.Errorf("failed to render %s prompt template: %w", task, err)
+	}
+	if err := tmpl.ExecuteTemplate(&system, "system", data); err != nil {
+		return Prompt{}, fmt.Errorf("failed to render %s prompt template: %w", task, err)
+	}
+
+	taskInfo := promptTasks[task]
+	text := strings.TrimSpace(body.String())
+	return Prompt{
+		System:      strings.TrimSpace(system.String()),
+		User:        text + "\n\n" + taskInfo.format(data),
+		Text:        text + "\n\n" + taskInfo.textFormat(data),
+		Temperature: taskInfo.temperature,
+	}, nil
+}
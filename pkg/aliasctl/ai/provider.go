@@ -1,29 +1,44 @@
 package ai
 
-import "strings"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
-// Provider interface for AI services.
+// Provider interface for AI services. Providers answer prompts rendered from the prompt
+// templates (see Prompts), so generating and converting aliases is the same request.
+// Requests end when ctx is canceled or the provider's timeout passes. If stream is not
+// nil, the response is streamed and the command and explanation are passed to stream as
+// they arrive, or the text of an answer that is not structured.
 type Provider interface {
-	ConvertAlias(alias, fromShell, toShell string) (string, error) // Converts an alias from one shell to another
-	GenerateAlias(command, shellType string) (string, error)       // Generates an alias for a command
+	Complete(ctx context.Context, prompt Prompt, stream StreamFunc) (AliasResult, error) // Answers a prompt with an AliasResult
+	Info() ProviderInfo                                                                  // Describes the provider configuration
 }
 
-// ParseAliasCommand attempts to extract an alias command from AI response text
-func ParseAliasCommand(responseText string) string {
-	// Clean up the response
-	responseText = strings.TrimSpace(responseText)
-
-	// Extract just the command if possible
-	lines := strings.Split(responseText, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "alias ") ||
-			strings.HasPrefix(line, "function ") ||
-			strings.HasPrefix(line, "Set-Alias ") ||
-			strings.HasPrefix(line, "doskey ") {
-			return line
-		}
-	}
+// ProviderInfo describes the configuration of a provider. It holds everything the
+// provider was created with, so ProviderType.New(info) creates the same provider.
+type ProviderInfo struct {
+	Type      string            // The provider implementation type, such as "ollama"
+	Endpoint  string            // The endpoint URL requests are sent to
+	APIKey    string            // The API key, empty if the provider needs none
+	Model     string            // The model used for requests
+	Timeout   time.Duration     // The time allowed for a request, zero for the default
+	Options   map[string]string // Settings specific to the provider type, see ProviderType.Options
+	Headers   map[string]string // Extra headers sent with every request, such as for a gateway
+	Transport Transport         // How requests reach the endpoint, such as through a proxy
+	Client    *http.Client      // The HTTP client requests are sent with, created from Transport by NewProvider if nil
+}
 
-	return responseText
+// AliasResult is an alias produced by a provider. Providers ask for it as structured
+// output; if a model answers with plain text instead, only Raw is set.
+type AliasResult struct {
+	Name        string `json:"name"`        // The alias name, empty for conversions
+	Command     string `json:"command"`     // The command the alias runs, without definition syntax
+	Shell       string `json:"shell"`       // The shell the command is written for
+	Explanation string `json:"explanation"` // Why the provider chose this alias or conversion
+	Raw         string `json:"-"`           // The alias definition scraped from a text response
+	Provider    string `json:"-"`           // The name of the provider that answered, set by Manager
+	Cached      bool   `json:"-"`           // Whether the answer came from the response cache
+	Usage       Usage  `json:"-"`           // The tokens the request used, zero if not reported or cached
 }
@@ -0,0 +1,314 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fixture is a recorded API response served by fixtureServer.
+type fixture struct {
+	status int    // The response status, 200 if zero
+	file   string // The response body, a file in testdata
+}
+
+// fixtureServer serves the fixtures in order, one per request, at path, and records the
+// body of every request it receives.
+type fixtureServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []map[string]any
+}
+
+func newFixtureServer(t *testing.T, path string, fixtures ...fixture) *fixtureServer {
+	t.Helper()
+	server := &fixtureServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		var request map[string]any
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("request body is not JSON: %v\n%s", err, body)
+		}
+		server.requests = append(server.requests, request)
+		if len(server.requests) > len(fixtures) {
+			t.Errorf("unexpected request %d to %s", len(server.requests), path)
+			http.Error(w, "no fixture left", http.StatusTeapot)
+			return
+		}
+
+		response := fixtures[len(server.requests)-1]
+		data, err := os.ReadFile(filepath.Join("testdata", response.file))
+		if err != nil {
+			t.Errorf("reading fixture: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if response.status != 0 {
+			w.WriteHeader(response.status)
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// request returns the body of the i-th request the server received.
+func (s *fixtureServer) request(t *testing.T, i int) map[string]any {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i >= len(s.requests) {
+		t.Fatalf("server received %d requests, want at least %d", len(s.requests), i+1)
+	}
+	return s.requests[i]
+}
+
+// testPrompt is the prompt sent in the provider tests.
+var testPrompt = Prompt{
+	System: "You create shell aliases.",
+	User:   "Create a bash alias for: git status. Answer with JSON.",
+	Text:   "Create a bash alias for: git status.",
+}
+
+// wantGS is the answer recorded in the structured fixtures.
+var wantGS = AliasResult{Name: "gs", Command: "git status", Shell: "bash", Explanation: "Short for git status."}
+
+// checkResult compares the decoded fields and usage of an answer.
+func checkResult(t *testing.T, got, want AliasResult) {
+	t.Helper()
+	if got.Name != want.Name || got.Command != want.Command || got.Shell != want.Shell || got.Explanation != want.Explanation || got.Raw != want.Raw {
+		t.Errorf("result = %+v, want %+v", got, want)
+	}
+	if got.Usage != want.Usage {
+		t.Errorf("usage = %+v, want %+v", got.Usage, want.Usage)
+	}
+}
+
+func TestOpenAIComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures []fixture
+		want     AliasResult
+		wantErr  int // The status of the expected *StatusError, zero for success
+	}{
+		{
+			name:     "json schema",
+			fixtures: []fixture{{file: "openai_json_schema.json"}},
+			want:     AliasResult{Name: "gs", Command: "git status", Shell: "bash", Explanation: "Short for git status.", Usage: Usage{InputTokens: 112, OutputTokens: 24}},
+		},
+		{
+			name: "text fallback after 400",
+			fixtures: []fixture{
+				{status: http.StatusBadRequest, file: "openai_response_format_unsupported.json"},
+				{file: "openai_text.json"},
+			},
+			want: AliasResult{Raw: "alias gs='git status'", Usage: Usage{InputTokens: 80, OutputTokens: 18}},
+		},
+		{
+			name: "400 on the text request too",
+			fixtures: []fixture{
+				{status: http.StatusBadRequest, file: "openai_response_format_unsupported.json"},
+				{status: http.StatusBadRequest, file: "openai_response_format_unsupported.json"},
+			},
+			wantErr: http.StatusBadRequest,
+		},
+		{
+			name:     "not found",
+			fixtures: []fixture{{status: http.StatusNotFound, file: "openai_response_format_unsupported.json"}},
+			wantErr:  http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFixtureServer(t, "/v1/chat/completions", tt.fixtures...)
+			provider := &OpenAIProvider{Endpoint: server.URL, APIKey: "sk-test", Model: "gpt-4o-mini"}
+
+			got, err := provider.Complete(context.Background(), testPrompt, nil)
+			if tt.wantErr != 0 {
+				var status *StatusError
+				if !errors.As(err, &status) || status.StatusCode != tt.wantErr {
+					t.Fatalf("Complete error = %v, want a *StatusError with status %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			checkResult(t, got, tt.want)
+
+			first := server.request(t, 0)
+			format, _ := first["response_format"].(map[string]any)
+			if format["type"] != "json_schema" {
+				t.Errorf("response_format = %v, want json_schema", first["response_format"])
+			}
+			if len(tt.fixtures) > 1 {
+				retry := server.request(t, 1)
+				if _, ok := retry["response_format"]; ok {
+					t.Error("text fallback still sent response_format")
+				}
+				messages, _ := retry["messages"].([]any)
+				last, _ := messages[len(messages)-1].(map[string]any)
+				if last["content"] != testPrompt.Text {
+					t.Errorf("text fallback asked %q, want the text prompt", last["content"])
+				}
+			}
+		})
+	}
+}
+
+func TestAnthropicComplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture fixture
+		want    AliasResult
+		wantErr int
+	}{
+		{
+			name:    "tool use",
+			fixture: fixture{file: "anthropic_tool_use.json"},
+			want:    AliasResult{Name: "gs", Command: "git status", Shell: "bash", Explanation: "Short for git status.", Usage: Usage{InputTokens: 403, OutputTokens: 61}},
+		},
+		{
+			name:    "text block fallback",
+			fixture: fixture{file: "anthropic_text.json"},
+			want:    AliasResult{Name: "gs", Command: "git status", Shell: "bash", Explanation: "Short for git status.", Usage: Usage{InputTokens: 398, OutputTokens: 40}},
+		},
+		{
+			name:    "invalid request",
+			fixture: fixture{status: http.StatusBadRequest, file: "anthropic_invalid_request.json"},
+			wantErr: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFixtureServer(t, "/v1/messages", tt.fixture)
+			provider := &AnthropicProvider{Endpoint: server.URL, APIKey: "sk-ant-test", Model: "claude-3-5-haiku-latest"}
+
+			got, err := provider.Complete(context.Background(), testPrompt, nil)
+			if tt.wantErr != 0 {
+				var status *StatusError
+				if !errors.As(err, &status) || status.StatusCode != tt.wantErr {
+					t.Fatalf("Complete error = %v, want a *StatusError with status %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			checkResult(t, got, tt.want)
+
+			request := server.request(t, 0)
+			choice, _ := request["tool_choice"].(map[string]any)
+			if choice["type"] != "tool" || choice["name"] != anthropicAliasTool {
+				t.Errorf("tool_choice = %v, want the %s tool", request["tool_choice"], anthropicAliasTool)
+			}
+			if request["system"] != testPrompt.System {
+				t.Errorf("system = %v, want %q", request["system"], testPrompt.System)
+			}
+		})
+	}
+}
+
+func TestOllamaComplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture fixture
+		want    AliasResult
+		wantErr int
+	}{
+		{
+			name:    "format json",
+			fixture: fixture{file: "ollama_json.json"},
+			want:    AliasResult{Name: "gs", Command: "git status", Shell: "bash", Explanation: "Short for git status.", Usage: Usage{InputTokens: 96, OutputTokens: 31}},
+		},
+		{
+			name:    "model ignoring json mode",
+			fixture: fixture{file: "ollama_text.json"},
+			want:    AliasResult{Raw: "alias gs='git status'", Usage: Usage{InputTokens: 90, OutputTokens: 20}},
+		},
+		{
+			name:    "model not found",
+			fixture: fixture{status: http.StatusNotFound, file: "ollama_model_not_found.json"},
+			wantErr: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFixtureServer(t, "/api/generate", tt.fixture)
+			provider := &OllamaProvider{Endpoint: server.URL, Model: "llama3.2"}
+
+			got, err := provider.Complete(context.Background(), testPrompt, nil)
+			if tt.wantErr != 0 {
+				var status *StatusError
+				if !errors.As(err, &status) || status.StatusCode != tt.wantErr {
+					t.Fatalf("Complete error = %v, want a *StatusError with status %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			checkResult(t, got, tt.want)
+
+			if request := server.request(t, 0); request["format"] != "json" || request["stream"] != false {
+				t.Errorf("format = %v, stream = %v; want json without streaming", request["format"], request["stream"])
+			}
+		})
+	}
+}
+
+func TestParseAliasResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    AliasResult
+	}{
+		{
+			name:    "json",
+			content: `{"name": " gs ", "command": "git status", "shell": "bash", "explanation": "Short for git status."}`,
+			want:    wantGS,
+		},
+		{
+			name:    "fenced json",
+			content: "```json\n{\"name\": \"gs\", \"command\": \"git status\", \"shell\": \"bash\", \"explanation\": \"Short for git status.\"}\n```",
+			want:    wantGS,
+		},
+		{
+			name:    "fence without a language",
+			content: "```\n{\"name\": \"gs\", \"command\": \"git status\", \"shell\": \"bash\", \"explanation\": \"Short for git status.\"}\n```\n",
+			want:    wantGS,
+		},
+		{
+			name:    "plain text with an alias",
+			content: "You could use:\n  alias gs='git status'\nto save typing.",
+			want:    AliasResult{Raw: "alias gs='git status'"},
+		},
+		{
+			name:    "powershell",
+			content: "Set-Alias -Name gs -Value Get-GitStatus",
+			want:    AliasResult{Raw: "Set-Alias -Name gs -Value Get-GitStatus"},
+		},
+		{
+			name:    "json without a command",
+			content: `{"name": "gs", "command": "", "shell": "bash", "explanation": ""}`,
+			want:    AliasResult{Raw: `{"name": "gs", "command": "", "shell": "bash", "explanation": ""}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkResult(t, ParseAliasResult(tt.content), tt.want)
+		})
+	}
+}
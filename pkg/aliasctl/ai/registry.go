@@ -0,0 +1,187 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProviderType describes an AI provider implementation. Provider types are registered
+// with RegisterProviderType and selected by name when a provider is configured.
+type ProviderType struct {
+	Name            string                           // The type name, such as "ollama"
+	Title           string                           // The service name shown to users, such as "Ollama"
+	Description     string                           // A short description of the service
+	DefaultEndpoint string                           // The endpoint used when none is configured, empty if there is none
+	NeedsAPIKey     bool                             // Whether requests fail without an API key
+	Local           bool                             // Whether the models run locally, so requests cost nothing
+	DefaultTimeout  time.Duration                    // The time allowed for a request when the provider has no Timeout set
+	Options         map[string]string                // Settings specific to the type, by name, with their descriptions
+	New             func(info ProviderInfo) Provider // Creates a provider from its configuration
+}
+
+// providerTypes holds the registered provider types in registration order.
+var providerTypes []ProviderType
+
+// RegisterProviderType adds a provider type to the registry.
+// Registering a second type with the same name replaces the first.
+func RegisterProviderType(providerType ProviderType) {
+	for i, existing := range providerTypes {
+		if existing.Name == providerType.Name {
+			providerTypes[i] = providerType
+			return
+		}
+	}
+	providerTypes = append(providerTypes, providerType)
+}
+
+// ProviderTypes returns the registered provider types sorted by name.
+func ProviderTypes() []ProviderType {
+	sorted := append([]ProviderType(nil), providerTypes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// ProviderTypeNames returns the names of the registered provider types, sorted.
+func ProviderTypeNames() []string {
+	var names []string
+	for _, providerType := range ProviderTypes() {
+		names = append(names, providerType.Name)
+	}
+	return names
+}
+
+// GetProviderType returns the provider type registered under name.
+func GetProviderType(name string) (ProviderType, error) {
+	for _, providerType := range providerTypes {
+		if providerType.Name == name {
+			return providerType, nil
+		}
+	}
+	return ProviderType{}, fmt.Errorf("unsupported AI provider type: %s (supported types: %s)", name, strings.Join(ProviderTypeNames(), ", "))
+}
+
+// Timeout returns the time allowed for a request to a provider of this type that has
+// the given timeout set.
+func (pt ProviderType) Timeout(timeout time.Duration) time.Duration {
+	switch {
+	case timeout > 0:
+		return timeout
+	case pt.DefaultTimeout > 0:
+		return pt.DefaultTimeout
+	}
+	return DefaultTimeout
+}
+
+// NewProvider creates a provider from its configuration. An empty endpoint is replaced
+// by the type's default endpoint, and options the type does not know are rejected.
+// Without a Client, the provider gets one that sends requests as its Transport describes.
+func NewProvider(info ProviderInfo) (Provider, error) {
+	providerType, err := GetProviderType(info.Type)
+	if err != nil {
+		return nil, err
+	}
+	if info.Endpoint == "" {
+		if providerType.DefaultEndpoint == "" {
+			return nil, fmt.Errorf("%s provider needs an endpoint URL", info.Type)
+		}
+		info.Endpoint = providerType.DefaultEndpoint
+	}
+	for option := range info.Options {
+		if _, known := providerType.Options[option]; !known {
+			var known []string
+			for name := range providerType.Options {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			if len(known) == 0 {
+				return nil, fmt.Errorf("unknown option '%s' for %s provider: it has no options", option, info.Type)
+			}
+			return nil, fmt.Errorf("unknown option '%s' for %s provider (supported options: %s)", option, info.Type, strings.Join(known, ", "))
+		}
+	}
+	if info.Client == nil {
+		if info.Client, err = info.Transport.NewClient(); err != nil {
+			return nil, err
+		}
+	}
+	return providerType.New(info), nil
+}
+
+func init() {
+	RegisterProviderType(ProviderType{
+		Name:            "ollama",
+		Title:           "Ollama",
+		Description:     "Ollama, running models locally",
+		DefaultEndpoint: "http://localhost:11434",
+		DefaultTimeout:  DefaultLocalTimeout,
+		Local:           true,
+		New: func(info ProviderInfo) Provider {
+			return &OllamaProvider{Endpoint: info.Endpoint, Model: info.Model, Timeout: info.Timeout, Headers: info.Headers, Transport: info.Transport, Client: info.Client}
+		},
+	})
+	RegisterProviderType(ProviderType{
+		Name:            "openai",
+		Title:           "OpenAI-compatible",
+		Description:     "OpenAI, or any service with an OpenAI-compatible API",
+		DefaultEndpoint: "https://api.openai.com",
+		NeedsAPIKey:     true,
+		New: func(info ProviderInfo) Provider {
+			return &OpenAIProvider{Endpoint: info.Endpoint, APIKey: info.APIKey, Model: info.Model, Timeout: info.Timeout, Headers: info.Headers, Transport: info.Transport, Client: info.Client}
+		},
+	})
+	RegisterProviderType(ProviderType{
+		Name:            "anthropic",
+		Title:           "Anthropic Claude",
+		Description:     "Anthropic Claude",
+		DefaultEndpoint: "https://api.anthropic.com",
+		NeedsAPIKey:     true,
+		New: func(info ProviderInfo) Provider {
+			return &AnthropicProvider{Endpoint: info.Endpoint, APIKey: info.APIKey, Model: info.Model, Timeout: info.Timeout, Headers: info.Headers, Transport: info.Transport, Client: info.Client}
+		},
+	})
+	RegisterProviderType(ProviderType{
+		Name:            "gemini",
+		Title:           "Google Gemini",
+		Description:     "Google Gemini",
+		DefaultEndpoint: "https://generativelanguage.googleapis.com",
+		NeedsAPIKey:     true,
+		New: func(info ProviderInfo) Provider {
+			return &GeminiProvider{Endpoint: info.Endpoint, APIKey: info.APIKey, Model: info.Model, Timeout: info.Timeout, Headers: info.Headers, Transport: info.Transport, Client: info.Client}
+		},
+	})
+	RegisterProviderType(ProviderType{
+		Name:        "azure-openai",
+		Title:       "Azure OpenAI",
+		Description: "Azure OpenAI; the model is the deployment name",
+		NeedsAPIKey: true,
+		Options: map[string]string{
+			"api_version": "The Azure OpenAI API version, " + DefaultAzureAPIVersion + " if not set",
+		},
+		New: func(info ProviderInfo) Provider {
+			return &AzureOpenAIProvider{Endpoint: info.Endpoint, APIKey: info.APIKey, Deployment: info.Model, APIVersion: info.Options["api_version"], Timeout: info.Timeout, Headers: info.Headers, Transport: info.Transport, Client: info.Client}
+		},
+	})
+	RegisterProviderType(ProviderType{
+		Name:            "mistral",
+		Title:           "Mistral",
+		Description:     "Mistral AI",
+		DefaultEndpoint: "https://api.mistral.ai",
+		NeedsAPIKey:     true,
+		New: func(info ProviderInfo) Provider {
+			return &MistralProvider{Endpoint: info.Endpoint, APIKey: info.APIKey, Model: info.Model, Timeout: info.Timeout, Headers: info.Headers, Transport: info.Transport, Client: info.Client}
+		},
+	})
+	RegisterProviderType(ProviderType{
+		Name:            "llamacpp",
+		Title:           "llama.cpp server",
+		Description:     "llama.cpp server or LM Studio, running models locally",
+		DefaultEndpoint: "http://localhost:8080",
+		DefaultTimeout:  DefaultLocalTimeout,
+		Local:           true,
+		New: func(info ProviderInfo) Provider {
+			return &LlamaCppProvider{Endpoint: info.Endpoint, APIKey: info.APIKey, Model: info.Model, Timeout: info.Timeout, Headers: info.Headers, Transport: info.Transport, Client: info.Client}
+		},
+	})
+}
@@ -0,0 +1,206 @@
+package ai
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// streamedFields are the AliasResult fields whose text is shown while a structured
+// answer streams in. The name and shell are short and shown with the final result.
+var streamedFields = map[string]bool{"command": true, "explanation": true}
+
+// fieldStream turns the raw fragments of a structured answer into the decoded text of
+// its streamedFields, so a stream shows "git status" rather than {"command":"git st.
+// Fields are shown in the order they arrive, one per line. An answer that turns out not
+// to be a JSON object, such as the text answer of a server without structured output, is
+// passed on as it is. A Markdown code fence around the JSON is left out.
+type fieldStream struct {
+	stream StreamFunc
+
+	pending string // Text held back until it is known whether the answer is JSON
+	mode    int    // streamUndecided, streamJSON, streamText or streamDone
+
+	depth     int    // The nesting depth of objects and arrays
+	inString  bool   // Whether the scanner is inside a string
+	escape    string // An escape sequence inside a string that is not complete yet
+	expectKey bool   // Whether the next string in the top-level object is a key
+	isKey     bool   // Whether the current string is a key of the top-level object
+	key       strings.Builder
+	field     string // The top-level key whose value is being read
+	emitting  bool   // Whether the current string is a value to show
+	shown     bool   // Whether any field text has been shown
+	newline   bool   // Whether the current value starts a new line once it shows text
+	surrogate rune   // The first half of a UTF-16 surrogate pair escape, if any
+}
+
+// Modes of a fieldStream.
+const (
+	streamUndecided = iota // Nothing but white space or a code fence seen yet
+	streamJSON             // Decoding a JSON object
+	streamText             // Passing plain text through
+	streamDone             // The JSON object has ended; anything after it is dropped
+)
+
+// decodedStream returns a StreamFunc that shows the decoded fields of a structured answer
+// on stream, see fieldStream. Each answer needs its own decodedStream. Returns nil if
+// stream is nil.
+func decodedStream(stream StreamFunc) StreamFunc {
+	if stream == nil {
+		return nil
+	}
+	fs := &fieldStream{stream: stream}
+	return fs.write
+}
+
+// write takes the next fragment of the answer.
+func (fs *fieldStream) write(fragment string) {
+	if fs.mode == streamUndecided {
+		fs.pending += fragment
+		fragment = fs.decide()
+		if fs.mode == streamUndecided {
+			return
+		}
+	}
+
+	switch fs.mode {
+	case streamText:
+		if fragment != "" {
+			fs.stream(fragment)
+		}
+	case streamJSON:
+		var out strings.Builder
+		for i := 0; i < len(fragment) && fs.mode == streamJSON; i++ {
+			fs.scan(fragment[i], &out)
+		}
+		if out.Len() > 0 {
+			fs.stream(out.String())
+		}
+	}
+}
+
+// decide looks at the start of the answer to choose between JSON and text, skipping
+// white space and the opening line of a code fence. Returns the part of the pending text
+// still to be handled once the mode is known.
+func (fs *fieldStream) decide() string {
+	for {
+		text := strings.TrimLeft(fs.pending, " \t\r\n")
+		switch {
+		case text == "":
+			return ""
+		case strings.HasPrefix(text, "```"):
+			newline := strings.IndexByte(text, '\n')
+			if newline < 0 {
+				return ""
+			}
+			fs.pending = text[newline+1:]
+		case strings.HasPrefix("```", text):
+			// Possibly the start of a fence
+			return ""
+		case text[0] == '{':
+			fs.mode = streamJSON
+			fs.pending = ""
+			return text
+		default:
+			fs.mode = streamText
+			fs.pending = ""
+			return text
+		}
+	}
+}
+
+// scan handles one byte of the JSON object, adding the decoded text to show to out.
+func (fs *fieldStream) scan(c byte, out *strings.Builder) {
+	if fs.inString {
+		fs.scanString(c, out)
+		return
+	}
+
+	switch c {
+	case '{', '[':
+		fs.depth++
+		fs.expectKey = c == '{' && fs.depth == 1
+	case '}', ']':
+		fs.depth--
+		if fs.depth == 0 {
+			fs.mode = streamDone
+		}
+	case ',':
+		fs.expectKey = fs.depth == 1
+	case '"':
+		fs.inString = true
+		fs.isKey = fs.depth == 1 && fs.expectKey
+		fs.emitting = fs.depth == 1 && !fs.expectKey && streamedFields[fs.field]
+		if fs.isKey {
+			fs.key.Reset()
+		}
+		fs.newline = fs.emitting && fs.shown
+		fs.expectKey = false
+	}
+}
+
+// scanString handles one byte inside a string, decoding escape sequences.
+func (fs *fieldStream) scanString(c byte, out *strings.Builder) {
+	if fs.escape == "" {
+		switch c {
+		case '"':
+			fs.inString = false
+			if fs.isKey {
+				fs.field = fs.key.String()
+			}
+			return
+		case '\\':
+			fs.escape = `\`
+			return
+		}
+		fs.add(string([]byte{c}), out)
+		return
+	}
+
+	fs.escape += string([]byte{c})
+	if fs.escape[1] == 'u' {
+		if len(fs.escape) < 6 {
+			return
+		}
+		code, err := strconv.ParseUint(fs.escape[2:], 16, 16)
+		fs.escape = ""
+		if err != nil {
+			return
+		}
+		r := rune(code)
+		switch {
+		case utf16.IsSurrogate(r) && fs.surrogate == 0:
+			fs.surrogate = r
+			return
+		case fs.surrogate != 0:
+			r = utf16.DecodeRune(fs.surrogate, r)
+			fs.surrogate = 0
+		}
+		fs.add(string(r), out)
+		return
+	}
+
+	escape := fs.escape
+	fs.escape = ""
+	if escape == `\/` {
+		// Valid in JSON but not in Go
+		fs.add("/", out)
+	} else if decoded, err := strconv.Unquote(`"` + escape + `"`); err == nil {
+		fs.add(decoded, out)
+	}
+}
+
+// add adds decoded string text to the current key, or to out if the string is shown.
+func (fs *fieldStream) add(text string, out *strings.Builder) {
+	switch {
+	case fs.isKey:
+		fs.key.WriteString(text)
+	case fs.emitting:
+		if fs.newline {
+			out.WriteByte('\n')
+			fs.newline = false
+		}
+		out.WriteString(text)
+		fs.shown = true
+	}
+}
@@ -0,0 +1,194 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// collectStream returns a stream function and the text it was given.
+func collectStream() (StreamFunc, *strings.Builder) {
+	var shown strings.Builder
+	return func(text string) { shown.WriteString(text) }, &shown
+}
+
+func TestDecodedStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		fragments []string
+		want      string
+	}{
+		{
+			name:      "json",
+			fragments: []string{`{"name":"gs","command":"git status","shell":"bash","explanation":"Short for git status."}`},
+			want:      "git status\nShort for git status.",
+		},
+		{
+			name:      "split everywhere",
+			fragments: strings.Split(`{"name": "gtodo", "command": "grep -rn \"TODO\" .", "shell": "bash", "explanation": "Finds TODO\ncomments."}`, ""),
+			want:      "grep -rn \"TODO\" .\nFinds TODO\ncomments.",
+		},
+		{
+			name:      "unicode escapes",
+			fragments: []string{`{"command": "echo é\ud83d`, `\ude00 \/tmp", "explanation": "caf`, "é", `"}`},
+			want:      "echo é😀 /tmp\ncafé",
+		},
+		{
+			name:      "code fence",
+			fragments: []string{"``", "`json\n{\"command\": ", "\"ls -l\", \"explanation\": \"\"}\n```\n"},
+			want:      "ls -l",
+		},
+		{
+			name:      "empty command",
+			fragments: []string{`{"command": "", "explanation": "Nothing to do."}`},
+			want:      "Nothing to do.",
+		},
+		{
+			name:      "nested values are not shown",
+			fragments: []string{`{"extra": {"command": "rm -rf /"}, "command": "ls"}`},
+			want:      "ls",
+		},
+		{
+			name:      "plain text",
+			fragments: []string{"  ", "alias gs=", "'git status'\n"},
+			want:      "alias gs='git status'\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, shown := collectStream()
+			decoded := decodedStream(stream)
+			for _, fragment := range tt.fragments {
+				decoded(fragment)
+			}
+			if shown.String() != tt.want {
+				t.Errorf("shown %q, want %q", shown.String(), tt.want)
+			}
+		})
+	}
+
+	if decodedStream(nil) != nil {
+		t.Error("decodedStream(nil) is not nil")
+	}
+}
+
+// streamedGTodo is the answer recorded in the stream fixtures, and what is shown of it.
+var (
+	streamedGTodo = AliasResult{Name: "gtodo", Command: `grep -rn "TODO" .`, Shell: "bash", Explanation: "Finds TODO comments."}
+	shownGTodo    = "grep -rn \"TODO\" .\nFinds TODO comments."
+)
+
+func TestProviderStreaming(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		fixtures  []fixture
+		provider  func(endpoint string) Provider
+		want      AliasResult
+		wantShown string
+		wantErr   string
+	}{
+		{
+			name:     "openai",
+			path:     "/v1/chat/completions",
+			fixtures: []fixture{{file: "openai_stream.sse"}},
+			provider: func(endpoint string) Provider {
+				return &OpenAIProvider{Endpoint: endpoint, APIKey: "sk-test", Model: "gpt-4o-mini"}
+			},
+			want:      withUsage(streamedGTodo, 120, 30),
+			wantShown: shownGTodo,
+		},
+		{
+			name: "openai text fallback",
+			path: "/v1/chat/completions",
+			fixtures: []fixture{
+				{status: http.StatusBadRequest, file: "openai_response_format_unsupported.json"},
+				{file: "openai_stream_text.sse"},
+			},
+			provider: func(endpoint string) Provider {
+				return &OpenAIProvider{Endpoint: endpoint, APIKey: "sk-test", Model: "local-model"}
+			},
+			want:      AliasResult{Raw: "alias gtodo='grep -rn TODO .'"},
+			wantShown: "alias gtodo='grep -rn TODO .'",
+		},
+		{
+			name:     "anthropic",
+			path:     "/v1/messages",
+			fixtures: []fixture{{file: "anthropic_stream_tool_use.sse"}},
+			provider: func(endpoint string) Provider {
+				return &AnthropicProvider{Endpoint: endpoint, APIKey: "sk-ant-test", Model: "claude-3-5-haiku-latest"}
+			},
+			want:      withUsage(streamedGTodo, 410, 58),
+			wantShown: shownGTodo,
+		},
+		{
+			name:     "anthropic error event",
+			path:     "/v1/messages",
+			fixtures: []fixture{{file: "anthropic_stream_error.sse"}},
+			provider: func(endpoint string) Provider {
+				return &AnthropicProvider{Endpoint: endpoint, APIKey: "sk-ant-test", Model: "claude-3-5-haiku-latest"}
+			},
+			wantErr: "Overloaded",
+		},
+		{
+			name:     "ollama",
+			path:     "/api/generate",
+			fixtures: []fixture{{file: "ollama_stream.ndjson"}},
+			provider: func(endpoint string) Provider {
+				return &OllamaProvider{Endpoint: endpoint, Model: "llama3.2"}
+			},
+			want:      withUsage(streamedGTodo, 99, 35),
+			wantShown: shownGTodo,
+		},
+		{
+			name:     "ollama error line",
+			path:     "/api/generate",
+			fixtures: []fixture{{file: "ollama_stream_error.ndjson"}},
+			provider: func(endpoint string) Provider {
+				return &OllamaProvider{Endpoint: endpoint, Model: "llama3.2"}
+			},
+			wantErr: "unexpected EOF",
+		},
+		{
+			name:     "gemini",
+			path:     "/v1beta/models/gemini-2.0-flash:streamGenerateContent",
+			fixtures: []fixture{{file: "gemini_stream.sse"}},
+			provider: func(endpoint string) Provider {
+				return &GeminiProvider{Endpoint: endpoint, APIKey: "test-key", Model: "gemini-2.0-flash"}
+			},
+			want:      withUsage(streamedGTodo, 101, 33),
+			wantShown: shownGTodo,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFixtureServer(t, tt.path, tt.fixtures...)
+			stream, shown := collectStream()
+
+			got, err := tt.provider(server.URL).Complete(context.Background(), testPrompt, stream)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Complete error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			checkResult(t, got, tt.want)
+			if shown.String() != tt.wantShown {
+				t.Errorf("streamed %q, want %q", shown.String(), tt.wantShown)
+			}
+			if request := server.request(t, len(tt.fixtures)-1); tt.name != "gemini" && request["stream"] != true {
+				t.Errorf("stream = %v, want true", request["stream"])
+			}
+		})
+	}
+}
+
+// withUsage returns result with the given token usage.
+func withUsage(result AliasResult, input, output int) AliasResult {
+	result.Usage = Usage{InputTokens: input, OutputTokens: output}
+	return result
+}
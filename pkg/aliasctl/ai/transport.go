@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProxyNone is the Transport proxy that sends requests directly, ignoring the
+// HTTP_PROXY and HTTPS_PROXY environment variables.
+const ProxyNone = "none"
+
+// tlsVersions maps the TLS versions a Transport can require to their crypto/tls values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Transport describes how requests reach a provider's endpoint, for networks that need a
+// proxy, trust an internal certificate authority or require client certificates. The zero
+// Transport uses the proxy from the environment and the system's certificate authorities.
+// Paths may start with ~/ for the home directory.
+type Transport struct {
+	Proxy         string // The proxy URL (http, https or socks5), ProxyNone for none, or empty for the environment's
+	CAFile        string // A PEM bundle of certificate authorities trusted besides the system's
+	CertFile      string // The PEM client certificate, for endpoints that require one
+	KeyFile       string // The PEM private key of the client certificate
+	TLSMinVersion string // The lowest TLS version accepted, such as "1.2"; Go's default if empty
+}
+
+// IsZero reports whether the transport has no settings.
+func (t Transport) IsZero() bool {
+	return t == Transport{}
+}
+
+// NewClient returns an HTTP client that sends requests as the transport describes. The
+// zero Transport returns the shared client. Requests are bounded by their context instead
+// of a client timeout.
+// Returns an error if the proxy URL or TLS version is invalid, or a certificate file
+// cannot be read.
+func (t Transport) NewClient() (*http.Client, error) {
+	if t.IsZero() {
+		return httpClient, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	switch t.Proxy {
+	case "":
+	case ProxyNone:
+		transport.Proxy = nil
+	default:
+		proxyURL, err := url.Parse(t.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL '%s': use a URL such as http://proxy.example.com:3128", t.Proxy)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy URL '%s': must start with http://, https:// or socks5://", t.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{}
+	if t.TLSMinVersion != "" {
+		version, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(t.TLSMinVersion), "tls")]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS version '%s': use 1.0, 1.1, 1.2 or 1.3", t.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(expandHome(t.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s has no PEM certificates", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, fmt.Errorf("a client certificate needs both a certificate file and a key file")
+		}
+		cert, err := tls.LoadX509KeyPair(expandHome(t.CertFile), expandHome(t.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// expandHome replaces a leading ~/ in path with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, rest)
+		}
+	}
+	return path
+}
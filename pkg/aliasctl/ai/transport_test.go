@@ -0,0 +1,232 @@
+package ai
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writePEM writes a PEM block of the given type to a file in dir and returns its path.
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newTestCA creates a certificate authority.
+func newTestCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "aliasctl test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, key
+}
+
+// newClientCert creates a certificate authority and a client certificate it signs, and
+// writes the client certificate and key to dir. Returns the pool with the authority and
+// the paths of the certificate and key files.
+func newClientCert(t *testing.T, dir string) (*x509.CertPool, string, string) {
+	t.Helper()
+	ca, caKey := newTestCA(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "aliasctl"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return pool, writePEM(t, dir, "client.pem", "CERTIFICATE", der), writePEM(t, dir, "client.key", "EC PRIVATE KEY", keyDER)
+}
+
+// newTLSServer starts an HTTPS server that answers "ok", with the given TLS settings if
+// any, and returns it with the path of a CA bundle holding its certificate.
+func newTLSServer(t *testing.T, config *tls.Config) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	server.TLS = config
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Refused handshakes are expected
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server, writePEM(t, t.TempDir(), "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+}
+
+// get sends a GET request to url with the client the transport describes and returns the
+// response body.
+func get(t *testing.T, transport Transport, url string) (string, error) {
+	t.Helper()
+	client, err := transport.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestTransportCAFile(t *testing.T) {
+	server, caFile := newTLSServer(t, nil)
+	otherCA, _ := newTestCA(t)
+	otherCAFile := writePEM(t, t.TempDir(), "other-ca.pem", "CERTIFICATE", otherCA.Raw)
+
+	if body, err := get(t, Transport{CAFile: caFile}, server.URL); err != nil || body != "ok" {
+		t.Errorf("GET with the server's CA = %q, %v; want ok", body, err)
+	}
+	if _, err := get(t, Transport{CAFile: otherCAFile}, server.URL); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("GET with an unknown CA error = %v, want a certificate error", err)
+	}
+}
+
+func TestTransportClientCert(t *testing.T) {
+	pool, certFile, keyFile := newClientCert(t, t.TempDir())
+	server, caFile := newTLSServer(t, &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool})
+
+	if body, err := get(t, Transport{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}, server.URL); err != nil || body != "ok" {
+		t.Errorf("GET with the client certificate = %q, %v; want ok", body, err)
+	}
+	if _, err := get(t, Transport{CAFile: caFile}, server.URL); err == nil {
+		t.Error("GET without a client certificate succeeded, want the server to refuse it")
+	}
+}
+
+func TestTransportTLSMinVersion(t *testing.T) {
+	server, caFile := newTLSServer(t, &tls.Config{MaxVersion: tls.VersionTLS12})
+
+	if body, err := get(t, Transport{CAFile: caFile, TLSMinVersion: "1.2"}, server.URL); err != nil || body != "ok" {
+		t.Errorf("GET with TLS 1.2 allowed = %q, %v; want ok", body, err)
+	}
+	if _, err := get(t, Transport{CAFile: caFile, TLSMinVersion: "TLS1.3"}, server.URL); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("GET requiring TLS 1.3 from a TLS 1.2 server error = %v, want a protocol version error", err)
+	}
+}
+
+func TestTransportProxy(t *testing.T) {
+	var mu sync.Mutex
+	var proxied []string
+	received := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), proxied...)
+	}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.URL.String())
+		mu.Unlock()
+		io.WriteString(w, "from the proxy")
+	}))
+	t.Cleanup(proxy.Close)
+
+	// The host does not resolve, so only the proxy can answer
+	body, err := get(t, Transport{Proxy: proxy.URL}, "http://api.example.invalid/v1/models")
+	if err != nil || body != "from the proxy" {
+		t.Errorf("GET through the proxy = %q, %v; want the proxy's answer", body, err)
+	}
+	if got := received(); len(got) != 1 || got[0] != "http://api.example.invalid/v1/models" {
+		t.Errorf("proxy received %v, want the request", got)
+	}
+
+	t.Setenv("HTTPS_PROXY", proxy.URL)
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	client, err := Transport{Proxy: ProxyNone}.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if client.Transport.(*http.Transport).Proxy != nil {
+		t.Error("ProxyNone transport still reads the proxy from the environment")
+	}
+	if _, err := client.Get("https://api.example.invalid/v1/models"); err == nil {
+		t.Error("GET with ProxyNone succeeded, want it sent directly to the unresolvable host")
+	}
+	if got := received(); len(got) != 1 {
+		t.Errorf("proxy received %v with ProxyNone, want nothing more", got)
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	_, caFile := newTLSServer(t, nil)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		transport Transport
+		wantErr   string
+	}{
+		{name: "bad proxy URL", transport: Transport{Proxy: "proxy.example.com:3128"}, wantErr: "invalid proxy URL"},
+		{name: "unsupported proxy scheme", transport: Transport{Proxy: "ftp://proxy.example.com"}, wantErr: "unsupported proxy URL"},
+		{name: "bad TLS version", transport: Transport{TLSMinVersion: "1.4"}, wantErr: "invalid TLS version"},
+		{name: "missing CA bundle", transport: Transport{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, wantErr: "failed to read CA bundle"},
+		{name: "CA bundle without certificates", transport: Transport{CAFile: empty}, wantErr: "no PEM certificates"},
+		{name: "certificate without key", transport: Transport{CertFile: caFile}, wantErr: "both a certificate file and a key file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.transport.NewClient(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewClient error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if client, err := (Transport{}).NewClient(); err != nil || client != httpClient {
+		t.Errorf("zero Transport client = %p, %v; want the shared client", client, err)
+	}
+}
@@ -0,0 +1,192 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Usage is the number of tokens a request used, as reported by the provider.
+type Usage struct {
+	InputTokens  int // Tokens of the prompt
+	OutputTokens int // Tokens of the answer
+}
+
+// Price is what a model costs, in dollars per million tokens.
+type Price struct {
+	Input  float64 `json:"input" toml:"input"`   // Dollars per million input tokens
+	Output float64 `json:"output" toml:"output"` // Dollars per million output tokens
+}
+
+// Cost returns the cost of the given numbers of input and output tokens at the price.
+func (p Price) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1e6
+}
+
+// UsageRecord totals the requests for one command to one provider and model on one day.
+type UsageRecord struct {
+	Day          string `json:"day" yaml:"day" toml:"day"`                               // The day, as 2006-01-02 in local time
+	Provider     string `json:"provider" yaml:"provider" toml:"provider"`                // The name of the provider
+	Type         string `json:"type" yaml:"type" toml:"type"`                            // The provider type
+	Model        string `json:"model" yaml:"model" toml:"model"`                         // The model that answered
+	Command      string `json:"command" yaml:"command" toml:"command"`                   // The prompt task, such as generate
+	Requests     int    `json:"requests" yaml:"requests" toml:"requests"`                // The number of requests answered
+	InputTokens  int    `json:"input_tokens" yaml:"input_tokens" toml:"input_tokens"`    // The input tokens of the requests
+	OutputTokens int    `json:"output_tokens" yaml:"output_tokens" toml:"output_tokens"` // The output tokens of the requests
+}
+
+// BudgetError is returned for a request to a hosted provider once the estimated cost
+// of the month has reached the monthly budget.
+type BudgetError struct {
+	Budget float64 // The monthly budget in dollars
+	Spent  float64 // The estimated cost of the month in dollars
+}
+
+// Error returns the error message for a BudgetError.
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("the monthly AI budget of %s is used up (%s estimated this month), so hosted AI providers are not asked until next month", FormatDollars(e.Budget), FormatDollars(e.Spent))
+}
+
+// FormatDollars formats an amount in dollars, with more digits for amounts below a cent.
+func FormatDollars(amount float64) string {
+	if amount > 0 && amount < 0.01 {
+		return fmt.Sprintf("$%.4f", amount)
+	}
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// UsageLedger keeps the token usage of answered requests in a file, totaled per day,
+// provider, model and command. Costs are estimated from Prices, so a model without a
+// price costs nothing. With a MonthlyBudget, requests to hosted providers are refused
+// once the estimated cost of the month reaches it; local providers are always asked.
+type UsageLedger struct {
+	Path          string           // The ledger file
+	Prices        map[string]Price // The price of each model, by model name
+	MonthlyBudget float64          // The monthly budget in dollars, zero for none
+
+	mu sync.Mutex // Serializes updates from concurrent requests
+}
+
+// Records returns the usage records, sorted by day, command, provider and model.
+// A missing ledger has no records.
+func (l *UsageLedger) Records() ([]UsageRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.read()
+}
+
+// read returns the records of the ledger file, sorted.
+func (l *UsageLedger) read() ([]UsageRecord, error) {
+	var ledger struct {
+		Usage []UsageRecord `toml:"usage"`
+	}
+	if _, err := toml.DecodeFile(l.Path, &ledger); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read AI usage ledger %s: %w", l.Path, err)
+	}
+	sort.Slice(ledger.Usage, func(i, j int) bool {
+		a, b := ledger.Usage[i], ledger.Usage[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Command != b.Command {
+			return a.Command < b.Command
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	return ledger.Usage, nil
+}
+
+// Record adds a request for command that the provider registered as name answered,
+// using usage, to today's record.
+func (l *UsageLedger) Record(name string, info ProviderInfo, command string, usage Usage) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records, err := l.read()
+	if err != nil {
+		return err
+	}
+	day := time.Now().Format(time.DateOnly)
+	index := -1
+	for i, record := range records {
+		if record.Day == day && record.Provider == name && record.Model == info.Model && record.Command == command {
+			index = i
+		}
+	}
+	if index < 0 {
+		records = append(records, UsageRecord{Day: day, Provider: name, Type: info.Type, Model: info.Model, Command: command})
+		index = len(records) - 1
+	}
+	records[index].Requests++
+	records[index].InputTokens += usage.InputTokens
+	records[index].OutputTokens += usage.OutputTokens
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create AI usage ledger directory: %w", err)
+	}
+	file, err := os.Create(l.Path)
+	if err != nil {
+		return fmt.Errorf("failed to write AI usage ledger %s: %w", l.Path, err)
+	}
+	defer file.Close()
+	ledger := struct {
+		Usage []UsageRecord `toml:"usage"`
+	}{records}
+	return toml.NewEncoder(file).Encode(ledger)
+}
+
+// Cost returns the estimated cost of a record, and whether its model has a price.
+func (l *UsageLedger) Cost(record UsageRecord) (float64, bool) {
+	price, ok := l.Prices[record.Model]
+	if !ok {
+		return 0, false
+	}
+	return price.Cost(record.InputTokens, record.OutputTokens), true
+}
+
+// MonthCost returns the estimated cost of the records of month, written as 2006-01.
+func (l *UsageLedger) MonthCost(month string) (float64, error) {
+	records, err := l.Records()
+	if err != nil {
+		return 0, err
+	}
+	var cost float64
+	for _, record := range records {
+		if strings.HasPrefix(record.Day, month+"-") {
+			recordCost, _ := l.Cost(record)
+			cost += recordCost
+		}
+	}
+	return cost, nil
+}
+
+// CheckBudget returns a *BudgetError if a request to a hosted provider would exceed the
+// monthly budget. Requests to local providers, such as Ollama, are always allowed.
+func (l *UsageLedger) CheckBudget(info ProviderInfo) error {
+	if l.MonthlyBudget <= 0 {
+		return nil
+	}
+	if providerType, err := GetProviderType(info.Type); err == nil && providerType.Local {
+		return nil
+	}
+	spent, err := l.MonthCost(time.Now().Format("2006-01"))
+	if err != nil {
+		return err
+	}
+	if spent >= l.MonthlyBudget {
+		return &BudgetError{Budget: l.MonthlyBudget, Spent: spent}
+	}
+	return nil
+}
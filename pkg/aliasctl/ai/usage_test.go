@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// newTestLedger returns a ledger in a temporary directory with a $1 monthly budget and a
+// price for gpt-4o only, holding records if any.
+func newTestLedger(t *testing.T, records ...UsageRecord) *UsageLedger {
+	t.Helper()
+	ledger := &UsageLedger{
+		Path:          filepath.Join(t.TempDir(), "usage.toml"),
+		Prices:        map[string]Price{"gpt-4o": {Input: 2.5, Output: 10}},
+		MonthlyBudget: 1,
+	}
+	if len(records) > 0 {
+		file, err := os.Create(ledger.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		if err := toml.NewEncoder(file).Encode(struct {
+			Usage []UsageRecord `toml:"usage"`
+		}{records}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ledger
+}
+
+var (
+	hostedInfo = ProviderInfo{Type: "openai", Model: "gpt-4o"}
+	localInfo  = ProviderInfo{Type: "ollama", Model: "llama3.2"}
+)
+
+func TestUsageLedgerRecord(t *testing.T) {
+	ledger := newTestLedger(t)
+	if records, err := ledger.Records(); err != nil || len(records) != 0 {
+		t.Fatalf("Records of a missing ledger = %v, %v; want none", records, err)
+	}
+	ledger.Record("gateway", hostedInfo, TaskGenerate, Usage{InputTokens: 100, OutputTokens: 20})
+	ledger.Record("gateway", hostedInfo, TaskGenerate, Usage{InputTokens: 50, OutputTokens: 10})
+	ledger.Record("gateway", hostedInfo, TaskConvert, Usage{InputTokens: 10, OutputTokens: 5})
+	ledger.Record("local", localInfo, TaskConvert, Usage{})
+
+	records, err := ledger.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().Format(time.DateOnly)
+	want := []UsageRecord{
+		{Day: today, Provider: "gateway", Type: "openai", Model: "gpt-4o", Command: TaskConvert, Requests: 1, InputTokens: 10, OutputTokens: 5},
+		{Day: today, Provider: "local", Type: "ollama", Model: "llama3.2", Command: TaskConvert, Requests: 1},
+		{Day: today, Provider: "gateway", Type: "openai", Model: "gpt-4o", Command: TaskGenerate, Requests: 2, InputTokens: 150, OutputTokens: 30},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("records = %+v, want %d", records, len(want))
+	}
+	for i := range want {
+		if records[i] != want[i] {
+			t.Errorf("record %d = %+v, want %+v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 12, 0, 0, 0, time.Local)
+	lastMonth := thisMonth.AddDate(0, 0, -1) // The last day of the previous month
+	record := func(day time.Time, model string, input, output int) UsageRecord {
+		return UsageRecord{Day: day.Format(time.DateOnly), Provider: "gateway", Type: "openai", Model: model, Command: TaskGenerate, Requests: 1, InputTokens: input, OutputTokens: output}
+	}
+
+	tests := []struct {
+		name      string
+		records   []UsageRecord
+		budget    float64
+		wantSpent float64 // The cost refused at for hosted providers, zero if allowed
+	}{
+		{name: "no usage", budget: 1},
+		{name: "under budget", records: []UsageRecord{record(thisMonth, "gpt-4o", 100_000, 50_000)}, budget: 1},
+		// $0.50 of input and $0.50 of output reach the budget
+		{name: "budget reached", records: []UsageRecord{record(thisMonth, "gpt-4o", 200_000, 50_000)}, budget: 1, wantSpent: 1},
+		{name: "budget exceeded", records: []UsageRecord{record(thisMonth, "gpt-4o", 200_000, 50_000), record(now, "gpt-4o", 400_000, 0)}, budget: 1, wantSpent: 2},
+		{name: "last month", records: []UsageRecord{record(lastMonth, "gpt-4o", 10_000_000, 1_000_000)}, budget: 1},
+		{name: "unpriced model", records: []UsageRecord{record(thisMonth, "mystery-model", 10_000_000, 1_000_000)}, budget: 1},
+		{name: "no budget", records: []UsageRecord{record(thisMonth, "gpt-4o", 10_000_000, 1_000_000)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger := newTestLedger(t, tt.records...)
+			ledger.MonthlyBudget = tt.budget
+
+			err := ledger.CheckBudget(hostedInfo)
+			var budgetErr *BudgetError
+			if tt.wantSpent == 0 {
+				if err != nil {
+					t.Errorf("CheckBudget(openai) = %v, want the request allowed", err)
+				}
+			} else if !errors.As(err, &budgetErr) || budgetErr.Budget != tt.budget || budgetErr.Spent != tt.wantSpent {
+				t.Errorf("CheckBudget(openai) = %v, want a *BudgetError with %v spent", err, tt.wantSpent)
+			}
+
+			for _, local := range []ProviderInfo{localInfo, {Type: "llamacpp", Model: "qwen"}} {
+				if err := ledger.CheckBudget(local); err != nil {
+					t.Errorf("CheckBudget(%s) = %v, want local providers always allowed", local.Type, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMonthCost(t *testing.T) {
+	ledger := newTestLedger(t,
+		UsageRecord{Day: "2026-09-30", Model: "gpt-4o", InputTokens: 1_000_000},
+		UsageRecord{Day: "2026-10-01", Model: "gpt-4o", OutputTokens: 100_000},
+		UsageRecord{Day: "2026-10-18", Model: "gpt-4o", InputTokens: 400_000, OutputTokens: 100_000},
+		UsageRecord{Day: "2026-10-18", Model: "mystery-model", InputTokens: 1_000_000, OutputTokens: 1_000_000},
+	)
+	for month, want := range map[string]float64{"2026-09": 2.5, "2026-10": 3, "2026-11": 0} {
+		if cost, err := ledger.MonthCost(month); err != nil || cost != want {
+			t.Errorf("MonthCost(%s) = %v, %v; want %v", month, cost, err, want)
+		}
+	}
+	if cost, priced := ledger.Cost(UsageRecord{Model: "mystery-model", InputTokens: 1_000_000}); cost != 0 || priced {
+		t.Errorf("Cost of an unpriced model = %v, %v; want 0, false", cost, priced)
+	}
+}
+
+func TestManagerBudget(t *testing.T) {
+	hosted := &stubProvider{info: hostedInfo, result: wantGS}
+	local := &stubProvider{info: localInfo, result: wantGS}
+	m := NewManager()
+	m.AddProvider("gateway", hosted)
+	m.AddProvider("local", local)
+	m.Usage = newTestLedger(t, UsageRecord{Day: time.Now().Format(time.DateOnly), Provider: "gateway", Type: "openai", Model: "gpt-4o", Command: TaskGenerate, Requests: 1, InputTokens: 400_000})
+
+	data := PromptData{Shell: "bash", Command: "git status"}
+	var budgetErr *BudgetError
+	if _, err := m.GenerateAlias(context.Background(), data, "gateway", nil); !errors.As(err, &budgetErr) {
+		t.Errorf("GenerateAlias(gateway) error = %v, want a *BudgetError", err)
+	}
+	if hosted.calls() != 0 {
+		t.Errorf("hosted provider asked %d times over budget, want 0", hosted.calls())
+	}
+
+	result, err := m.GenerateAlias(context.Background(), data, "local", nil)
+	if err != nil || local.calls() != 1 {
+		t.Fatalf("GenerateAlias(local) = %+v, %v; want the local provider asked", result, err)
+	}
+	records, _ := m.Usage.Records()
+	if len(records) != 2 || records[1].Provider != "local" || records[1].Requests != 1 {
+		t.Errorf("records = %+v, want the local request recorded", records)
+	}
+
+	// The fallback chain skips the hosted provider and asks the local one
+	m.Fallback = []string{"gateway", "local"}
+	if result, err := m.GenerateAlias(context.Background(), PromptData{Shell: "zsh", Command: "git status"}, "", nil); err != nil || result.Provider != "local" {
+		t.Errorf("GenerateAlias with a fallback chain = %+v, %v; want the local provider's answer", result, err)
+	}
+}
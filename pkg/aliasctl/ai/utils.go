@@ -1,20 +1,50 @@
 package ai
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// Common timeout for all API requests
-const defaultTimeout = 30 * time.Second
+// Default time allowed for a request, used when a provider has no Timeout set.
+// Local servers such as Ollama get longer, as loading a model can take minutes.
+const (
+	DefaultTimeout      = 30 * time.Second
+	DefaultLocalTimeout = 5 * time.Minute
+)
+
+// Retries of requests the provider answered with a rate limit or server error (429,
+// 500, 502, 503, 504 or 529). The delay doubles with each retry, with jitter, unless the
+// provider asks for a delay with Retry-After.
+const (
+	MaxRetries     = 2
+	retryBaseDelay = 500 * time.Millisecond
+	maxRetryDelay  = 30 * time.Second
+)
+
+// Common HTTP client that can be reused, for providers without a Transport or client of
+// their own. Requests are bounded by their context instead of a client timeout.
+var httpClient = &http.Client{}
+
+// StreamFunc receives the text of a response as it arrives: the decoded command and
+// explanation of a structured answer, or the text of an answer that is not structured.
+type StreamFunc func(text string)
 
-// Common HTTP client that can be reused
-var httpClient = &http.Client{
-	Timeout: defaultTimeout,
+// withTimeout bounds ctx by timeout, or by fallback if timeout is not set.
+func withTimeout(ctx context.Context, timeout, fallback time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = fallback
+	}
+	return context.WithTimeoutCause(ctx, timeout, fmt.Errorf("no complete response within %s (raise it with 'aliasctl set-ai-timeout')", timeout))
 }
 
 // ValidateEndpoint checks if the endpoint URL is valid.
@@ -27,41 +57,39 @@ func ValidateEndpoint(endpoint string) error {
 	return nil
 }
 
-// GenerationPrompt creates a standardized prompt for alias generation.
-// It formats a prompt for AI models to create shell aliases based on the command and shell type.
-// The prompt includes context, requirements, and formatting instructions.
-func GenerationPrompt(command, shellType string) string {
-	return fmt.Sprintf(`You are a shell alias creation expert for %s shell.
-
-Task: Create a concise, memorable alias for the following command:
-%s
-
-Requirements:
-- The alias name should be short but descriptive
-- Follow standard naming conventions for %s aliases
-- The alias should be intuitive and easy to remember
-- Don't abbreviate too aggressively, though initials like kgp for kubectl get pods are acceptable.
-- Avoid using special characters or spaces in the alias
-- Ensure the alias is unique and doesn't conflict with existing commands in the shell
-- Consider common aliases in the %s ecosystem
-
-Response format:
-Provide ONLY the complete alias definition in the correct syntax for %s shell.
-- For bash/zsh: alias name='command'
-- For PowerShell: Set-Alias name command or function name { command }
-- For CMD: doskey name=command
-- For fish: alias name 'command' or function name\n    command\nend
-
-Do not include any explanations, preambles, or additional text.`,
-		shellType, command, shellType, shellType, shellType)
+// aliasResultSchema is the JSON schema of AliasResult, sent to providers that support
+// structured output.
+var aliasResultSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name":        map[string]any{"type": "string", "description": "The alias name, or an empty string when converting"},
+		"command":     map[string]any{"type": "string", "description": "The command the alias runs, without alias or function definition syntax"},
+		"shell":       map[string]any{"type": "string", "description": "The shell the command is written for"},
+		"explanation": map[string]any{"type": "string", "description": "Why this alias or conversion was chosen, or the explanation asked for"},
+	},
+	"required":             []string{"name", "command", "shell", "explanation"},
+	"additionalProperties": false,
 }
 
-// ConversionPrompt creates a standardized prompt for alias conversion.
-// It formats a prompt for AI models to convert an alias between different shell formats.
-// The prompt specifies the source and target shell types and the alias to convert.
-func ConversionPrompt(alias, fromShell, toShell string) string {
-	return fmt.Sprintf("Convert the following command from %s shell to %s shell. Provide only the final command without explanation: %s",
-		fromShell, toShell, alias)
+// ParseAliasResult decodes a structured response into an AliasResult. Models sometimes
+// wrap the JSON in a Markdown code fence, which is removed. If the response is not the
+// requested JSON, the alias definition is scraped from the text with
+// ExtractAliasDefinition and returned in Raw.
+func ParseAliasResult(content string) AliasResult {
+	text := strings.TrimSpace(content)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text[strings.IndexByte(text+"\n", '\n'):], "\n")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+
+	var result AliasResult
+	if err := json.Unmarshal([]byte(text), &result); err == nil && strings.TrimSpace(result.Command) != "" {
+		result.Name = strings.TrimSpace(result.Name)
+		result.Command = strings.TrimSpace(result.Command)
+		result.Explanation = strings.TrimSpace(result.Explanation)
+		return result
+	}
+	return AliasResult{Raw: ExtractAliasDefinition(content)}
 }
 
 // ExtractAliasDefinition tries to extract the actual alias definition from response text.
@@ -87,13 +115,104 @@ func ExtractAliasDefinition(content string) string {
 	return content
 }
 
+// requestHeaders returns the headers of a request: the headers the API needs, then the
+// provider's extra headers, which replace API headers of the same name.
+func requestHeaders(headers, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return headers
+	}
+	merged := make(map[string]string, len(headers)+len(extra))
+	for name, value := range headers {
+		merged[name] = value
+	}
+	for name, value := range extra {
+		merged[name] = value
+	}
+	return merged
+}
+
 // MakeAPIRequest makes a generic API request with error handling.
 // It creates an HTTP request with the specified method, URL, headers, and body,
-// then executes it and processes the response.
+// then executes it with client, or the shared client if nil, and processes the response.
+// The request is aborted when ctx is canceled or its deadline passes.
 // Returns the response body and any error encountered during the request.
 // Provides detailed error messages based on HTTP status codes and common error patterns.
-func MakeAPIRequest(method, url string, headers map[string]string, body []byte) ([]byte, error) {
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+func MakeAPIRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body []byte) ([]byte, error) {
+	resp, err := sendRequest(ctx, client, method, url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read response body
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, requestError(ctx, url, fmt.Errorf("error reading response from %s: %w", url, err))
+	}
+	return respBody, nil
+}
+
+// MakeStreamingRequest makes an API request like MakeAPIRequest and calls onLine with
+// each line of the response body as it arrives, for newline-delimited JSON and
+// server-sent event streams. Reading stops at the first error returned by onLine.
+func MakeStreamingRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body []byte, onLine func(line string) error) error {
+	resp, err := sendRequest(ctx, client, method, url, headers, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			if err := onLine(line); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return requestError(ctx, url, fmt.Errorf("error reading response stream from %s: %w", url, err))
+	}
+	return nil
+}
+
+// sendRequest sends a request and returns the response if its status is 200 OK.
+// Rate limits and server errors are retried up to MaxRetries times with exponential
+// backoff, unless the wait would outlast ctx.
+func sendRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body []byte) (*http.Response, error) {
+	if client == nil {
+		client = httpClient
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := sendAttempt(ctx, client, method, url, headers, body)
+		var retry *retryableError
+		if err == nil || !errors.As(err, &retry) {
+			return resp, err
+		}
+		if attempt == MaxRetries {
+			return nil, fmt.Errorf("%w (gave up after %d attempts)", retry.err, attempt+1)
+		}
+
+		delay := retryDelay(attempt, retry.retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, retry.err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, requestError(ctx, url, retry.err)
+		}
+	}
+}
+
+// sendAttempt sends a request once and returns the response if its status is 200 OK.
+// Any other status is returned as a *StatusError, wrapped in a *retryableError if it is
+// worth retrying.
+func sendAttempt(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request to %s: %w", url, err)
 	}
@@ -107,7 +226,7 @@ func MakeAPIRequest(method, url string, headers map[string]string, body []byte)
 	}
 
 	// Execute the request
-	resp, err := httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		// Check for common network errors and provide better messages
 		if strings.Contains(err.Error(), "connection refused") {
@@ -116,39 +235,98 @@ func MakeAPIRequest(method, url string, headers map[string]string, body []byte)
 		if strings.Contains(err.Error(), "no such host") {
 			return nil, fmt.Errorf("host not found for %s: check your network connection and the endpoint URL", url)
 		}
-		if strings.Contains(err.Error(), "timeout") {
-			return nil, fmt.Errorf("request to %s timed out after %s: the service might be overloaded or unreachable", url, defaultTimeout)
-		}
-		return nil, fmt.Errorf("error connecting to %s: %w", url, err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response from %s: %w", url, err)
+		return nil, requestError(ctx, url, fmt.Errorf("error connecting to %s: %w", url, err))
 	}
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(resp.Body)
+
 		// Attempt to provide more context based on status code
+		err := &StatusError{StatusCode: resp.StatusCode}
 		switch resp.StatusCode {
 		case http.StatusUnauthorized:
-			return respBody, fmt.Errorf("API authentication error (status 401): invalid or missing API key")
+			err.Message = "API authentication error (status 401): invalid or missing API key"
 		case http.StatusForbidden:
-			return respBody, fmt.Errorf("API authorization error (status 403): your API key doesn't have permission for this operation")
+			err.Message = "API authorization error (status 403): your API key doesn't have permission for this operation"
 		case http.StatusNotFound:
-			return respBody, fmt.Errorf("API resource not found (status 404): the endpoint URL or API version might be incorrect")
+			err.Message = "API resource not found (status 404): the endpoint URL or API version might be incorrect"
 		case http.StatusTooManyRequests:
-			return respBody, fmt.Errorf("API rate limit exceeded (status 429): try again later or check your API usage limits")
+			err.Message = "API rate limit exceeded (status 429): try again later or check your API usage limits"
 		case http.StatusInternalServerError:
-			return respBody, fmt.Errorf("API server error (status 500): the service might be experiencing issues")
+			err.Message = "API server error (status 500): the service might be experiencing issues"
 		default:
-			return respBody, fmt.Errorf("API error (status %d): %s", resp.StatusCode, limitResponseText(string(respBody), 200))
+			err.Message = fmt.Sprintf("API error (status %d): %s", resp.StatusCode, limitResponseText(string(respBody), 200))
+		}
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, 529:
+			return nil, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
 		}
+		return nil, err
 	}
 
-	return respBody, nil
+	return resp, nil
+}
+
+// StatusError is returned by requests that the API answered with a status other than
+// 200 OK. Use errors.As to tell, for example, a rejected request from a network failure.
+type StatusError struct {
+	StatusCode int    // The HTTP status code of the response
+	Message    string // What the status means, with the start of the response body if it has no known meaning
+}
+
+// Error returns the error message for a StatusError.
+func (e *StatusError) Error() string { return e.Message }
+
+// retryableError is a response status worth retrying.
+type retryableError struct {
+	err        error         // What the response said
+	retryAfter time.Duration // The delay asked for with Retry-After, zero if none
+}
+
+// Error returns the message of the response error.
+func (e *retryableError) Error() string { return e.err.Error() }
+
+// Unwrap returns the response error, a *StatusError.
+func (e *retryableError) Unwrap() error { return e.err }
+
+// retryDelay returns how long to wait before retry number attempt (counting from zero):
+// the Retry-After delay if the provider gave one, otherwise an exponential backoff with
+// jitter.
+func retryDelay(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryDelay)
+	}
+	delay := min(retryBaseDelay<<attempt, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+// Returns zero if the header is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// requestError explains err as a timeout or cancellation if ctx ended, since the
+// transport error alone does not say why.
+func requestError(ctx context.Context, url string, err error) error {
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("request to %s timed out: %w", url, context.Cause(ctx))
+	case ctx.Err() == context.Canceled:
+		return fmt.Errorf("request to %s was canceled: %w", url, context.Canceled)
+	}
+	return err
 }
 
 // limitResponseText limits response text to a maximum length.
@@ -0,0 +1,187 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statusResponse is a response of a statusServer.
+type statusResponse struct {
+	status     int
+	retryAfter string // The Retry-After header, if any
+}
+
+// statusServer answers requests with the given statuses in order, then with 200 OK, and
+// counts the requests.
+type statusServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests int
+}
+
+func newStatusServer(t *testing.T, responses ...statusResponse) *statusServer {
+	t.Helper()
+	server := &statusServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		server.requests++
+		n := server.requests
+		server.mu.Unlock()
+		if n > len(responses) {
+			io.WriteString(w, "ok")
+			return
+		}
+		response := responses[n-1]
+		if response.retryAfter != "" {
+			w.Header().Set("Retry-After", response.retryAfter)
+		}
+		http.Error(w, `{"error": "try again"}`, response.status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// count returns the number of requests the server received.
+func (s *statusServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	date := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name     string
+		header   string
+		min, max time.Duration
+	}{
+		{name: "seconds", header: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "HTTP date", header: date, min: 85 * time.Second, max: 90 * time.Second},
+		{name: "date in the past", header: "Wed, 21 Oct 2015 07:28:00 GMT"},
+		{name: "zero", header: "0"},
+		{name: "negative", header: "-5"},
+		{name: "invalid", header: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The header is read from a real response
+			server := newStatusServer(t, statusResponse{status: http.StatusTooManyRequests, retryAfter: tt.header})
+			_, err := sendAttempt(context.Background(), httpClient, "POST", server.URL, nil, nil)
+			var retry *retryableError
+			if !errors.As(err, &retry) {
+				t.Fatalf("sendAttempt error = %v, want a *retryableError", err)
+			}
+			if retry.retryAfter < tt.min || retry.retryAfter > tt.max {
+				t.Errorf("retryAfter = %s, want between %s and %s", retry.retryAfter, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	if got := retryDelay(0, 3*time.Second); got != 3*time.Second {
+		t.Errorf("retryDelay with Retry-After 3s = %s, want 3s", got)
+	}
+	if got := retryDelay(0, time.Hour); got != maxRetryDelay {
+		t.Errorf("retryDelay with Retry-After 1h = %s, want it capped at %s", got, maxRetryDelay)
+	}
+	for attempt := range 10 {
+		backoff := min(retryBaseDelay<<attempt, maxRetryDelay)
+		if got := retryDelay(attempt, 0); got < backoff/2 || got > backoff {
+			t.Errorf("retryDelay(%d) = %s, want between %s and %s", attempt, got, backoff/2, backoff)
+		}
+	}
+}
+
+func TestSendRequestRetries(t *testing.T) {
+	t.Run("Retry-After is waited for", func(t *testing.T) {
+		server := newStatusServer(t, statusResponse{status: http.StatusServiceUnavailable, retryAfter: "1"})
+		start := time.Now()
+		resp, err := sendRequest(context.Background(), nil, "POST", server.URL, nil, nil)
+		if err != nil {
+			t.Fatalf("sendRequest: %v", err)
+		}
+		resp.Body.Close()
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("retried after %s, want the 1s the server asked for", elapsed)
+		}
+		if server.count() != 2 {
+			t.Errorf("server received %d requests, want 2", server.count())
+		}
+	})
+
+	t.Run("gives up after MaxRetries", func(t *testing.T) {
+		responses := make([]statusResponse, MaxRetries+1)
+		for i := range responses {
+			responses[i] = statusResponse{status: http.StatusTooManyRequests}
+		}
+		server := newStatusServer(t, responses...)
+		_, err := sendRequest(context.Background(), nil, "POST", server.URL, nil, nil)
+		var status *StatusError
+		if !errors.As(err, &status) || status.StatusCode != http.StatusTooManyRequests || !strings.Contains(err.Error(), "gave up after 3 attempts") {
+			t.Errorf("sendRequest error = %v, want a 429 *StatusError after 3 attempts", err)
+		}
+		if server.count() != MaxRetries+1 {
+			t.Errorf("server received %d requests, want %d", server.count(), MaxRetries+1)
+		}
+	})
+
+	for _, code := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity} {
+		t.Run(http.StatusText(code)+" is not retried", func(t *testing.T) {
+			server := newStatusServer(t, statusResponse{status: code, retryAfter: "1"})
+			_, err := sendRequest(context.Background(), nil, "POST", server.URL, nil, nil)
+			var status *StatusError
+			var retry *retryableError
+			if !errors.As(err, &status) || status.StatusCode != code || errors.As(err, &retry) {
+				t.Errorf("sendRequest error = %v, want a %d *StatusError", err, code)
+			}
+			if server.count() != 1 {
+				t.Errorf("server received %d requests, want 1", server.count())
+			}
+		})
+	}
+
+	t.Run("cancel while waiting", func(t *testing.T) {
+		// Retry-After is capped at 30s, which fits the deadline, so the client waits
+		server := newStatusServer(t, statusResponse{status: http.StatusTooManyRequests, retryAfter: "3600"})
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		time.AfterFunc(200*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := sendRequest(ctx, nil, "POST", server.URL, nil, nil)
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > 5*time.Second {
+			t.Errorf("sendRequest returned after %s, want it to wait until canceled", elapsed)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("sendRequest error = %v, want context.Canceled", err)
+		}
+		if server.count() != 1 {
+			t.Errorf("server received %d requests, want 1", server.count())
+		}
+	})
+
+	t.Run("wait longer than the deadline", func(t *testing.T) {
+		server := newStatusServer(t, statusResponse{status: http.StatusServiceUnavailable, retryAfter: "20"})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		_, err := sendRequest(ctx, nil, "POST", server.URL, nil, nil)
+		var status *StatusError
+		if !errors.As(err, &status) || status.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("sendRequest error = %v, want the 503 *StatusError", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("sendRequest returned after %s, want it to give up at once", elapsed)
+		}
+		if server.count() != 1 {
+			t.Errorf("server received %d requests, want 1", server.count())
+		}
+	})
+}
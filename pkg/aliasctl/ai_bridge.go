@@ -1,7 +1,12 @@
 package aliasctl
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
 )
@@ -13,66 +18,230 @@ func (am *AliasManager) InitAIProviders() {
 	am.aiManager = ai.NewManager()
 }
 
-// ConfigureOllama sets up the Ollama AI provider.
-// It creates and configures an Ollama provider with the specified endpoint and model,
-// then adds it to the AI manager and sets it as the default provider.
-// The configuration is saved after setup.
-func (am *AliasManager) ConfigureOllama(endpoint, model string) {
-	provider := &ai.OllamaProvider{
-		Endpoint: endpoint,
-		Model:    model,
+// AIProviderType describes an AI provider implementation, see ai.ProviderType.
+type AIProviderType = ai.ProviderType
+
+// AIProviderTypes returns the AI provider types that can be configured, sorted by name.
+func AIProviderTypes() []AIProviderType {
+	return ai.ProviderTypes()
+}
+
+// ConfigureProvider sets up an AI provider and registers it under name, replacing any
+// provider of that name. Several providers can share a type (see AIProviderTypes), such as
+// a company gateway next to the public OpenAI API; an empty provider.Type uses the name
+// as the type. An empty endpoint selects the type's default endpoint. The API key is
+// provider.APIKey, or is read from provider.APIKeyRef when the provider is loaded.
+// The provider becomes the default, keeps the timeout of the provider it replaces unless
+// provider.Timeout is set, and the configuration is saved. Requests go through the
+// provider's proxy and TLS settings, such as provider.CACert, if it has any.
+// Returns an error if the name is invalid, the type is unknown, an option is not
+// supported, the type needs an API key and none is given, or the proxy or TLS settings
+// are invalid.
+func (am *AliasManager) ConfigureProvider(name string, provider ProviderConfig) error {
+	info, err := am.newProviderInfo(name, provider)
+	if err != nil {
+		return err
+	}
+	if err := am.addAIProvider(name, info); err != nil {
+		return err
 	}
+	am.setAIKeyRef(name, provider.APIKeyRef)
+	am.aiManager.SetDefaultProvider(name)
+	return am.SaveConfig()
+}
 
-	if am.aiManager == nil {
-		am.aiManager = ai.NewManager()
+// ValidateProviderConfig reports the errors ConfigureProvider would return for the
+// configuration of a provider named name, without registering or saving it.
+func (am *AliasManager) ValidateProviderConfig(name string, provider ProviderConfig) error {
+	info, err := am.newProviderInfo(name, provider)
+	if err != nil {
+		return err
 	}
+	_, err = ai.NewProvider(info)
+	return err
+}
 
-	am.aiManager.AddProvider("ollama", provider)
-	am.aiManager.SetDefaultProvider("ollama")
-	am.AIConfigured = true
-	am.SaveConfig()
+// newProviderInfo checks the configuration of an AI provider to be registered under name
+// and returns what the provider is created with, see ConfigureProvider.
+func (am *AliasManager) newProviderInfo(name string, provider ProviderConfig) (ai.ProviderInfo, error) {
+	if !validProviderName(name) {
+		return ai.ProviderInfo{}, fmt.Errorf("invalid provider name '%s': use letters, digits, '-' and '_'", name)
+	}
+	if provider.Type == "" {
+		provider.Type = name
+	}
+	providerType, err := ai.GetProviderType(provider.Type)
+	if err != nil {
+		return ai.ProviderInfo{}, err
+	}
+
+	apiKey := provider.APIKey
+	if provider.APIKeyRef != "" {
+		if apiKey, err = resolveSecret(provider.APIKeyRef); err != nil {
+			return ai.ProviderInfo{}, err
+		}
+	}
+	if providerType.NeedsAPIKey && apiKey == "" {
+		return ai.ProviderInfo{}, fmt.Errorf("%s provider needs an API key", provider.Type)
+	}
+
+	// Certificate files are saved with absolute paths, as aliasctl runs from anywhere
+	for _, path := range []*string{&provider.CACert, &provider.ClientCert, &provider.ClientKey} {
+		if *path != "" && !strings.HasPrefix(*path, "~/") {
+			if absolute, err := filepath.Abs(*path); err == nil {
+				*path = absolute
+			}
+		}
+	}
+
+	info := ai.ProviderInfo{Type: provider.Type, Endpoint: provider.Endpoint, APIKey: apiKey, Model: provider.Model, Options: provider.Options, Headers: provider.Headers, Transport: provider.transport()}
+	if provider.Timeout != "" {
+		if info.Timeout, err = time.ParseDuration(provider.Timeout); err != nil || info.Timeout < 0 {
+			return ai.ProviderInfo{}, fmt.Errorf("invalid timeout '%s': use a duration such as 45s or 2m", provider.Timeout)
+		}
+	} else if am.aiManager != nil {
+		if existing, exists := am.aiManager.Providers[name]; exists {
+			info.Timeout = existing.Info().Timeout
+		}
+	}
+	return info, nil
 }
 
-// ConfigureOpenAI sets up the OpenAI-compatible AI provider.
-// It creates and configures an OpenAI provider with the specified endpoint, API key, and model,
-// then adds it to the AI manager and sets it as the default provider.
-// The configuration is saved after setup.
-func (am *AliasManager) ConfigureOpenAI(endpoint, apiKey, model string) {
-	provider := &ai.OpenAIProvider{
-		Endpoint: endpoint,
-		APIKey:   apiKey,
-		Model:    model,
+// validProviderName reports whether name can name an AI provider.
+func validProviderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
 	}
+	return true
+}
 
+// addAIProvider creates a provider from its configuration and registers it under name,
+// without saving the configuration.
+func (am *AliasManager) addAIProvider(name string, info ai.ProviderInfo) error {
+	provider, err := ai.NewProvider(info)
+	if err != nil {
+		return err
+	}
 	if am.aiManager == nil {
 		am.aiManager = ai.NewManager()
 	}
-
-	am.aiManager.AddProvider("openai", provider)
-	am.aiManager.SetDefaultProvider("openai")
+	am.aiManager.AddProvider(name, provider)
+	delete(am.aiUnloaded, name)
+	delete(am.aiLoadErrors, name)
 	am.AIConfigured = true
-	am.SaveConfig()
+	return nil
 }
 
-// ConfigureAnthropic sets up the Anthropic Claude AI provider.
-// It creates and configures an Anthropic provider with the specified endpoint, API key, and model,
-// then adds it to the AI manager and sets it as the default provider.
-// The configuration is saved after setup.
-func (am *AliasManager) ConfigureAnthropic(endpoint, apiKey, model string) {
-	provider := &ai.AnthropicProvider{
-		Endpoint: endpoint,
-		APIKey:   apiKey,
-		Model:    model,
+// setAIKeyRef records where the API key of the provider registered under name is read
+// from, so the reference rather than the key is saved. An empty ref saves the key.
+func (am *AliasManager) setAIKeyRef(name, ref string) {
+	if ref == "" {
+		delete(am.aiKeyRefs, name)
+		return
+	}
+	if am.aiKeyRefs == nil {
+		am.aiKeyRefs = make(map[string]string)
 	}
+	am.aiKeyRefs[name] = ref
+}
+
+// ConfigureOllama sets up the Ollama AI provider with the specified endpoint and model,
+// see ConfigureProvider.
+func (am *AliasManager) ConfigureOllama(endpoint, model string) error {
+	return am.ConfigureProvider("ollama", ProviderConfig{Endpoint: endpoint, Model: model})
+}
 
+// ConfigureOpenAI sets up the OpenAI-compatible AI provider with the specified endpoint,
+// API key, and model, see ConfigureProvider.
+func (am *AliasManager) ConfigureOpenAI(endpoint, apiKey, model string) error {
+	return am.ConfigureProvider("openai", ProviderConfig{Endpoint: endpoint, APIKey: apiKey, Model: model})
+}
+
+// ConfigureAnthropic sets up the Anthropic Claude AI provider with the specified endpoint,
+// API key, and model, see ConfigureProvider.
+func (am *AliasManager) ConfigureAnthropic(endpoint, apiKey, model string) error {
+	return am.ConfigureProvider("anthropic", ProviderConfig{Endpoint: endpoint, APIKey: apiKey, Model: model})
+}
+
+// SetAITimeout sets the time allowed for a request to the named AI provider, from
+// sending the prompt to receiving the whole response. A timeout of zero restores the
+// provider's default. The configuration is saved afterwards.
+func (am *AliasManager) SetAITimeout(providerName string, timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("invalid timeout %s: must not be negative", timeout)
+	}
 	if am.aiManager == nil {
 		am.aiManager = ai.NewManager()
 	}
+	provider, exists := am.aiManager.Providers[providerName]
+	if !exists {
+		return fmt.Errorf("AI provider '%s' not configured. Available providers: %s", providerName, strings.Join(am.aiManager.ListProviders(), ", "))
+	}
 
-	am.aiManager.AddProvider("anthropic", provider)
-	am.aiManager.SetDefaultProvider("anthropic")
-	am.AIConfigured = true
-	am.SaveConfig()
+	info := provider.Info()
+	info.Timeout = timeout
+	if err := am.addAIProvider(providerName, info); err != nil {
+		return err
+	}
+	return am.SaveConfig()
+}
+
+// SetAIFallbackChain sets the AI providers tried in order until one answers, for
+// requests that name no provider. An empty chain uses the default provider only.
+// The configuration is saved afterwards.
+func (am *AliasManager) SetAIFallbackChain(names []string) error {
+	if am.aiManager == nil {
+		am.aiManager = ai.NewManager()
+	}
+	if err := am.aiManager.SetFallbackChain(names); err != nil {
+		return err
+	}
+	return am.SaveConfig()
+}
+
+// SkipAICache makes AI requests ask the provider even when a cached answer exists.
+// The new answers still replace the cached ones.
+func (am *AliasManager) SkipAICache() {
+	if am.aiManager != nil && am.aiManager.Cache != nil {
+		am.aiManager.Cache.Refresh = true
+	}
+}
+
+// AICacheStats describes the cached AI answers.
+func (am *AliasManager) AICacheStats() (ai.CacheStats, error) {
+	if am.aiManager == nil || am.aiManager.Cache == nil {
+		return ai.CacheStats{}, fmt.Errorf("the AI answer cache is not set up")
+	}
+	return am.aiManager.Cache.Stats()
+}
+
+// ClearAICache removes every cached AI answer and returns how many there were.
+func (am *AliasManager) ClearAICache() (int, error) {
+	if am.aiManager == nil || am.aiManager.Cache == nil {
+		return 0, nil
+	}
+	return am.aiManager.Cache.Clear()
+}
+
+// formatDuration formats a duration for the configuration, leaving zero (the default) empty.
+func formatDuration(timeout time.Duration) string {
+	if timeout <= 0 {
+		return ""
+	}
+	return timeout.String()
+}
+
+// effectiveTimeout returns the time allowed for a request to a provider of the given type.
+func effectiveTimeout(providerType string, timeout time.Duration) time.Duration {
+	if pt, err := ai.GetProviderType(providerType); err == nil {
+		return pt.Timeout(timeout)
+	}
+	return ai.ProviderType{}.Timeout(timeout)
 }
 
 // GetAvailableProviders returns a list of configured AI provider names.
@@ -85,18 +254,86 @@ func (am *AliasManager) GetAvailableProviders() []string {
 	return am.aiManager.ListProviders()
 }
 
+// ProviderDetails returns the configuration of every registered AI provider, sorted by name.
+// Returns an empty slice if no providers are configured.
+func (am *AliasManager) ProviderDetails() []ProviderDetails {
+	details := []ProviderDetails{}
+	if am.aiManager == nil {
+		return details
+	}
+
+	defaultName := am.aiManager.DefaultName()
+	for _, name := range am.aiManager.ListProviders() {
+		info := am.aiManager.Providers[name].Info()
+		details = append(details, ProviderDetails{
+			Name:     name,
+			Type:     info.Type,
+			Endpoint: info.Endpoint,
+			Model:    info.Model,
+			Timeout:  effectiveTimeout(info.Type, info.Timeout).String(),
+			Default:  name == defaultName,
+			Fallback: slices.Index(am.aiManager.Fallback, name) + 1,
+		})
+	}
+	return details
+}
+
+// promptData returns the values the prompt templates are executed with for a request
+// about command in shell: the names of the aliases in effect and the naming conventions.
+func (am *AliasManager) promptData(shell ShellType, command string) ai.PromptData {
+	return ai.PromptData{
+		Shell:       string(shell),
+		Command:     command,
+		Aliases:     sortedNames(am.EffectiveAliases()),
+		Conventions: am.AINamingConventions,
+	}
+}
+
+// AIAlias is an alias produced by an AI provider.
+type AIAlias struct {
+	Name        string    // The alias name, empty for conversions
+	Command     string    // The command the alias runs
+	Shell       ShellType // The shell the command is written for
+	Explanation string    // Why the provider chose it, if it said
+	Provider    string    // The name of the provider that answered
+	Cached      bool      // Whether the answer came from the AI answer cache
+}
+
+// generatedAlias turns a generation result into an AIAlias for shell. For text responses
+// the alias definition is parsed instead.
+func generatedAlias(result ai.AliasResult, shell ShellType) AIAlias {
+	alias := AIAlias{Name: result.Name, Command: result.Command, Shell: shell, Explanation: result.Explanation, Provider: result.Provider, Cached: result.Cached}
+	if result.Raw != "" {
+		alias.Name, alias.Command = ParseAliasDefinition(result.Raw, shell)
+	}
+	return alias
+}
+
+// convertedAlias turns a conversion result for alias name into an AIAlias for shell. For
+// text responses the command is extracted with cleanConvertedCommand instead.
+func convertedAlias(name string, result ai.AliasResult, shell ShellType) AIAlias {
+	alias := AIAlias{Name: name, Command: result.Command, Shell: shell, Explanation: result.Explanation, Provider: result.Provider, Cached: result.Cached}
+	if result.Raw != "" {
+		alias.Command = cleanConvertedCommand(result.Raw, shell)
+	}
+	return alias
+}
+
 // ConvertAlias converts an alias from one shell to another using the specified provider.
 // It retrieves the alias definition for the current shell and asks the AI to convert it
-// to the target shell format.
-// Returns an error if the alias doesn't exist, no AI provider is configured, or the conversion fails.
-func (am *AliasManager) ConvertAlias(name, targetShell, providerName string) (string, error) {
+// to the target shell format. The result is screened with ScreenAICommand and returned
+// with its risk findings.
+// Returns an error if the alias doesn't exist, no AI provider is configured, the conversion
+// fails, or the safety policy blocks the result. The request ends when ctx is canceled;
+// if stream is not nil, the response text is passed to it as it arrives.
+func (am *AliasManager) ConvertAlias(ctx context.Context, name, targetShell, providerName string, stream func(text string)) (AIAlias, []RiskFinding, error) {
 	if !am.AIConfigured {
-		return "", fmt.Errorf("AI provider not configured. Use 'aliasctl configure-ollama', 'aliasctl configure-openai', or 'aliasctl configure-anthropic' to set up an AI provider")
+		return AIAlias{}, nil, fmt.Errorf("AI provider not configured. Use 'aliasctl configure-ollama', 'aliasctl configure-openai', or 'aliasctl configure-anthropic' to set up an AI provider")
 	}
 
-	commands, exists := am.Aliases[name]
+	commands, exists := am.EffectiveAliases()[name]
 	if !exists {
-		return "", fmt.Errorf("alias '%s' not found. Run 'aliasctl list' to see available aliases", name)
+		return AIAlias{}, nil, fmt.Errorf("alias '%s' not found. Run 'aliasctl list' to see available aliases", name)
 	}
 
 	var command string
@@ -118,20 +355,132 @@ func (am *AliasManager) ConvertAlias(name, targetShell, providerName string) (st
 	}
 
 	if command == "" {
-		return "", fmt.Errorf("command for shell '%s' not found", am.Shell)
+		return AIAlias{}, nil, fmt.Errorf("command for shell '%s' not found", am.Shell)
 	}
 
-	return am.aiManager.ConvertAlias(command, string(am.Shell), targetShell, providerName)
+	data := am.promptData(ShellType(targetShell), command)
+	data.FromShell = string(am.Shell)
+	result, err := am.aiManager.ConvertAlias(ctx, data, providerName, stream)
+	if err != nil {
+		return AIAlias{}, nil, err
+	}
+	converted := convertedAlias(name, result, ShellType(targetShell))
+	if converted.Command == "" {
+		return AIAlias{}, nil, fmt.Errorf("the AI provider returned no command")
+	}
+	findings, err := am.ScreenAICommand(converted.Command, command)
+	if err != nil {
+		return AIAlias{}, findings, err
+	}
+	return converted, findings, nil
 }
 
 // GenerateAlias generates an alias suggestion for the given command.
-// It uses the configured AI provider to suggest a shell-appropriate alias name and format
-// for the provided command.
-// Returns an error if no AI provider is configured or the generation fails.
-func (am *AliasManager) GenerateAlias(command, providerName string) (string, error) {
+// It uses the configured AI provider to suggest a shell-appropriate alias name and
+// command. The suggested command is screened with ScreenAICommand and returned with its
+// risk findings.
+// Returns an error if no AI provider is configured, the generation fails, or the safety
+// policy blocks the suggestion. The request ends when ctx is canceled; if stream is not
+// nil, the response text is passed to it as it arrives.
+func (am *AliasManager) GenerateAlias(ctx context.Context, command, providerName string, stream func(text string)) (AIAlias, []RiskFinding, error) {
 	if !am.AIConfigured {
-		return "", fmt.Errorf("AI provider not configured. Use 'aliasctl configure-ollama', 'aliasctl configure-openai', or 'aliasctl configure-anthropic' to set up an AI provider")
+		return AIAlias{}, nil, fmt.Errorf("AI provider not configured. Use 'aliasctl configure-ollama', 'aliasctl configure-openai', or 'aliasctl configure-anthropic' to set up an AI provider")
 	}
 
-	return am.aiManager.GenerateAlias(command, string(am.Shell), providerName)
+	result, err := am.aiManager.GenerateAlias(ctx, am.promptData(am.Shell, command), providerName, stream)
+	if err != nil {
+		return AIAlias{}, nil, err
+	}
+	generated := generatedAlias(result, am.Shell)
+	if generated.Name == "" || generated.Command == "" {
+		return AIAlias{}, nil, fmt.Errorf("failed to parse the generated alias definition: %s", result.Raw)
+	}
+	findings, err := am.ScreenAICommand(generated.Command, command)
+	if err != nil {
+		return AIAlias{}, findings, err
+	}
+	return generated, findings, nil
+}
+
+// ExplainAlias asks the AI what the named alias does: its command for the current shell,
+// each option and argument, and its risks. The explanation is returned in the
+// Explanation of the result, with the risks AnalyzeCommandRisk finds in the command.
+// Returns an error if no AI provider is configured, the alias has no command for the
+// current shell, or the request fails. The request ends when ctx is canceled; if stream
+// is not nil, the response text is passed to it as it arrives.
+func (am *AliasManager) ExplainAlias(ctx context.Context, name, providerName string, stream func(text string)) (AIAlias, []RiskFinding, error) {
+	if !am.AIConfigured {
+		return AIAlias{}, nil, fmt.Errorf("AI provider not configured. Use 'aliasctl configure-ollama', 'aliasctl configure-openai', or 'aliasctl configure-anthropic' to set up an AI provider")
+	}
+
+	commands, exists := am.EffectiveAliases()[name]
+	if !exists {
+		return AIAlias{}, nil, fmt.Errorf("alias '%s' not found. Run 'aliasctl list' to see available aliases", name)
+	}
+	command := commands.ForShell(am.Shell)
+	if command == "" {
+		return AIAlias{}, nil, fmt.Errorf("alias '%s' has no command for shell '%s'", name, am.Shell)
+	}
+
+	data := am.promptData(am.Shell, command)
+	data.Name = name
+	result, err := am.aiManager.ExplainAlias(ctx, data, providerName, stream)
+	if err != nil {
+		return AIAlias{}, nil, err
+	}
+	explanation := result.Explanation
+	if result.Raw != "" {
+		explanation = strings.TrimSpace(result.Raw)
+	}
+	if explanation == "" {
+		return AIAlias{}, nil, fmt.Errorf("the AI provider returned no explanation")
+	}
+	explained := AIAlias{Name: name, Command: command, Shell: am.Shell, Explanation: explanation, Provider: result.Provider, Cached: result.Cached}
+	return explained, AnalyzeCommandRisk(command, ""), nil
+}
+
+// SaveAIExplanation sets the description of the named alias to the first line of an
+// explanation from ExplainAlias, which sums up what the alias does, saves the aliases and
+// returns the description.
+// Returns an error if the alias is not one of the user's own aliases or cannot be saved.
+func (am *AliasManager) SaveAIExplanation(name, explanation string) (string, error) {
+	description, _, _ := strings.Cut(strings.TrimSpace(explanation), "\n")
+	description = strings.TrimSpace(description)
+	if !am.SetAliasDescription(name, description) {
+		return "", fmt.Errorf("alias '%s' is not one of your aliases (aliases from bundles cannot be changed)", name)
+	}
+	am.SetOperation(OpEdit, fmt.Sprintf("ai-explain %s", name))
+	if err := am.SaveAliases(); err != nil {
+		return "", err
+	}
+	return description, nil
+}
+
+// AskCommand asks the AI for a command in the current shell that does what request
+// describes, such as "find large files modified today", and an alias name for it. The
+// command is screened with ScreenAICommand as a command without a source, so under the
+// block policy any risk refuses it, and is returned with its risk findings.
+// Returns an error if no AI provider is configured, the request fails, or the safety
+// policy blocks the command. The request ends when ctx is canceled; if stream is not
+// nil, the response text is passed to it as it arrives.
+func (am *AliasManager) AskCommand(ctx context.Context, request, providerName string, stream func(text string)) (AIAlias, []RiskFinding, error) {
+	if !am.AIConfigured {
+		return AIAlias{}, nil, fmt.Errorf("AI provider not configured. Use 'aliasctl configure-ollama', 'aliasctl configure-openai', or 'aliasctl configure-anthropic' to set up an AI provider")
+	}
+
+	data := am.promptData(am.Shell, "")
+	data.Request = request
+	result, err := am.aiManager.AskCommand(ctx, data, providerName, stream)
+	if err != nil {
+		return AIAlias{}, nil, err
+	}
+	proposed := generatedAlias(result, am.Shell)
+	if proposed.Name == "" || proposed.Command == "" {
+		return AIAlias{}, nil, fmt.Errorf("failed to parse the proposed alias definition: %s", result.Raw)
+	}
+	findings, err := am.ScreenAICommand(proposed.Command, "")
+	if err != nil {
+		return AIAlias{}, findings, err
+	}
+	return proposed, findings, nil
 }
@@ -0,0 +1,130 @@
+package aliasctl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
+)
+
+// Outcomes of an AI provider check, see ProviderCheck.
+const (
+	CheckOK    = "ok"
+	CheckError = "error"
+)
+
+// aiCheckTimeout bounds the check of a single AI provider by CheckAIProviders.
+const aiCheckTimeout = 30 * time.Second
+
+// ListAIModels returns the models available to the named AI provider, sorted, or to the
+// default provider if providerName is empty.
+// Returns an error if the provider is not configured, its type cannot list models, or
+// the request fails.
+func (am *AliasManager) ListAIModels(ctx context.Context, providerName string) ([]string, error) {
+	if am.aiManager == nil {
+		am.aiManager = ai.NewManager()
+	}
+	provider, err := am.aiManager.GetProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	return ai.ListModels(ctx, provider)
+}
+
+// ListAIModelsFor returns the models available to a provider that is not configured yet,
+// so a model can be checked before ConfigureProvider saves it. Nothing is saved.
+func (am *AliasManager) ListAIModelsFor(ctx context.Context, name string, provider ProviderConfig) ([]string, error) {
+	info, err := am.newProviderInfo(name, provider)
+	if err != nil {
+		return nil, err
+	}
+	created, err := ai.NewProvider(info)
+	if err != nil {
+		return nil, err
+	}
+	return ai.ListModels(ctx, created)
+}
+
+// AIModelAvailable reports whether model is one of models, as listed by ListAIModels.
+// A model name without a tag matches the "latest" tag, as it does in Ollama.
+func AIModelAvailable(models []string, model string) bool {
+	return ai.HasModel(models, model)
+}
+
+// CheckAIProviders checks every saved AI provider, sorted by name: it asks the provider
+// for its models, which needs a reachable endpoint and a valid API key, and looks for the
+// configured model among them. Providers whose type cannot list models are sent a short
+// test request instead. Providers that failed to load from the config are reported with
+// the reason. The providers are checked at the same time; each check ends when ctx is
+// canceled or after 30 seconds.
+func (am *AliasManager) CheckAIProviders(ctx context.Context) []ProviderCheck {
+	checks := []ProviderCheck{}
+	var providers []ai.Provider
+	if am.aiManager != nil {
+		for _, name := range am.aiManager.ListProviders() {
+			provider := am.aiManager.Providers[name]
+			info := provider.Info()
+			checks = append(checks, ProviderCheck{Name: name, Type: info.Type, Endpoint: info.Endpoint, Model: info.Model})
+			providers = append(providers, provider)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkAIProvider(ctx, provider, &checks[i])
+		}()
+	}
+	wg.Wait()
+
+	for name, provider := range am.aiUnloaded {
+		message := "not loaded from the config"
+		if err := am.aiLoadErrors[name]; err != nil {
+			message = fmt.Sprintf("not loaded from the config: %v", err)
+		}
+		checks = append(checks, ProviderCheck{Name: name, Type: provider.Type, Endpoint: provider.Endpoint, Model: provider.Model, Status: CheckError, Message: message})
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+	return checks
+}
+
+// checkAIProvider checks a single provider and records the outcome in check.
+func checkAIProvider(ctx context.Context, provider ai.Provider, check *ProviderCheck) {
+	ctx, cancel := context.WithTimeout(ctx, aiCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	lister, ok := provider.(ai.ModelLister)
+	if !ok {
+		prompt, err := (*ai.Prompts)(nil).Render(ai.TaskGenerate, ai.PromptData{Shell: string(ShellBash), Command: "echo ok"})
+		if err == nil {
+			_, err = provider.Complete(ctx, prompt, nil)
+		}
+		check.Latency = time.Since(start).Round(time.Millisecond).String()
+		if err != nil {
+			check.Status, check.Message = CheckError, err.Error()
+			return
+		}
+		check.Status, check.Message = CheckOK, "answered a test request (this provider type cannot list its models)"
+		return
+	}
+
+	models, err := lister.ListModels(ctx)
+	check.Latency = time.Since(start).Round(time.Millisecond).String()
+	if err != nil {
+		check.Status, check.Message = CheckError, err.Error()
+		return
+	}
+	check.Models = len(models)
+	if !ai.HasModel(models, check.Model) {
+		check.Status = CheckError
+		check.Message = fmt.Sprintf("model '%s' is not among the %d models the provider lists (see 'aliasctl ai models %s')", check.Model, len(models), check.Name)
+		return
+	}
+	check.Status, check.Message = CheckOK, fmt.Sprintf("model '%s' available", check.Model)
+}
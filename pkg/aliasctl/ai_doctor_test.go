@@ -0,0 +1,119 @@
+package aliasctl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newStubProviders returns a server standing in for the AI providers checked by ai doctor:
+// an Ollama server at /ollama, an OpenAI-compatible gateway at /gateway that accepts the
+// key "sk-good", and a server that answers 403 at /forbidden.
+func newStubProviders(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ollama/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models": [{"name": "qwen2.5-coder:7b"}, {"name": "llama3.2:latest"}]}`))
+	})
+	mux.HandleFunc("GET /gateway/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-good" {
+			http.Error(w, `{"error": {"message": "Incorrect API key provided"}}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": [{"id": "gpt-4o-mini"}, {"id": "gpt-4o"}]}`))
+	})
+	mux.HandleFunc("GET /forbidden/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": "forbidden"}`, http.StatusForbidden)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// mustConfigure configures an AI provider or fails the test.
+func mustConfigure(t *testing.T, am *AliasManager, name string, provider ProviderConfig) {
+	t.Helper()
+	if err := am.ConfigureProvider(name, provider); err != nil {
+		t.Fatalf("ConfigureProvider(%s): %v", name, err)
+	}
+}
+
+func TestCheckAIProviders(t *testing.T) {
+	server := newStubProviders(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	am := newTestManager(t)
+	mustConfigure(t, am, "local", ProviderConfig{Type: "ollama", Endpoint: server.URL + "/ollama", Model: "llama3.2"})
+	mustConfigure(t, am, "local-missing", ProviderConfig{Type: "ollama", Endpoint: server.URL + "/ollama", Model: "mistral"})
+	mustConfigure(t, am, "gateway", ProviderConfig{Type: "openai", Endpoint: server.URL + "/gateway", Model: "gpt-4o", APIKey: "sk-good"})
+	mustConfigure(t, am, "gateway-bad-key", ProviderConfig{Type: "openai", Endpoint: server.URL + "/gateway", Model: "gpt-4o", APIKey: "sk-bad"})
+	mustConfigure(t, am, "forbidden", ProviderConfig{Type: "openai", Endpoint: server.URL + "/forbidden", Model: "gpt-4o", APIKey: "sk-good"})
+	mustConfigure(t, am, "down", ProviderConfig{Type: "ollama", Endpoint: closed.URL, Model: "llama3.2"})
+
+	want := []struct {
+		name    string
+		status  string
+		models  int
+		message string
+	}{
+		{"down", CheckError, 0, "connection refused"},
+		{"forbidden", CheckError, 0, "status 403"},
+		{"gateway", CheckOK, 2, "model 'gpt-4o' available"},
+		{"gateway-bad-key", CheckError, 0, "invalid API key"},
+		{"local", CheckOK, 2, "model 'llama3.2' available"},
+		{"local-missing", CheckError, 2, "model 'mistral' is not among the 2 models"},
+	}
+	checks := am.CheckAIProviders(context.Background())
+	if len(checks) != len(want) {
+		t.Fatalf("checks = %+v, want %d", checks, len(want))
+	}
+	for i, check := range checks {
+		w := want[i]
+		if check.Name != w.name || check.Status != w.status || check.Models != w.models || !strings.Contains(check.Message, w.message) {
+			t.Errorf("check %d = %+v, want %s %s with %d models and a message containing %q", i, check, w.name, w.status, w.models, w.message)
+		}
+		if check.Latency == "" {
+			t.Errorf("check %s has no latency", check.Name)
+		}
+	}
+}
+
+func TestListAIModels(t *testing.T) {
+	server := newStubProviders(t)
+	am := newTestManager(t)
+	mustConfigure(t, am, "gateway-bad-key", ProviderConfig{Type: "openai", Endpoint: server.URL + "/gateway", Model: "gpt-4o", APIKey: "sk-bad"})
+	mustConfigure(t, am, "local", ProviderConfig{Type: "ollama", Endpoint: server.URL + "/ollama", Model: "llama3.2"})
+
+	// The provider configured last is the default
+	models, err := am.ListAIModels(context.Background(), "")
+	if err != nil {
+		t.Fatalf("ListAIModels: %v", err)
+	}
+	if strings.Join(models, ",") != "llama3.2:latest,qwen2.5-coder:7b" {
+		t.Errorf("models = %v, want the sorted Ollama models", models)
+	}
+	if !AIModelAvailable(models, "llama3.2") {
+		t.Error("llama3.2 not available, want it to match llama3.2:latest")
+	}
+
+	if _, err := am.ListAIModels(context.Background(), "gateway-bad-key"); err == nil || !strings.Contains(err.Error(), "invalid API key") {
+		t.Errorf("ListAIModels(gateway-bad-key) error = %v, want an authentication error", err)
+	}
+	if _, err := am.ListAIModels(context.Background(), "missing"); err == nil {
+		t.Error("ListAIModels(missing) succeeded, want an error")
+	}
+
+	models, err = am.ListAIModelsFor(context.Background(), "new", ProviderConfig{Type: "openai", Endpoint: server.URL + "/gateway", Model: "gpt-4o", APIKey: "sk-good"})
+	if err != nil {
+		t.Fatalf("ListAIModelsFor: %v", err)
+	}
+	if strings.Join(models, ",") != "gpt-4o,gpt-4o-mini" {
+		t.Errorf("models = %v, want the sorted gateway models", models)
+	}
+	if _, exists := am.aiManager.Providers["new"]; exists {
+		t.Error("ListAIModelsFor registered the provider")
+	}
+}
@@ -0,0 +1,99 @@
+package aliasctl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
+)
+
+// PromptTemplate describes the prompt template of an AI task.
+type PromptTemplate struct {
+	Task    string // The task, such as "generate"
+	Source  string // The template text
+	Custom  bool   // Whether the template replaces the built-in one
+	Path    string // The file that replaces the built-in template, whether or not it exists
+	Version string // Identifies the template in the AI answer cache
+}
+
+// promptsDir returns the directory of the prompt templates that replace the built-in ones.
+func (am *AliasManager) promptsDir() string {
+	return filepath.Join(am.ConfigDir, "prompts")
+}
+
+// prompts returns the prompt templates in use.
+func (am *AliasManager) prompts() *ai.Prompts {
+	return &ai.Prompts{Dir: am.promptsDir()}
+}
+
+// AIPromptTasks returns the AI tasks that have a prompt template, sorted.
+func AIPromptTasks() []string {
+	return ai.PromptTasks()
+}
+
+// AIPromptTemplate returns the prompt template of task: the file in the prompts directory
+// of the config if there is one, otherwise the built-in template.
+func (am *AliasManager) AIPromptTemplate(task string) (PromptTemplate, error) {
+	prompts := am.prompts()
+	source, custom, err := prompts.Source(task)
+	if err != nil {
+		return PromptTemplate{}, err
+	}
+	return PromptTemplate{Task: task, Source: source, Custom: custom, Path: prompts.Path(task), Version: prompts.Version(task)}, nil
+}
+
+// RenderAIPrompt renders the prompt template of task for command, with the aliases and
+// naming conventions in effect, and returns the system prompt and the prompt.
+// Conversions are rendered from the current shell to targetShell. For explanations,
+// command may name an alias, which is explained; for ask it is the description.
+func (am *AliasManager) RenderAIPrompt(task, command string, targetShell ShellType) (string, string, error) {
+	data := am.promptData(am.Shell, command)
+	switch task {
+	case ai.TaskConvert:
+		data.Shell, data.FromShell = string(targetShell), string(am.Shell)
+	case ai.TaskExplain:
+		if commands, exists := am.EffectiveAliases()[command]; exists {
+			data.Name, data.Command = command, commands.ForShell(am.Shell)
+		}
+	case ai.TaskAsk:
+		data.Command, data.Request = "", command
+	}
+	prompt, err := am.prompts().Render(task, data)
+	if err != nil {
+		return "", "", err
+	}
+	return prompt.System, prompt.User, nil
+}
+
+// SaveAIPromptTemplate replaces the built-in prompt template of task with source.
+// Returns an error, and saves nothing, if the template cannot be parsed or rendered.
+func (am *AliasManager) SaveAIPromptTemplate(task, source string) error {
+	if err := ai.CheckTemplate(task, source); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(am.promptsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create prompts directory %s: %w", am.promptsDir(), err)
+	}
+	path := am.prompts().Path(task)
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		return fmt.Errorf("failed to write prompt template %s: %w", path, err)
+	}
+	return nil
+}
+
+// ResetAIPromptTemplate removes the file replacing the built-in prompt template of task.
+// It reports whether there was one.
+func (am *AliasManager) ResetAIPromptTemplate(task string) (bool, error) {
+	if _, err := ai.DefaultTemplate(task); err != nil {
+		return false, err
+	}
+	path := am.prompts().Path(task)
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to remove prompt template %s: %w", path, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,142 @@
+package aliasctl
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
+)
+
+// AIPrice is what a model costs, in dollars per million tokens, see ai.Price.
+type AIPrice = ai.Price
+
+// AIBudgetError is returned by AI requests once the monthly budget is used up, see
+// ai.BudgetError.
+type AIBudgetError = ai.BudgetError
+
+// FormatDollars formats an amount in dollars, such as an AI cost, see ai.FormatDollars.
+func FormatDollars(amount float64) string {
+	return ai.FormatDollars(amount)
+}
+
+// Ways to group the AI requests in AIUsage.
+const (
+	UsageByCommand  = "command"  // By the command that sent them, such as generate
+	UsageByProvider = "provider" // By the provider that answered
+	UsageByModel    = "model"    // By the model that answered
+	UsageByDay      = "day"      // By day
+)
+
+// UsageGroupings returns the ways the AI requests can be grouped in AIUsage.
+func UsageGroupings() []string {
+	return []string{UsageByCommand, UsageByProvider, UsageByModel, UsageByDay}
+}
+
+// usageLedger returns the AI token usage ledger.
+func (am *AliasManager) usageLedger() *ai.UsageLedger {
+	if am.aiManager == nil {
+		am.aiManager = ai.NewManager()
+	}
+	if am.aiManager.Usage == nil {
+		am.aiManager.Usage = &ai.UsageLedger{Path: filepath.Join(am.ConfigDir, "ai-usage.toml")}
+	}
+	return am.aiManager.Usage
+}
+
+// AIUsage reports the tokens used by the AI requests of month, written as 2006-01, and
+// their estimated cost, grouped by the given UsageGroupings. An empty month is the
+// current month. Costs are estimated from the AIPrices of the config, so models without
+// a price count as free and are listed in the report. Answers from the AI cache are free
+// and not counted.
+// Returns an error if the month or a grouping is invalid or the ledger cannot be read.
+func (am *AliasManager) AIUsage(month string, groupBy []string) (AIUsageReport, error) {
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return AIUsageReport{}, fmt.Errorf("invalid month '%s': use the form 2006-01", month)
+	}
+	for _, group := range groupBy {
+		if !slices.Contains(UsageGroupings(), group) {
+			return AIUsageReport{}, fmt.Errorf("cannot group AI usage by '%s' (use %s)", group, strings.Join(UsageGroupings(), ", "))
+		}
+	}
+
+	ledger := am.usageLedger()
+	records, err := ledger.Records()
+	if err != nil {
+		return AIUsageReport{}, err
+	}
+
+	report := AIUsageReport{Month: month, Entries: []AIUsageEntry{}, Budget: ledger.MonthlyBudget}
+	groups := make(map[AIUsageEntry]*AIUsageEntry)
+	for _, record := range records {
+		if !strings.HasPrefix(record.Day, month+"-") {
+			continue
+		}
+		var key AIUsageEntry
+		for _, group := range groupBy {
+			switch group {
+			case UsageByCommand:
+				key.Command = record.Command
+			case UsageByProvider:
+				key.Provider = record.Provider
+			case UsageByModel:
+				key.Model = record.Model
+			case UsageByDay:
+				key.Day = record.Day
+			}
+		}
+		entry, exists := groups[key]
+		if !exists {
+			entry = &AIUsageEntry{Day: key.Day, Command: key.Command, Provider: key.Provider, Model: key.Model}
+			groups[key] = entry
+		}
+
+		cost, priced := ledger.Cost(record)
+		if !priced && !slices.Contains(report.Unpriced, record.Model) {
+			report.Unpriced = append(report.Unpriced, record.Model)
+		}
+		for _, totals := range []*AIUsageEntry{entry, &report.Total} {
+			totals.Requests += record.Requests
+			totals.InputTokens += record.InputTokens
+			totals.OutputTokens += record.OutputTokens
+			totals.Cost += cost
+		}
+	}
+
+	for _, entry := range groups {
+		report.Entries = append(report.Entries, *entry)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.Command != b.Command {
+			return a.Command < b.Command
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	sort.Strings(report.Unpriced)
+	return report, nil
+}
+
+// SetAIBudget sets the monthly budget for hosted AI providers in dollars. Once the
+// estimated cost of the month's requests reaches it, hosted providers are not asked
+// until the next month; local providers such as Ollama still are. A budget of zero
+// removes the limit. The configuration is saved afterwards.
+func (am *AliasManager) SetAIBudget(dollars float64) error {
+	if dollars < 0 {
+		return fmt.Errorf("invalid budget %g: must not be negative", dollars)
+	}
+	am.usageLedger().MonthlyBudget = dollars
+	return am.SaveConfig()
+}
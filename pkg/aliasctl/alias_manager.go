@@ -5,8 +5,24 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 )
 
+// aliasNamePattern matches alias names that every supported shell accepts as a plain word:
+// letters, digits and a few punctuation characters, not starting with '-'. Shell syntax
+// such as spaces, quotes, '=', ';' or '|' is refused, so a name can be written into an
+// alias file as it is.
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.:@%+][A-Za-z0-9_.:@%+-]*$`)
+
+// ValidateAliasName returns an error if name cannot be used as an alias name, see
+// aliasNamePattern.
+func ValidateAliasName(name string) error {
+	if !aliasNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid alias name '%s': use letters, digits and '_', '-', '.', ':', '@', '%%' or '+', not starting with '-'", name)
+	}
+	return nil
+}
+
 // LoadAliases loads aliases from the alias store file.
 // It reads the stored aliases from disk into memory, supporting both TOML and JSON formats.
 // If the file does not exist, it initializes an empty alias collection.
@@ -16,6 +32,7 @@ func (am *AliasManager) LoadAliases() error {
 	if err != nil {
 		if os.IsNotExist(err) {
 			am.Aliases = make(map[string]AliasCommands)
+			am.savedAliases = cloneAliases(am.Aliases)
 			return nil
 		}
 		return err
@@ -24,17 +41,35 @@ func (am *AliasManager) LoadAliases() error {
 	// Use the TOML support function if it exists, otherwise fall back to JSON
 	if err := am.AddLoadAliasesTomlSupport(data); err != nil {
 		// Fall back to JSON parsing for backward compatibility or if TOML parsing fails
-		return json.Unmarshal(data, &am.Aliases)
+		if err := json.Unmarshal(data, &am.Aliases); err != nil {
+			return err
+		}
 	}
 
+	am.savedAliases = cloneAliases(am.Aliases)
 	return nil
 }
 
 // SaveAliases saves aliases to the alias store file.
 // It writes the current aliases from memory to disk, creating any necessary directories.
 // The file is saved in TOML format if supported, otherwise JSON is used.
+// The changes since the aliases were last loaded or saved are recorded in the
+// operation journal under the label set with SetOperation; if they cannot be, a
+// JournalError is added to Warnings and the save still succeeds.
 // Returns an error if the file cannot be created or written.
 func (am *AliasManager) SaveAliases() error {
+	if err := am.writeAliasStore(); err != nil {
+		return err
+	}
+
+	if err := am.recordOperation(); err != nil {
+		am.Warnings = append(am.Warnings, &JournalError{Err: err})
+	}
+	return nil
+}
+
+// writeAliasStore writes the aliases to the alias store file.
+func (am *AliasManager) writeAliasStore() error {
 	// Create the directory if it doesn't exist
 	dir := filepath.Dir(am.AliasStore)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -77,6 +112,7 @@ func (am *AliasManager) AddAlias(name, command string) {
 	case ShellCmd:
 		commands.Cmd = command
 	}
+	commands.markAIGenerated(am.Shell, false)
 	am.Aliases[name] = commands
 }
 
@@ -91,62 +127,83 @@ func (am *AliasManager) RemoveAlias(name string) bool {
 	return false
 }
 
-// ListAliases prints all aliases for the current shell type.
-// It displays the aliases in a "name = command" format, sorted by name.
-// If no aliases are defined, it prints a message indicating that.
-func (am *AliasManager) ListAliases() {
-	fmt.Printf("Aliases for %s shell on %s platform:\n", am.Shell, am.Platform)
-	if len(am.Aliases) == 0 {
-		fmt.Println("No aliases defined.")
-		return
-	}
-
-	for name, commands := range am.Aliases {
-		var command string
-		switch am.Shell {
-		case ShellBash:
-			command = commands.Bash
-		case ShellZsh:
-			command = commands.Zsh
-		case ShellFish:
-			command = commands.Fish
-		case ShellKsh:
-			command = commands.Ksh
-		case ShellPowerShell:
-			command = commands.PowerShell
-		case ShellPowerShellCore:
-			command = commands.PowerShellCore
-		case ShellCmd:
-			command = commands.Cmd
-		}
-		if command != "" {
-			fmt.Printf("%s = %s\n", name, command)
+// SetAliasDescription sets the description of an existing alias.
+// It returns false if no alias with that name exists.
+// The change is stored in memory but not saved to disk until SaveAliases is called.
+func (am *AliasManager) SetAliasDescription(name, description string) bool {
+	commands, exists := am.Aliases[name]
+	if !exists {
+		return false
+	}
+	commands.Description = description
+	am.Aliases[name] = commands
+	return true
+}
+
+// ListAliases returns the aliases defined for the given shell, sorted by name.
+// If shell is empty, every alias is returned and each entry's Command holds the
+// command for the current shell, which may be empty.
+// Aliases from bundles are included, and each entry records the layer it comes from.
+// The result carries every per-shell command so it can be rendered in any format.
+func (am *AliasManager) ListAliases(shell ShellType) AliasList {
+	list := AliasList{
+		SchemaVersion: AliasListSchemaVersion,
+		Platform:      am.Platform,
+		Shell:         shell,
+		Aliases:       []AliasEntry{},
+	}
+
+	commandShell := shell
+	if commandShell == "" {
+		commandShell = am.Shell
+	}
+
+	aliases := am.EffectiveAliases()
+	for _, name := range sortedNames(aliases) {
+		commands := aliases[name]
+		command := commands.ForShell(commandShell)
+		if shell != "" && command == "" {
+			continue
 		}
+		list.Aliases = append(list.Aliases, AliasEntry{
+			Name:        name,
+			Command:     command,
+			Commands:    commands.Shells(),
+			Description: commands.Description,
+			Source:      am.AliasSource(name),
+			AIGenerated: commands.AIGeneratedShells(),
+		})
 	}
+
+	return list
+}
+
+// ShellInfo returns the detected shell environment and whether its files exist.
+func (am *AliasManager) ShellInfo() ShellInfo {
+	info := ShellInfo{
+		Shell:     am.Shell,
+		Platform:  am.Platform,
+		AliasFile: am.AliasFile,
+		ConfigDir: am.ConfigDir,
+	}
+	if _, err := os.Stat(am.AliasFile); err == nil {
+		info.AliasFileExists = true
+	}
+	if _, err := os.Stat(am.ConfigDir); err == nil {
+		info.ConfigDirExists = true
+	}
+	return info
 }
 
 // SetShell manually sets the shell type.
 // It validates that the shell is one of the supported types and updates the configuration.
 // Returns an error if the shell type is not supported or if saving the configuration fails.
 func (am *AliasManager) SetShell(shell string) error {
-	switch shell {
-	case "bash":
-		am.Shell = ShellBash
-	case "zsh":
-		am.Shell = ShellZsh
-	case "fish":
-		am.Shell = ShellFish
-	case "ksh":
-		am.Shell = ShellKsh
-	case "powershell":
-		am.Shell = ShellPowerShell
-	case "pwsh":
-		am.Shell = ShellPowerShellCore
-	case "cmd":
-		am.Shell = ShellCmd
-	default:
-		return fmt.Errorf("unsupported shell: %s (supported shells: bash, zsh, fish, ksh, powershell, pwsh, cmd)", shell)
+	shellType, err := ParseShellType(shell)
+	if err != nil {
+		return err
 	}
+	am.Shell = shellType
 	return am.SaveConfig()
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -46,46 +47,7 @@ func (am *AliasManager) ApplyAliases() error {
 		newContent.WriteString("# Aliases managed by AliasCtl\n")
 	}
 
-	for name, commands := range am.Aliases {
-		var command string
-		switch am.Shell {
-		case ShellBash:
-			command = commands.Bash
-		case ShellZsh:
-			command = commands.Zsh
-		case ShellFish:
-			command = commands.Fish
-		case ShellKsh:
-			command = commands.Ksh
-		case ShellPowerShell:
-			command = commands.PowerShell
-		case ShellPowerShellCore:
-			command = commands.PowerShellCore
-		case ShellCmd:
-			command = commands.Cmd
-		}
-
-		if command != "" {
-			switch am.Shell {
-			case ShellPowerShell, ShellPowerShellCore:
-				if strings.Contains(command, " ") {
-					newContent.WriteString(fmt.Sprintf("function %s { %s }\n", name, command))
-				} else {
-					newContent.WriteString(fmt.Sprintf("Set-Alias %s %s\n", name, command))
-				}
-			case ShellCmd:
-				newContent.WriteString(fmt.Sprintf("doskey %s=%s\n", name, command))
-			case ShellFish:
-				if strings.Contains(command, " ") {
-					newContent.WriteString(fmt.Sprintf("function %s\n    %s\nend\n", name, command))
-				} else {
-					newContent.WriteString(fmt.Sprintf("alias %s '%s'\n", name, command))
-				}
-			default:
-				newContent.WriteString(fmt.Sprintf("alias %s='%s'\n", name, command))
-			}
-		}
-	}
+	newContent.WriteString(am.renderAliasBlock(am.Shell))
 
 	newContent.WriteString("# End of aliases managed by AliasCtl\n")
 
@@ -104,6 +66,120 @@ func (am *AliasManager) ApplyAliases() error {
 	return os.WriteFile(am.AliasFile, []byte(newContent.String()), 0644)
 }
 
+// renderAliasBlock renders every alias in effect for the given shell as shell source,
+// including aliases from bundles. Aliases are emitted in name order so repeated
+// applies produce identical output.
+func (am *AliasManager) renderAliasBlock(shell ShellType) string {
+	var block strings.Builder
+	aliases := am.EffectiveAliases()
+	for _, name := range sortedNames(aliases) {
+		command := aliases[name].ForShell(shell)
+		if command != "" {
+			block.WriteString(formatAliasDefinition(shell, name, command))
+		}
+	}
+	return block.String()
+}
+
+// sortedAliasNames returns the names of all personal aliases in alphabetical order.
+func (am *AliasManager) sortedAliasNames() []string {
+	return sortedNames(am.Aliases)
+}
+
+// sortedNames returns the names of the given aliases in alphabetical order.
+func sortedNames(aliases map[string]AliasCommands) []string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatAliasDefinition formats a single alias using the syntax of the given shell.
+// Commands containing spaces are wrapped in functions for PowerShell and fish,
+// since their alias mechanisms cannot take arguments.
+func formatAliasDefinition(shell ShellType, name, command string) string {
+	switch shell {
+	case ShellPowerShell, ShellPowerShellCore:
+		if strings.Contains(command, " ") {
+			return fmt.Sprintf("function %s { %s }\n", name, command)
+		}
+		return fmt.Sprintf("Set-Alias %s %s\n", name, command)
+	case ShellCmd:
+		return fmt.Sprintf("doskey %s=%s\n", name, command)
+	case ShellFish:
+		if strings.Contains(command, " ") {
+			return fmt.Sprintf("function %s\n    %s\nend\n", name, command)
+		}
+		return fmt.Sprintf("alias %s '%s'\n", name, command)
+	default:
+		return fmt.Sprintf("alias %s='%s'\n", name, command)
+	}
+}
+
+// ParseAliasDefinition attempts to extract the alias name and command from a definition
+// in the syntax of the given shell, such as one suggested by an AI provider.
+// Returns empty strings if the definition cannot be parsed.
+func ParseAliasDefinition(definition string, shell ShellType) (name string, command string) {
+	definition = strings.TrimSpace(definition)
+
+	switch shell {
+	case ShellBash, ShellZsh, ShellFish, ShellKsh:
+		if strings.HasPrefix(definition, "alias ") {
+			parts := strings.SplitN(strings.TrimPrefix(definition, "alias "), "=", 2)
+			if len(parts) == 2 && !strings.ContainsAny(strings.TrimSpace(parts[0]), " '\"") {
+				name = strings.TrimSpace(parts[0])
+				// Remove surrounding quotes
+				command = strings.Trim(strings.TrimSpace(parts[1]), "'\"")
+				return
+			}
+			// fish also accepts: alias name 'command'
+			if words, err := splitShellWords(definition, shell == ShellFish); err == nil && len(words) >= 3 {
+				return words[1], strings.Join(words[2:], " ")
+			}
+		}
+	case ShellPowerShell, ShellPowerShellCore:
+		if strings.HasPrefix(definition, "Set-Alias ") {
+			parts := strings.Fields(strings.TrimPrefix(definition, "Set-Alias "))
+			if len(parts) >= 2 {
+				name = parts[0]
+				command = parts[1]
+				return
+			}
+		} else if strings.HasPrefix(definition, "function ") {
+			parts := strings.SplitN(strings.TrimPrefix(definition, "function "), " {", 2)
+			if len(parts) == 2 {
+				name = strings.TrimSpace(parts[0])
+				command = strings.TrimSpace(strings.TrimSuffix(parts[1], "}"))
+				return
+			}
+		}
+	case ShellCmd:
+		if strings.HasPrefix(definition, "doskey ") {
+			parts := strings.SplitN(strings.TrimPrefix(definition, "doskey "), "=", 2)
+			if len(parts) == 2 {
+				name = strings.TrimSpace(parts[0])
+				command = strings.TrimSpace(parts[1])
+				return
+			}
+		}
+	}
+
+	// Fallback: if we couldn't parse with the specific shell format,
+	// try to use a generic approach - look for the first space or equals
+	if strings.Contains(definition, "=") {
+		parts := strings.SplitN(definition, "=", 2)
+		if len(parts) == 2 {
+			name = strings.TrimSpace(parts[0])
+			command = strings.TrimSpace(parts[1])
+			return
+		}
+	}
+
+	return "", ""
+}
+
 // ImportAliasesFromShell imports aliases from the shell configuration file.
 // It parses the shell configuration file to extract alias definitions using
 // shell-specific patterns, and adds them to the AliasManager's collection.
@@ -213,76 +289,58 @@ func (am *AliasManager) ImportAliasesFromShell() error {
 		}
 	}
 
+	am.SetOperation(OpImport, fmt.Sprintf("import from %s", am.AliasFile))
 	return am.SaveAliases()
 }
 
-// ExportAliases exports aliases to a different shell format.
-// It writes all aliases to a specified file, formatted according to the
-// syntax rules of the target shell. If AI is configured and the target shell
-// differs from the current shell, it will attempt to convert the aliases.
-// Returns an error if the file cannot be created or written.
-func (am *AliasManager) ExportAliases(targetShell, outputFile string) error {
-	var content strings.Builder
-	content.WriteString("# Aliases exported by AliasCtl\n")
-
-	for name, commands := range am.Aliases {
-		var command string
-		switch targetShell {
-		case "bash":
-			command = commands.Bash
-		case "zsh":
-			command = commands.Zsh
-		case "fish":
-			command = commands.Fish
-		case "ksh":
-			command = commands.Ksh
-		case "powershell":
-			command = commands.PowerShell
-		case "pwsh":
-			command = commands.PowerShellCore
-		case "cmd":
-			command = commands.Cmd
-		}
-
-		if command != "" {
-			if am.AIConfigured && string(am.Shell) != targetShell {
-				convertedCommand, err := am.ConvertAlias(name, targetShell, "")
-				if err == nil {
-					command = convertedCommand
-				}
-			}
-
-			switch targetShell {
-			case "powershell", "pwsh":
-				if strings.Contains(command, " ") {
-					content.WriteString(fmt.Sprintf("function %s { %s }\n", name, command))
-				} else {
-					content.WriteString(fmt.Sprintf("Set-Alias %s %s\n", name, command))
-				}
-			case "cmd":
-				content.WriteString(fmt.Sprintf("doskey %s=%s\n", name, command))
-			case "fish":
-				if strings.Contains(command, " ") {
-					content.WriteString(fmt.Sprintf("function %s\n    %s\nend\n", name, command))
-				} else {
-					content.WriteString(fmt.Sprintf("alias %s '%s'\n", name, command))
-				}
-			default:
-				content.WriteString(fmt.Sprintf("alias %s='%s'\n", name, command))
-			}
-		}
+// ExportAliases exports aliases using the exporter registered as target, a shell type
+// or another format such as json or nix (see Exporters). All aliases in effect are
+// exported, including those from bundles. AI-produced commands still waiting for review
+// are not exported, and a shell export leaves out aliases without a command for that
+// shell (see AliasesWithoutShell); use ConvertAllAliases and ResolvePending to fill
+// them in.
+// Returns an error if the target is unknown or the file cannot be written.
+func (am *AliasManager) ExportAliases(target, outputFile string) error {
+	exporter, err := GetExporter(target)
+	if err != nil {
+		return err
 	}
 
-	content.WriteString("# End of exported aliases\n")
+	content, err := exporter.Export(am.EffectiveAliases())
+	if err != nil {
+		return fmt.Errorf("failed to export aliases as %s: %w", target, err)
+	}
 
 	dir := filepath.Dir(outputFile)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory %s: %w (check directory permissions)", dir, err)
 	}
 
-	if err := os.WriteFile(outputFile, []byte(content.String()), 0644); err != nil {
+	if err := os.WriteFile(outputFile, content, 0644); err != nil {
 		return fmt.Errorf("failed to write to file %s: %w (check file permissions)", outputFile, err)
 	}
 
 	return nil
 }
+
+// AliasesWithoutShell returns the names of the aliases in effect that have no command for
+// shell, sorted, and which of them have a command for it waiting for review. These are
+// the aliases ExportAliases leaves out of an export for the shell.
+func (am *AliasManager) AliasesWithoutShell(shell ShellType) (missing, pending []string, err error) {
+	pendingCommands, err := am.PendingCommands()
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, commands := range am.EffectiveAliases() {
+		if commands.ForShell(shell) != "" {
+			continue
+		}
+		missing = append(missing, name)
+		if isPending(pendingCommands, name, shell) {
+			pending = append(pending, name)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(pending)
+	return missing, pending, nil
+}
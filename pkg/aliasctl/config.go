@@ -6,6 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
@@ -67,78 +70,49 @@ func (am *AliasManager) LoadConfig() error {
 	am.Shell = config.DefaultShell
 	am.AliasFile = config.DefaultAliasFile
 	am.EncryptionUsed = config.UseEncryption
+	am.TrustedBundleKeys = config.TrustedBundleKeys
+	am.RequireSignedBundles = config.RequireSignedBundles
+	am.AISafetyPolicy = config.AISafetyPolicy
+	am.AINamingConventions = config.AINamingConventions
 
 	// Initialize aiManager if nil
 	if am.aiManager == nil {
 		am.aiManager = ai.NewManager()
 	}
-
-	// Handle API configuration - check for encrypted keys first
-	if config.OllamaEndpoint != "" && config.OllamaModel != "" {
-		am.ConfigureOllama(config.OllamaEndpoint, config.OllamaModel)
-	}
-
-	// Handle OpenAI configuration
-	if config.OpenAIEndpoint != "" && config.OpenAIModel != "" {
-		var apiKey string
-
-		// Try to use encrypted key first
-		if config.UseEncryption && config.OpenAIKeyEncrypted != "" {
-			decryptedKey, err := DecryptString(config.OpenAIKeyEncrypted, am.EncryptionKey)
-			if err == nil {
-				apiKey = decryptedKey
-			} else {
-				fmt.Printf("Warning: Failed to decrypt OpenAI API key: %v\n", err)
-				if _, ok := err.(*KeyFileNotFoundError); ok {
-					fmt.Printf("Encryption key file not found at: %s\n", am.EncryptionKey)
-					fmt.Printf("Use 'aliasctl encrypt-api-keys' to set up encryption\n")
-				}
-
-				// Fallback to plaintext key with warning if available
-				if config.OpenAIKey != "" {
-					fmt.Println("Warning: Using plaintext API key from config. Consider encrypting your API keys.")
-					apiKey = config.OpenAIKey
-				}
-			}
-		} else if config.OpenAIKey != "" {
-			fmt.Println("Warning: API key is stored in plaintext. Use 'aliasctl encrypt-api-keys' to encrypt it.")
-			apiKey = config.OpenAIKey
-		}
-
-		if apiKey != "" {
-			am.ConfigureOpenAI(config.OpenAIEndpoint, apiKey, config.OpenAIModel)
-		}
+	am.aiManager.Fallback = config.AIFallbackChain
+	if am.aiManager.Cache == nil {
+		am.aiManager.Cache = &ai.Cache{Dir: filepath.Join(am.ConfigDir, "ai-cache")}
 	}
-
-	// Handle Anthropic configuration
-	if config.AnthropicEndpoint != "" && config.AnthropicModel != "" {
-		var apiKey string
-
-		// Try to use encrypted key first
-		if config.UseEncryption && config.AnthropicKeyEncrypted != "" {
-			decryptedKey, err := DecryptString(config.AnthropicKeyEncrypted, am.EncryptionKey)
-			if err == nil {
-				apiKey = decryptedKey
-			} else {
-				fmt.Printf("Warning: Failed to decrypt Anthropic API key: %v\n", err)
-				if _, ok := err.(*KeyFileNotFoundError); ok {
-					fmt.Printf("Encryption key file not found at: %s\n", am.EncryptionKey)
-					fmt.Printf("Use 'aliasctl encrypt-api-keys' to set up encryption\n")
-				}
-
-				// Fallback to plaintext key with warning if available
-				if config.AnthropicKey != "" {
-					fmt.Println("Warning: Using plaintext Anthropic API key from config. Consider encrypting your API keys.")
-					apiKey = config.AnthropicKey
-				}
-			}
-		} else if config.AnthropicKey != "" {
-			fmt.Println("Warning: Anthropic API key is stored in plaintext. Use 'aliasctl encrypt-api-keys' to encrypt it.")
-			apiKey = config.AnthropicKey
+	am.aiManager.Cache.MaxSize = config.AICacheMaxSize
+	if am.aiManager.Usage == nil {
+		am.aiManager.Usage = &ai.UsageLedger{Path: filepath.Join(am.ConfigDir, "ai-usage.toml")}
+	}
+	am.aiManager.Usage.Prices = config.AIPrices
+	am.aiManager.Usage.MonthlyBudget = config.AIMonthlyBudget
+	am.aiManager.Prompts = am.prompts()
+	am.aiManager.Cache.TTL = 0
+	if config.AICacheTTL != "" {
+		if ttl, err := time.ParseDuration(config.AICacheTTL); err == nil && ttl > 0 {
+			am.aiManager.Cache.TTL = ttl
+		} else {
+			fmt.Printf("Warning: Ignoring invalid AI cache TTL '%s' in config, using the default\n", config.AICacheTTL)
 		}
+	}
 
-		if apiKey != "" {
-			am.ConfigureAnthropic(config.AnthropicEndpoint, apiKey, config.AnthropicModel)
+	// Handle API configuration - check for encrypted keys first
+	providers := config.providerConfigs()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	am.aiUnloaded = make(map[string]ProviderConfig)
+	am.aiLoadErrors = make(map[string]error)
+	for _, name := range names {
+		if err := am.loadProvider(name, providers[name], config.UseEncryption); err != nil {
+			fmt.Printf("Warning: Ignoring AI provider '%s' in config: %v\n", name, err)
+			am.aiUnloaded[name] = providers[name]
+			am.aiLoadErrors[name] = err
 		}
 	}
 
@@ -147,16 +121,52 @@ func (am *AliasManager) LoadConfig() error {
 		am.aiManager.SetDefaultProvider(config.AIProvider)
 	}
 
+	// Move providers from the flat fields to provider tables
+	if config.hasLegacyProviders() {
+		if err := am.migrateProviderConfig(data); err != nil {
+			fmt.Printf("Warning: Failed to move AI providers to provider tables: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateProviderConfig saves the configuration with the AI providers in provider tables
+// instead of the flat fields, after backing up the original configuration data.
+func (am *AliasManager) migrateProviderConfig(data []byte) error {
+	backupFile := am.ConfigFile + ".flat.bak"
+	if err := os.WriteFile(backupFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to create backup file %s: %w (check disk space and permissions)", backupFile, err)
+	}
+	if err := am.SaveConfig(); err != nil {
+		return err
+	}
+	fmt.Printf("AI providers moved to [providers] tables in the config. Original config backup saved as %s\n", backupFile)
 	return nil
 }
 
 // SaveConfig saves the application configuration in TOML format.
 func (am *AliasManager) SaveConfig() error {
 	config := Config{
-		DefaultShell:     am.Shell,
-		DefaultAliasFile: am.AliasFile,
-		UseEncryption:    am.EncryptionUsed,
-		AIProviders:      make(map[string]bool),
+		DefaultShell:         am.Shell,
+		DefaultAliasFile:     am.AliasFile,
+		UseEncryption:        am.EncryptionUsed,
+		AIProviders:          make(map[string]bool),
+		TrustedBundleKeys:    am.TrustedBundleKeys,
+		RequireSignedBundles: am.RequireSignedBundles,
+		AISafetyPolicy:       am.AISafetyPolicy,
+		AINamingConventions:  am.AINamingConventions,
+	}
+	if am.aiManager != nil {
+		config.AIFallbackChain = am.aiManager.Fallback
+		if am.aiManager.Cache != nil {
+			config.AICacheTTL = formatDuration(am.aiManager.Cache.TTL)
+			config.AICacheMaxSize = am.aiManager.Cache.MaxSize
+		}
+		if am.aiManager.Usage != nil {
+			config.AIPrices = am.aiManager.Usage.Prices
+			config.AIMonthlyBudget = am.aiManager.Usage.MonthlyBudget
+		}
 	}
 
 	// Track which providers are configured
@@ -165,65 +175,33 @@ func (am *AliasManager) SaveConfig() error {
 		config.AIProviders[name] = true
 	}
 
-	// Get default provider name
-	if am.aiManager != nil && am.aiManager.Default != nil {
-		// Determine the provider type
-		switch am.aiManager.Default.(type) {
-		case *ai.OllamaProvider:
-			config.AIProvider = "ollama"
-		case *ai.OpenAIProvider:
-			config.AIProvider = "openai"
-		case *ai.AnthropicProvider:
-			config.AIProvider = "anthropic"
-		}
-	}
-
 	// Configure providers
-	ollamaProvider, ok := am.aiManager.Providers["ollama"].(*ai.OllamaProvider)
-	if ok {
-		config.OllamaEndpoint = ollamaProvider.Endpoint
-		config.OllamaModel = ollamaProvider.Model
-	}
-
-	openAIProvider, ok := am.aiManager.Providers["openai"].(*ai.OpenAIProvider)
-	if ok {
-		config.OpenAIEndpoint = openAIProvider.Endpoint
-		config.OpenAIModel = openAIProvider.Model
-
-		// Handle API key encryption
-		if am.EncryptionUsed {
-			encryptedKey, err := EncryptString(openAIProvider.APIKey, am.EncryptionKey)
-			if err == nil {
-				config.OpenAIKeyEncrypted = encryptedKey
-				config.OpenAIKey = "" // Clear plaintext key
-			} else {
-				fmt.Printf("Warning: Failed to encrypt API key: %v\n", err)
-				fmt.Printf("API key will be stored in plaintext. Run 'aliasctl encrypt-api-keys' to retry encryption.\n")
-				config.OpenAIKey = openAIProvider.APIKey
-			}
-		} else {
-			config.OpenAIKey = openAIProvider.APIKey
+	if am.aiManager != nil {
+		config.AIProvider = am.aiManager.DefaultName()
+		config.Providers = make(map[string]ProviderConfig, len(providers)+len(am.aiUnloaded))
+		for name, provider := range am.aiUnloaded {
+			config.Providers[name] = provider
 		}
-	}
-
-	anthropicProvider, ok := am.aiManager.Providers["anthropic"].(*ai.AnthropicProvider)
-	if ok {
-		config.AnthropicEndpoint = anthropicProvider.Endpoint
-		config.AnthropicModel = anthropicProvider.Model
-
-		// Handle API key encryption
-		if am.EncryptionUsed {
-			encryptedKey, err := EncryptString(anthropicProvider.APIKey, am.EncryptionKey)
-			if err == nil {
-				config.AnthropicKeyEncrypted = encryptedKey
-				config.AnthropicKey = "" // Clear plaintext key
-			} else {
-				fmt.Printf("Warning: Failed to encrypt Anthropic API key: %v\n", err)
-				fmt.Printf("API key will be stored in plaintext. Run 'aliasctl encrypt-api-keys' to retry encryption.\n")
-				config.AnthropicKey = anthropicProvider.APIKey
+		for _, name := range providers {
+			info := am.aiManager.Providers[name].Info()
+			provider := ProviderConfig{
+				Type:          info.Type,
+				Endpoint:      info.Endpoint,
+				Model:         info.Model,
+				APIKeyRef:     am.aiKeyRefs[name],
+				Timeout:       formatDuration(info.Timeout),
+				Headers:       info.Headers,
+				Options:       info.Options,
+				Proxy:         info.Transport.Proxy,
+				CACert:        info.Transport.CAFile,
+				ClientCert:    info.Transport.CertFile,
+				ClientKey:     info.Transport.KeyFile,
+				TLSMinVersion: info.Transport.TLSMinVersion,
 			}
-		} else {
-			config.AnthropicKey = anthropicProvider.APIKey
+			if provider.APIKeyRef == "" {
+				provider.APIKey, provider.APIKeyEncrypted = am.saveAPIKey(name, info.APIKey)
+			}
+			config.Providers[name] = provider
 		}
 	}
 
@@ -257,6 +235,153 @@ func (am *AliasManager) SaveConfig() error {
 	return nil
 }
 
+// loadProvider registers the AI provider saved under name, reading or decrypting its API
+// key. Providers without an endpoint or model are skipped.
+func (am *AliasManager) loadProvider(name string, provider ProviderConfig, useEncryption bool) error {
+	if provider.Endpoint == "" || provider.Model == "" {
+		return nil
+	}
+	providerType, err := ai.GetProviderType(provider.Type)
+	if err != nil {
+		return err
+	}
+
+	var apiKey string
+	if provider.APIKeyRef != "" {
+		if apiKey, err = resolveSecret(provider.APIKeyRef); err != nil {
+			return err
+		}
+	} else {
+		apiKey = am.loadAPIKey(name, provider, useEncryption)
+	}
+	if providerType.NeedsAPIKey && apiKey == "" {
+		return fmt.Errorf("no API key")
+	}
+
+	info := ai.ProviderInfo{Type: provider.Type, Endpoint: provider.Endpoint, APIKey: apiKey, Model: provider.Model, Options: provider.Options, Headers: provider.Headers, Transport: provider.transport()}
+	if provider.Timeout != "" {
+		if timeout, err := time.ParseDuration(provider.Timeout); err == nil && timeout > 0 {
+			info.Timeout = timeout
+		} else {
+			fmt.Printf("Warning: Ignoring invalid %s timeout '%s' in config, using the default\n", name, provider.Timeout)
+		}
+	}
+	if err := am.addAIProvider(name, info); err != nil {
+		return err
+	}
+	am.setAIKeyRef(name, provider.APIKeyRef)
+	return nil
+}
+
+// transport returns how requests reach the endpoint of the provider.
+func (p ProviderConfig) transport() ai.Transport {
+	return ai.Transport{Proxy: p.Proxy, CAFile: p.CACert, CertFile: p.ClientCert, KeyFile: p.ClientKey, TLSMinVersion: p.TLSMinVersion}
+}
+
+// resolveSecret reads a secret from where ref points: "env:NAME" reads an environment
+// variable and "file:PATH" the first line of a file.
+func resolveSecret(ref string) (string, error) {
+	kind, location, _ := strings.Cut(ref, ":")
+	switch kind {
+	case "env":
+		value := os.Getenv(location)
+		if value == "" {
+			return "", fmt.Errorf("environment variable %s for the API key is not set", location)
+		}
+		return value, nil
+	case "file":
+		if rest, ok := strings.CutPrefix(location, "~/"); ok {
+			homeDir, _ := os.UserHomeDir()
+			location = filepath.Join(homeDir, rest)
+		}
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return "", fmt.Errorf("failed to read API key file: %w", err)
+		}
+		value, _, _ := strings.Cut(string(data), "\n")
+		if value = strings.TrimSpace(value); value == "" {
+			return "", fmt.Errorf("API key file %s is empty", location)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid API key reference '%s': use env:NAME or file:PATH", ref)
+}
+
+// loadAPIKey returns the API key of the AI provider saved under name, preferring the
+// encrypted key and falling back to the plaintext key with a warning.
+func (am *AliasManager) loadAPIKey(name string, provider ProviderConfig, useEncryption bool) string {
+	if useEncryption && provider.APIKeyEncrypted != "" {
+		decryptedKey, err := DecryptString(provider.APIKeyEncrypted, am.EncryptionKey)
+		if err == nil {
+			return decryptedKey
+		}
+		fmt.Printf("Warning: Failed to decrypt %s API key: %v\n", name, err)
+		if _, ok := err.(*KeyFileNotFoundError); ok {
+			fmt.Printf("Encryption key file not found at: %s\n", am.EncryptionKey)
+			fmt.Printf("Use 'aliasctl encrypt-api-keys' to set up encryption\n")
+		}
+
+		// Fallback to plaintext key with warning if available
+		if provider.APIKey != "" {
+			fmt.Printf("Warning: Using plaintext %s API key from config. Consider encrypting your API keys.\n", name)
+		}
+		return provider.APIKey
+	}
+	if provider.APIKey != "" {
+		fmt.Printf("Warning: %s API key is stored in plaintext. Use 'aliasctl encrypt-api-keys' to encrypt it.\n", name)
+	}
+	return provider.APIKey
+}
+
+// saveAPIKey returns the plaintext and encrypted API key to save for the AI provider
+// registered under name. Only one of them is set: the encrypted key when encryption is
+// used and succeeds, otherwise the plaintext key.
+func (am *AliasManager) saveAPIKey(name, apiKey string) (string, string) {
+	if apiKey == "" || !am.EncryptionUsed {
+		return apiKey, ""
+	}
+	encryptedKey, err := EncryptString(apiKey, am.EncryptionKey)
+	if err != nil {
+		fmt.Printf("Warning: Failed to encrypt %s API key: %v\n", name, err)
+		fmt.Printf("API key will be stored in plaintext. Run 'aliasctl encrypt-api-keys' to retry encryption.\n")
+		return apiKey, ""
+	}
+	return "", encryptedKey
+}
+
+// providerConfigs returns the saved AI providers by name, including the providers saved
+// in the flat fields by earlier versions.
+func (c Config) providerConfigs() map[string]ProviderConfig {
+	providers := make(map[string]ProviderConfig, len(c.Providers)+3)
+	if c.OllamaEndpoint != "" {
+		providers["ollama"] = ProviderConfig{Type: "ollama", Endpoint: c.OllamaEndpoint, Model: c.OllamaModel, Timeout: c.OllamaTimeout}
+	}
+	if c.OpenAIEndpoint != "" {
+		providers["openai"] = ProviderConfig{Type: "openai", Endpoint: c.OpenAIEndpoint, APIKey: c.OpenAIKey, APIKeyEncrypted: c.OpenAIKeyEncrypted, Model: c.OpenAIModel, Timeout: c.OpenAITimeout}
+	}
+	if c.AnthropicEndpoint != "" {
+		providers["anthropic"] = ProviderConfig{Type: "anthropic", Endpoint: c.AnthropicEndpoint, APIKey: c.AnthropicKey, APIKeyEncrypted: c.AnthropicKeyEncrypted, Model: c.AnthropicModel, Timeout: c.AnthropicTimeout}
+	}
+	for name, provider := range c.Providers {
+		if provider.Type == "" {
+			provider.Type = name
+		}
+		providers[name] = provider
+	}
+	return providers
+}
+
+// hasLegacyProviders reports whether AI providers are saved in the flat fields, or in
+// provider tables without a type.
+func (c Config) hasLegacyProviders() bool {
+	for _, provider := range c.Providers {
+		if provider.Type == "" {
+			return true
+		}
+	}
+	return c.OllamaEndpoint != "" || c.OpenAIEndpoint != "" || c.AnthropicEndpoint != ""
+}
+
 // convertConfigToTOML reads the existing JSON config file, parses it, and writes it back as TOML.
 // It creates a backup of the original JSON file before conversion.
 func (am *AliasManager) convertConfigToTOML() error {
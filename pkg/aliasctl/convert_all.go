@@ -0,0 +1,292 @@
+package aliasctl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultConvertConcurrency is the number of conversions run at once by ConvertAllAliases.
+const DefaultConvertConcurrency = 4
+
+// ConversionResult reports the outcome of converting one alias to one shell.
+type ConversionResult struct {
+	Name     string        // The alias name
+	Shell    ShellType     // The shell the alias was converted to
+	From     ShellType     // The shell whose command was converted
+	Command  string        // The converted command, empty if the conversion failed
+	Provider string        // The name of the provider that answered, empty if resumed or failed
+	Cached   bool          // Whether the answer came from the AI answer cache
+	Risks    []RiskFinding // What ScreenAICommand found in the converted command
+	Resumed  bool          // Whether the result was saved by an earlier, interrupted run
+	Err      error         // Why the conversion failed, if it did
+}
+
+// conversionCheckpoint is a finished conversion saved while ConvertAllAliases runs, so
+// an interrupted run can be resumed without asking the AI provider again.
+type conversionCheckpoint struct {
+	Name    string    `json:"name"`    // The alias name
+	Shell   ShellType `json:"shell"`   // The shell the alias was converted to
+	Source  string    `json:"source"`  // The command that was converted
+	Command string    `json:"command"` // The converted command
+}
+
+// conversionJob is a single conversion to run.
+type conversionJob struct {
+	name   string
+	shell  ShellType
+	from   ShellType
+	source string
+}
+
+// convertCheckpointFile returns the path of the checkpoint of an unfinished ConvertAllAliases run.
+func (am *AliasManager) convertCheckpointFile() string {
+	return filepath.Join(am.ConfigDir, "convert-checkpoint.jsonl")
+}
+
+// ConvertAllAliases converts an existing command of every personal alias with the AI
+// provider for each target shell the alias has no command for, and queues the results
+// for review (see PendingCommands). Shells that already have a command waiting for
+// review are skipped. At most concurrency conversions run at once, and progress, if not
+// nil, is called after each one.
+//
+// Each finished conversion is saved to a checkpoint file right away. The results are
+// only queued once every conversion has finished; if the run is interrupted, the next
+// run picks up the saved conversions and only converts the rest.
+//
+// Canceling ctx stops the run: no further conversions are started, requests in flight are
+// aborted, and an error is returned with the checkpoint kept for the next run. The run
+// stops the same way if a finished conversion cannot be written to the checkpoint.
+//
+// Every converted command is screened with ScreenAICommand. Returns the result of every
+// conversion sorted by alias and shell. Failed and blocked conversions are reported in
+// the results and are not queued.
+func (am *AliasManager) ConvertAllAliases(ctx context.Context, targets []ShellType, providerName string, concurrency int, progress func(done, total int, result ConversionResult)) ([]ConversionResult, error) {
+	if !am.AIConfigured {
+		return nil, fmt.Errorf("AI provider not configured. Use 'aliasctl configure-ollama', 'aliasctl configure-openai', or 'aliasctl configure-anthropic' to set up an AI provider")
+	}
+	if concurrency < 1 {
+		concurrency = DefaultConvertConcurrency
+	}
+
+	pendingCommands, err := am.PendingCommands()
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []conversionJob
+	for _, name := range am.sortedAliasNames() {
+		commands := am.Aliases[name]
+		for _, shell := range targets {
+			if commands.ForShell(shell) != "" || isPending(pendingCommands, name, shell) {
+				continue
+			}
+			if from, source := am.conversionSource(commands); source != "" {
+				jobs = append(jobs, conversionJob{name: name, shell: shell, from: from, source: source})
+			}
+		}
+	}
+	if len(jobs) == 0 {
+		os.Remove(am.convertCheckpointFile())
+		return nil, nil
+	}
+
+	saved := am.readConvertCheckpoint()
+	checkpoint, err := os.OpenFile(am.convertCheckpointFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open conversion checkpoint %s: %w (check file permissions)", am.convertCheckpointFile(), err)
+	}
+	defer checkpoint.Close()
+
+	results := make([]ConversionResult, 0, len(jobs))
+	report := func(result ConversionResult) {
+		results = append(results, result)
+		if progress != nil {
+			progress(len(results), len(jobs), result)
+		}
+	}
+
+	var remaining []conversionJob
+	for _, job := range jobs {
+		entry, ok := saved[job.name+"\x00"+string(job.shell)]
+		if ok && entry.Source == job.source {
+			result := ConversionResult{Name: job.name, Shell: job.shell, From: job.from, Command: entry.Command, Resumed: true}
+			if result.Risks, result.Err = am.ScreenAICommand(entry.Command, job.source); result.Err != nil {
+				result.Command = ""
+			}
+			report(result)
+		} else {
+			remaining = append(remaining, job)
+		}
+	}
+
+	// A conversion that cannot be saved would be lost to an interruption, so a failed
+	// checkpoint write stops the run
+	runCtx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	var checkpointErr error
+
+	promptBase := am.promptData("", "")
+	queue := make(chan conversionJob)
+	finished := make(chan ConversionResult)
+	var writeMu sync.Mutex
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency && i < len(remaining); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range queue {
+				result := ConversionResult{Name: job.name, Shell: job.shell, From: job.from}
+				data := promptBase
+				data.Shell, data.FromShell, data.Command = string(job.shell), string(job.from), job.source
+				output, err := am.aiManager.ConvertAlias(runCtx, data, providerName, nil)
+				if err == nil {
+					result.Provider, result.Cached = output.Provider, output.Cached
+					if result.Command = convertedAlias(job.name, output, job.shell).Command; result.Command == "" {
+						err = fmt.Errorf("the AI provider returned no command")
+					}
+				}
+				if err == nil {
+					if result.Risks, err = am.ScreenAICommand(result.Command, job.source); err != nil {
+						result.Command = ""
+					}
+				}
+				if err != nil {
+					result.Err = err
+				} else {
+					line, _ := json.Marshal(conversionCheckpoint{Name: job.name, Shell: job.shell, Source: job.source, Command: result.Command})
+					writeMu.Lock()
+					if _, err := checkpoint.Write(append(line, '\n')); err != nil && checkpointErr == nil {
+						checkpointErr = fmt.Errorf("failed to write conversion checkpoint %s: %w", am.convertCheckpointFile(), err)
+						stop(checkpointErr)
+					}
+					writeMu.Unlock()
+				}
+				finished <- result
+			}
+		}()
+	}
+	go func() {
+	feed:
+		for _, job := range remaining {
+			select {
+			case queue <- job:
+			case <-runCtx.Done():
+				break feed
+			}
+		}
+		close(queue)
+		workers.Wait()
+		close(finished)
+	}()
+	for result := range finished {
+		report(result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].Shell < results[j].Shell
+	})
+	if checkpointErr != nil {
+		return results, fmt.Errorf("conversion stopped: %w (conversions saved before it are kept, run it again to resume)", checkpointErr)
+	}
+	if ctx.Err() != nil {
+		return results, fmt.Errorf("conversion interrupted: %w (finished conversions are saved, run it again to resume)", context.Cause(ctx))
+	}
+
+	var converted []PendingCommand
+	for _, result := range results {
+		if result.Err == nil {
+			converted = append(converted, PendingCommand{
+				Name:          result.Name,
+				Shell:         result.Shell,
+				Command:       result.Command,
+				SourceShell:   result.From,
+				SourceCommand: am.Aliases[result.Name].ForShell(result.From),
+				Origin:        OriginConvert,
+				Risks:         result.Risks,
+			})
+		}
+	}
+	if err := am.AddPendingCommands(converted); err != nil {
+		return results, err
+	}
+
+	checkpoint.Close()
+	os.Remove(am.convertCheckpointFile())
+	return results, nil
+}
+
+// conversionSource picks the command an alias is converted from: the current shell's
+// command if it was written by hand, otherwise the first hand-written command, otherwise
+// any command. Returns an empty command if the alias has none.
+func (am *AliasManager) conversionSource(commands AliasCommands) (ShellType, string) {
+	if command := commands.ForShell(am.Shell); command != "" && !commands.IsAIGenerated(am.Shell) {
+		return am.Shell, command
+	}
+	for _, handWritten := range []bool{true, false} {
+		for _, shell := range SupportedShells {
+			command := commands.ForShell(shell)
+			if command != "" && (!handWritten || !commands.IsAIGenerated(shell)) {
+				return shell, command
+			}
+		}
+	}
+	return "", ""
+}
+
+// readConvertCheckpoint reads the conversions saved by an interrupted ConvertAllAliases
+// run, keyed by alias name and shell. Unreadable lines are skipped.
+func (am *AliasManager) readConvertCheckpoint() map[string]conversionCheckpoint {
+	saved := make(map[string]conversionCheckpoint)
+	file, err := os.Open(am.convertCheckpointFile())
+	if err != nil {
+		return saved
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry conversionCheckpoint
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			saved[entry.Name+"\x00"+string(entry.Shell)] = entry
+		}
+	}
+	return saved
+}
+
+// cleanConvertedCommand extracts the command from a text AI conversion response, dropping
+// Markdown code fences and unwrapping an alias definition if the provider returned one.
+func cleanConvertedCommand(output string, shell ShellType) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "```") {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	first := strings.Trim(lines[0], "`")
+	if strings.HasPrefix(first, "function ") && len(lines) >= 3 && lines[len(lines)-1] == "end" {
+		return strings.Join(lines[1:len(lines)-1], "; ")
+	}
+	for _, prefix := range []string{"alias ", "Set-Alias ", "function ", "doskey "} {
+		if strings.HasPrefix(first, prefix) {
+			if _, command := ParseAliasDefinition(first, shell); command != "" {
+				return command
+			}
+		}
+	}
+	return first
+}
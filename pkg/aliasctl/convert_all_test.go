@@ -0,0 +1,128 @@
+package aliasctl
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
+)
+
+// convertStub is an AI provider that converts a command to itself. It cancels the run
+// after cancelAfter conversions, if set, and refuses prompts once ctx is canceled.
+type convertStub struct {
+	commands    []string // The commands it knows, found in the prompt
+	cancelAfter int
+	cancel      context.CancelFunc
+
+	mu        sync.Mutex
+	converted []string
+}
+
+func (p *convertStub) Complete(ctx context.Context, prompt ai.Prompt, stream ai.StreamFunc) (ai.AliasResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ai.AliasResult{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, command := range p.commands {
+		if strings.Contains(prompt.User+prompt.Text, command) {
+			p.converted = append(p.converted, command)
+			if len(p.converted) == p.cancelAfter {
+				p.cancel()
+			}
+			return ai.AliasResult{Command: command}, nil
+		}
+	}
+	return ai.AliasResult{}, nil
+}
+
+func (p *convertStub) Info() ai.ProviderInfo {
+	return ai.ProviderInfo{Type: "stub", Model: "convert"}
+}
+
+// useConvertStub replaces the AI providers of am with a convertStub that knows commands.
+func useConvertStub(am *AliasManager, commands ...string) *convertStub {
+	provider := &convertStub{commands: commands}
+	am.aiManager = ai.NewManager()
+	am.aiManager.AddProvider("stub", provider)
+	am.AIConfigured = true
+	return provider
+}
+
+func TestConvertAllAliasesResume(t *testing.T) {
+	am := newTestManager(t)
+	commands := []string{"docker ps", "git status", "ls -la", "make test"}
+	for i, name := range []string{"dps", "gs", "ll", "mt"} {
+		am.AddAlias(name, commands[i])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first := useConvertStub(am, commands...)
+	first.cancelAfter, first.cancel = 2, cancel
+	_, err := am.ConvertAllAliases(ctx, []ShellType{ShellZsh}, "", 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "conversion interrupted") {
+		t.Fatalf("ConvertAllAliases error = %v, want the run interrupted", err)
+	}
+	if len(first.converted) != 2 {
+		t.Fatalf("first run converted %v, want 2 commands before the interruption", first.converted)
+	}
+	if _, err := os.Stat(am.convertCheckpointFile()); err != nil {
+		t.Fatalf("checkpoint not kept after the interruption: %v", err)
+	}
+	if pending, _ := am.PendingCommands(); len(pending) != 0 {
+		t.Errorf("interrupted run queued %d commands, want none", len(pending))
+	}
+
+	second := useConvertStub(am, commands...)
+	results, err := am.ConvertAllAliases(context.Background(), []ShellType{ShellZsh}, "", 1, nil)
+	if err != nil {
+		t.Fatalf("ConvertAllAliases: %v", err)
+	}
+	if len(second.converted) != 2 {
+		t.Errorf("second run converted %v, want only the 2 remaining commands", second.converted)
+	}
+	resumed := make(map[string]bool)
+	for _, command := range first.converted {
+		resumed[command] = true
+	}
+	for _, result := range results {
+		if result.Err != nil || result.Resumed != resumed[result.Command] {
+			t.Errorf("result %+v, want resumed = %v", result, resumed[result.Command])
+		}
+	}
+	if pending, _ := am.PendingCommands(); len(pending) != len(commands) {
+		t.Errorf("queued %d commands, want %d", len(pending), len(commands))
+	}
+	if _, err := os.Stat(am.convertCheckpointFile()); !os.IsNotExist(err) {
+		t.Errorf("checkpoint kept after a finished run: %v", err)
+	}
+}
+
+func TestConvertAllAliasesCheckpointFailure(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	am := newTestManager(t)
+	am.AddAlias("gs", "git status")
+	am.AddAlias("ll", "ls -la")
+	provider := useConvertStub(am, "git status", "ls -la")
+	if err := os.MkdirAll(am.ConfigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Every write to the checkpoint fails as if the disk were full
+	if err := os.Symlink("/dev/full", am.convertCheckpointFile()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := am.ConvertAllAliases(context.Background(), []ShellType{ShellZsh}, "", 1, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to write conversion checkpoint") {
+		t.Fatalf("ConvertAllAliases error = %v, want a checkpoint write error", err)
+	}
+	if len(provider.converted) != 1 {
+		t.Errorf("converted %v, want the run stopped after the first conversion", provider.converted)
+	}
+}
@@ -1,12 +1,11 @@
 package aliasctl
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
 )
 
 // KeyFileNotFoundError is used when a key file is not found.
@@ -49,105 +48,40 @@ func (am *AliasManager) EncryptAPIKeys() error {
 		}
 	}
 
-	// Get providers from the AI manager
-	providers := am.GetAvailableProviders()
-	hasProvider := make(map[string]bool)
-	for _, name := range providers {
-		hasProvider[name] = true
-	}
-
-	// Update the configuration
-	config := Config{}
-	if err := loadConfigFromFile(am.ConfigFile, &config); err != nil {
-		return fmt.Errorf("failed to load configuration for encryption: %w", err)
-	}
-
-	// Encrypt API keys as needed
-	if hasProvider["openai"] {
-		// Get the OpenAI provider through the AI manager
-		provider, err := am.aiManager.GetProvider("openai")
-		if err == nil {
-			if openAIProvider, ok := provider.(*ai.OpenAIProvider); ok && openAIProvider.APIKey != "" {
-				encryptedKey, err := EncryptString(openAIProvider.APIKey, am.EncryptionKey)
-				if err != nil {
-					return fmt.Errorf("failed to encrypt OpenAI API key: %w", err)
-				}
-
-				config.OpenAIKeyEncrypted = encryptedKey
-				config.OpenAIKey = "" // Clear plaintext key
-				config.UseEncryption = true
-			}
-		}
-	}
-
-	if hasProvider["anthropic"] {
-		// Get the Anthropic provider through the AI manager
-		provider, err := am.aiManager.GetProvider("anthropic")
-		if err == nil {
-			if anthropicProvider, ok := provider.(*ai.AnthropicProvider); ok && anthropicProvider.APIKey != "" {
-				encryptedKey, err := EncryptString(anthropicProvider.APIKey, am.EncryptionKey)
-				if err != nil {
-					return fmt.Errorf("failed to encrypt Anthropic API key: %w", err)
-				}
-
-				config.AnthropicKeyEncrypted = encryptedKey
-				config.AnthropicKey = "" // Clear plaintext key
-				config.UseEncryption = true
+	// Check that every API key can be encrypted before switching
+	for _, name := range am.GetAvailableProviders() {
+		if apiKey := am.aiManager.Providers[name].Info().APIKey; apiKey != "" {
+			if _, err := EncryptString(apiKey, am.EncryptionKey); err != nil {
+				return fmt.Errorf("failed to encrypt %s API key: %w", name, err)
 			}
 		}
 	}
 
-	// Save the updated configuration
-	if err := saveConfigToFile(am.ConfigFile, config); err != nil {
+	// Save the configuration, which encrypts the API keys
+	am.EncryptionUsed = true
+	if err := am.SaveConfig(); err != nil {
 		return fmt.Errorf("failed to save configuration with encrypted keys: %w", err)
 	}
-
-	am.EncryptionUsed = true
 	return nil
 }
 
 // DisableEncryption disables encryption and reverts to plaintext API keys.
-// It decrypts any encrypted API keys in the configuration and stores them
-// in plaintext. The encryption flag is also turned off.
-// Returns a KeyFileNotFoundError if the encryption key file doesn't exist,
-// or a generic error if decryption fails for any other reason.
+// The API keys were decrypted when the configuration was loaded, so they are saved in
+// plaintext and the encryption flag is turned off.
+// Returns a KeyFileNotFoundError if encryption is used but the encryption key file doesn't
+// exist, as the encrypted keys could not have been decrypted.
 func (am *AliasManager) DisableEncryption() error {
-	// Load configuration
-	config := Config{}
-	if err := loadConfigFromFile(am.ConfigFile, &config); err != nil {
-		return fmt.Errorf("failed to load configuration to disable encryption: %w (check if config file exists and has valid format)", err)
-	}
-
-	// Check if we have encrypted keys that need decryption
-	if config.OpenAIKeyEncrypted != "" {
-		// Decrypt the OpenAI key
-		decryptedKey, err := DecryptString(config.OpenAIKeyEncrypted, am.EncryptionKey)
-		if err != nil {
-			if _, ok := err.(*KeyFileNotFoundError); ok {
-				return &KeyFileNotFoundError{KeyPath: am.EncryptionKey}
-			}
-			return fmt.Errorf("failed to decrypt OpenAI API key: %w (encryption key may be corrupted or inaccessible)", err)
-		}
-		config.OpenAIKey = decryptedKey
-		config.OpenAIKeyEncrypted = ""
-	}
-
-	if config.AnthropicKeyEncrypted != "" {
-		// Decrypt the Anthropic key
-		decryptedKey, err := DecryptString(config.AnthropicKeyEncrypted, am.EncryptionKey)
-		if err != nil {
-			return fmt.Errorf("failed to decrypt Anthropic API key: %w", err)
+	if am.EncryptionUsed {
+		if _, err := os.Stat(am.EncryptionKey); os.IsNotExist(err) {
+			return &KeyFileNotFoundError{KeyPath: am.EncryptionKey}
 		}
-		config.AnthropicKey = decryptedKey
-		config.AnthropicKeyEncrypted = ""
 	}
 
 	// Update the encryption flag
-	config.UseEncryption = false
 	am.EncryptionUsed = false
 
 	// Save the updated configuration
-	if err := saveConfigToFile(am.ConfigFile, config); err != nil {
+	if err := am.SaveConfig(); err != nil {
 		return fmt.Errorf("failed to save configuration with decrypted keys: %w", err)
 	}
 
@@ -194,30 +128,12 @@ func SaveConfig(path string, config Config) error {
 
 // GenerateRandomKey generates a random encryption key.
 // It creates a 256-bit (32 byte) key using a secure random number generator.
-// On Unix-like systems, it reads from /dev/urandom for entropy.
 // Returns the generated key and any error encountered during generation.
 func GenerateRandomKey() ([]byte, error) {
-	// Implementation depends on your encryption methodology
-	// This is a placeholder for a function that would generate a secure key
 	key := make([]byte, 32) // 256-bit key
-	if _, err := os.ReadFile("/dev/urandom"); err == nil {
-		// For Unix-like systems
-		file, err := os.Open("/dev/urandom")
-		if err != nil {
-			return nil, err
-		}
-		defer file.Close()
-
-		if _, err := file.Read(key); err != nil {
-			return nil, err
-		}
-	} else {
-		// For systems without /dev/urandom
-		// Use a cryptographically secure random number generator
-		// This is just a placeholder and should be replaced with proper crypto/rand usage
-		return nil, fmt.Errorf("secure random number generation not implemented for this platform")
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
 	}
-
 	return key, nil
 }
 
@@ -270,19 +186,3 @@ func DecryptString(ciphertext string, keyPath string) (string, error) {
 
 	return ciphertext[10:], nil
 }
-
-// loadConfigFromFile is a wrapper around LoadConfig to avoid name conflicts.
-// It loads configuration from the specified path into the config struct.
-func loadConfigFromFile(path string, config *Config) error {
-	// Implementation should be provided elsewhere
-	// This is just a wrapper to avoid name conflicts
-	return LoadConfig(path, config)
-}
-
-// saveConfigToFile is a wrapper around SaveConfig to avoid name conflicts.
-// It saves the configuration to the specified path.
-func saveConfigToFile(path string, config Config) error {
-	// Implementation should be provided elsewhere
-	// This is just a wrapper to avoid name conflicts
-	return SaveConfig(path, config)
-}
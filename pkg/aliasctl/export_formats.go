@@ -0,0 +1,241 @@
+package aliasctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	for _, shell := range SupportedShells {
+		RegisterExporter(shellScriptExporter{shell: shell})
+	}
+	RegisterExporter(storeExporter{name: "json", description: "JSON in the alias store schema"})
+	RegisterExporter(storeExporter{name: "yaml", description: "YAML in the alias store schema"})
+	RegisterExporter(storeExporter{name: "toml", description: "TOML, the native alias store and bundle format"})
+	RegisterExporter(doskeyMacroExporter{})
+	RegisterExporter(powerShellModuleExporter{})
+	RegisterExporter(fishConfDExporter{})
+	RegisterExporter(nixExporter{})
+}
+
+// nixIdentifierPattern matches attribute names that need no quoting in Nix.
+var nixIdentifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_'-]*$`)
+
+// shellScriptExporter writes aliases as a script for one shell, like 'aliasctl apply'.
+type shellScriptExporter struct {
+	shell ShellType
+}
+
+// Name returns the target name, which is the shell type.
+func (e shellScriptExporter) Name() string { return string(e.shell) }
+
+// Description returns a short description of the output.
+func (e shellScriptExporter) Description() string {
+	return fmt.Sprintf("%s alias definitions", e.shell)
+}
+
+// Shell returns the shell whose commands are exported.
+func (e shellScriptExporter) Shell() ShellType { return e.shell }
+
+// Export renders the aliases defined for the shell.
+func (e shellScriptExporter) Export(aliases map[string]AliasCommands) ([]byte, error) {
+	var content strings.Builder
+	content.WriteString("# Aliases exported by AliasCtl\n")
+	for _, name := range sortedNames(aliases) {
+		if command := aliases[name].ForShell(e.shell); command != "" {
+			content.WriteString(formatAliasDefinition(e.shell, name, command))
+		}
+	}
+	content.WriteString("# End of exported aliases\n")
+	return []byte(content.String()), nil
+}
+
+// storeExporter writes every alias with all its shell commands, in the schema of the
+// alias store. The output can be imported again or subscribed to as a bundle.
+type storeExporter struct {
+	name        string
+	description string
+}
+
+// Name returns the target name, which is the encoding.
+func (e storeExporter) Name() string { return e.name }
+
+// Description returns a short description of the output.
+func (e storeExporter) Description() string { return e.description }
+
+// Export encodes the aliases.
+func (e storeExporter) Export(aliases map[string]AliasCommands) ([]byte, error) {
+	switch e.name {
+	case "json":
+		data, err := json.MarshalIndent(aliases, "", "  ")
+		return append(data, '\n'), err
+	case "yaml":
+		return yaml.Marshal(aliases)
+	default:
+		var buf bytes.Buffer
+		err := toml.NewEncoder(&buf).Encode(aliases)
+		return buf.Bytes(), err
+	}
+}
+
+// doskeyMacroExporter writes a doskey macro file, loaded with 'doskey /macrofile=<file>'.
+type doskeyMacroExporter struct{}
+
+// Name returns the target name.
+func (doskeyMacroExporter) Name() string { return "doskey-mac" }
+
+// Description returns a short description of the output.
+func (doskeyMacroExporter) Description() string {
+	return "doskey macro file (.mac) for 'doskey /macrofile'"
+}
+
+// Export renders the cmd commands as macros. Descriptions are kept as ';=' comments,
+// which doskey ignores.
+func (doskeyMacroExporter) Export(aliases map[string]AliasCommands) ([]byte, error) {
+	var content strings.Builder
+	content.WriteString(";= Aliases exported by AliasCtl\n")
+	content.WriteString(";= Load with: doskey /macrofile=<this file>\n")
+	for _, name := range sortedNames(aliases) {
+		commands := aliases[name]
+		if commands.Cmd == "" {
+			continue
+		}
+		if commands.Description != "" {
+			fmt.Fprintf(&content, ";= %s\n", commands.Description)
+		}
+		fmt.Fprintf(&content, "%s=%s\n", name, commands.Cmd)
+	}
+	return []byte(content.String()), nil
+}
+
+// powerShellModuleExporter writes a PowerShell script module that exports the aliases.
+// Windows PowerShell commands are used, falling back to PowerShell Core commands.
+type powerShellModuleExporter struct{}
+
+// Name returns the target name.
+func (powerShellModuleExporter) Name() string { return "powershell-module" }
+
+// Description returns a short description of the output.
+func (powerShellModuleExporter) Description() string {
+	return "PowerShell module (.psm1) with Export-ModuleMember"
+}
+
+// Export renders the aliases as functions and aliases and exports them from the module.
+func (powerShellModuleExporter) Export(aliases map[string]AliasCommands) ([]byte, error) {
+	var content strings.Builder
+	var functions, aliasNames []string
+	content.WriteString("# Aliases exported by AliasCtl\n")
+	content.WriteString("# Load with: Import-Module <this file>\n\n")
+	for _, name := range sortedNames(aliases) {
+		commands := aliases[name]
+		command := commands.PowerShell
+		if command == "" {
+			command = commands.PowerShellCore
+		}
+		if command == "" {
+			continue
+		}
+		if commands.Description != "" {
+			fmt.Fprintf(&content, "# %s\n", commands.Description)
+		}
+		content.WriteString(formatAliasDefinition(ShellPowerShell, name, command))
+		if strings.Contains(command, " ") {
+			functions = append(functions, name)
+		} else {
+			aliasNames = append(aliasNames, name)
+		}
+	}
+
+	content.WriteString("\nExport-ModuleMember")
+	if len(functions) > 0 {
+		fmt.Fprintf(&content, " -Function %s", strings.Join(functions, ", "))
+	}
+	if len(aliasNames) > 0 {
+		fmt.Fprintf(&content, " -Alias %s", strings.Join(aliasNames, ", "))
+	}
+	content.WriteString("\n")
+	return []byte(content.String()), nil
+}
+
+// fishConfDExporter writes a drop-in for fish's conf.d directory, which fish sources at
+// startup.
+type fishConfDExporter struct{}
+
+// Name returns the target name.
+func (fishConfDExporter) Name() string { return "fish-conf.d" }
+
+// Description returns a short description of the output.
+func (fishConfDExporter) Description() string {
+	return "fish drop-in for ~/.config/fish/conf.d/aliasctl.fish"
+}
+
+// Export renders the fish commands, defined only in interactive sessions.
+func (fishConfDExporter) Export(aliases map[string]AliasCommands) ([]byte, error) {
+	var content strings.Builder
+	content.WriteString("# Aliases exported by AliasCtl\n")
+	content.WriteString("# Save as ~/.config/fish/conf.d/aliasctl.fish\n")
+	content.WriteString("status is-interactive; or return\n\n")
+	for _, name := range sortedNames(aliases) {
+		if command := aliases[name].Fish; command != "" {
+			content.WriteString(formatAliasDefinition(ShellFish, name, command))
+		}
+	}
+	return []byte(content.String()), nil
+}
+
+// nixExporter writes a home-manager module setting programs.<shell>.shellAliases for
+// bash, zsh and fish.
+type nixExporter struct{}
+
+// Name returns the target name.
+func (nixExporter) Name() string { return "nix" }
+
+// Description returns a short description of the output.
+func (nixExporter) Description() string {
+	return "Nix home-manager module with programs.<shell>.shellAliases"
+}
+
+// Export renders an attrset per shell that has aliases.
+func (nixExporter) Export(aliases map[string]AliasCommands) ([]byte, error) {
+	var content strings.Builder
+	content.WriteString("# Aliases exported by AliasCtl\n")
+	content.WriteString("# Add this file to the imports of your home-manager configuration\n")
+	content.WriteString("{\n")
+	for _, shell := range []ShellType{ShellBash, ShellZsh, ShellFish} {
+		var attrs strings.Builder
+		for _, name := range sortedNames(aliases) {
+			if command := aliases[name].ForShell(shell); command != "" {
+				fmt.Fprintf(&attrs, "    %s = %s;\n", nixAttrName(name), nixString(command))
+			}
+		}
+		if attrs.Len() > 0 {
+			fmt.Fprintf(&content, "  programs.%s.shellAliases = {\n%s  };\n", shell, attrs.String())
+		}
+	}
+	content.WriteString("}\n")
+	return []byte(content.String()), nil
+}
+
+// nixAttrName returns name as a Nix attribute name, quoted if needed.
+func nixAttrName(name string) string {
+	switch name {
+	case "assert", "else", "if", "in", "inherit", "let", "or", "rec", "then", "with":
+		return nixString(name)
+	}
+	if nixIdentifierPattern.MatchString(name) {
+		return name
+	}
+	return nixString(name)
+}
+
+// nixString returns s as a double-quoted Nix string.
+func nixString(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,83 @@
+package aliasctl
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// roundTripAliases are exported and imported again by TestExportImportRoundTrip. The
+// commands hold double quotes, variables, pipes and doskey parameters, which every
+// format must carry over unchanged.
+var roundTripAliases = map[string]AliasCommands{
+	"gs": {
+		Bash: "git status -sb", Zsh: "git status -sb", Fish: "git status -sb", Ksh: "git status -sb",
+		PowerShell: "git status -sb", PowerShellCore: "git status -sb", Cmd: "git status -sb",
+		Description: "Short status", AIGenerated: "fish",
+	},
+	"gl": {
+		Bash: `git log --format="%h %s" | head -n 20`, Zsh: `git log --format="%h %s" | head -n 20`,
+		Cmd: `git log --format="%h %s" $*`, Description: `Log as "hash subject"`,
+	},
+	"home":    {Bash: `cd "$HOME" && ls -la`, Zsh: `cd "$HOME" && ls -la`, Ksh: `cd "$HOME" && ls -la`},
+	"dps":     {Cmd: "docker ps -a $*", PowerShell: "docker ps -a"},
+	"k":       {Bash: "kubectl", Zsh: "kubectl", Fish: "kubectl", Cmd: "kubectl $*"},
+	"k8s:ctx": {Zsh: "kubectl config current-context"},
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	zshOnly := func(command func(AliasCommands) string) func(AliasCommands) AliasCommands {
+		return func(commands AliasCommands) AliasCommands { return AliasCommands{Zsh: command(commands)} }
+	}
+	tests := []struct {
+		target   string                            // The exporter
+		file     string                            // The file exported to, whose name the importer is detected from
+		importer string                            // The importer that must be detected
+		want     func(AliasCommands) AliasCommands // What is imported back from an alias
+	}{
+		{target: "bash", file: "aliases.sh", importer: "oh-my-zsh", want: zshOnly(func(c AliasCommands) string { return c.Bash })},
+		{target: "zsh", file: "aliases.zsh", importer: "oh-my-zsh", want: zshOnly(func(c AliasCommands) string { return c.Zsh })},
+		{target: "ksh", file: "aliases.ksh", importer: "oh-my-zsh", want: zshOnly(func(c AliasCommands) string { return c.Ksh })},
+		{target: "cmd", file: "aliases.cmd", importer: "doskey", want: func(c AliasCommands) AliasCommands { return AliasCommands{Cmd: c.Cmd} }},
+		{target: "doskey-mac", file: "aliases.mac", importer: "doskey", want: func(c AliasCommands) AliasCommands { return AliasCommands{Cmd: c.Cmd} }},
+		{target: "json", file: "aliases.json", importer: "yaml", want: func(c AliasCommands) AliasCommands {
+			c.AIGenerated = "" // Imported commands count as written by hand
+			return c
+		}},
+		{target: "yaml", file: "aliases.yaml", importer: "yaml", want: func(c AliasCommands) AliasCommands {
+			c.AIGenerated = ""
+			return c
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			exporting := newTestManager(t)
+			for name, commands := range roundTripAliases {
+				exporting.Aliases[name] = commands
+			}
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := exporting.ExportAliases(tt.target, path); err != nil {
+				t.Fatalf("ExportAliases: %v", err)
+			}
+
+			importing := newTestManager(t)
+			format, err := importing.ImportAliasesFrom("", path)
+			if err != nil {
+				t.Fatalf("ImportAliasesFrom: %v", err)
+			}
+			if format != tt.importer {
+				t.Errorf("detected format = %s, want %s", format, tt.importer)
+			}
+
+			want := make(map[string]AliasCommands)
+			for name, commands := range roundTripAliases {
+				if imported := tt.want(commands); imported != (AliasCommands{Description: imported.Description}) {
+					want[name] = imported
+				}
+			}
+			if !reflect.DeepEqual(importing.Aliases, want) {
+				t.Errorf("imported aliases =\n%+v\nwant\n%+v", importing.Aliases, want)
+			}
+		})
+	}
+}
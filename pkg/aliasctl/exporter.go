@@ -0,0 +1,57 @@
+package aliasctl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Exporter writes aliases in a shell's syntax or in a format read by another tool.
+// Exporters are registered with RegisterExporter and selected by name, see ExportAliases.
+type Exporter interface {
+	Name() string                                            // The target name, as given to 'aliasctl export'
+	Description() string                                     // A short description of the output
+	Export(aliases map[string]AliasCommands) ([]byte, error) // Renders the aliases
+}
+
+// ShellExporter is implemented by exporters that write the commands of a single shell.
+type ShellExporter interface {
+	Exporter
+	Shell() ShellType // The shell whose commands are exported
+}
+
+// exporters holds the registered exporters in registration order.
+var exporters []Exporter
+
+// RegisterExporter adds an exporter to the registry.
+// Registering a second exporter with the same name replaces the first.
+func RegisterExporter(exporter Exporter) {
+	for i, existing := range exporters {
+		if existing.Name() == exporter.Name() {
+			exporters[i] = exporter
+			return
+		}
+	}
+	exporters = append(exporters, exporter)
+}
+
+// Exporters returns the registered exporters sorted by name.
+func Exporters() []Exporter {
+	sorted := append([]Exporter(nil), exporters...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+	return sorted
+}
+
+// GetExporter returns the exporter registered under name.
+func GetExporter(name string) (Exporter, error) {
+	for _, exporter := range exporters {
+		if exporter.Name() == name {
+			return exporter, nil
+		}
+	}
+	var names []string
+	for _, exporter := range Exporters() {
+		names = append(names, exporter.Name())
+	}
+	return nil, fmt.Errorf("unsupported export target: %s (supported targets: %s)", name, strings.Join(names, ", "))
+}
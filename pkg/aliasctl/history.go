@@ -0,0 +1,197 @@
+package aliasctl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zshMeta is the byte zsh uses to escape special characters in its history file.
+// The byte following it has bit 5 flipped.
+const zshMeta = 0x83
+
+// HistoryEntry is a single command read from a shell history file.
+type HistoryEntry struct {
+	Command string    // The command line as typed
+	Time    time.Time // When the command was run, zero if the history has no timestamps
+}
+
+// DefaultHistoryFile returns the history file the given shell writes to by default.
+// For bash and zsh the HISTFILE environment variable takes precedence.
+// Returns an error if the shell's history format is not supported.
+func DefaultHistoryFile(shell ShellType) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+
+	switch shell {
+	case ShellBash:
+		if histFile := os.Getenv("HISTFILE"); histFile != "" {
+			return histFile, nil
+		}
+		return filepath.Join(homeDir, ".bash_history"), nil
+	case ShellZsh:
+		if histFile := os.Getenv("HISTFILE"); histFile != "" {
+			return histFile, nil
+		}
+		for _, name := range []string{".zsh_history", ".zhistory", ".histfile"} {
+			path := filepath.Join(homeDir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+		return filepath.Join(homeDir, ".zsh_history"), nil
+	case ShellFish:
+		dataDir := os.Getenv("XDG_DATA_HOME")
+		if dataDir == "" {
+			dataDir = filepath.Join(homeDir, ".local", "share")
+		}
+		return filepath.Join(dataDir, "fish", "fish_history"), nil
+	default:
+		return "", fmt.Errorf("reading history is not supported for shell: %s (supported shells: bash, zsh, fish)", shell)
+	}
+}
+
+// ReadShellHistory reads and parses the history file at path using the format of the given shell.
+// Bash timestamps (HISTTIMEFORMAT), zsh extended history and fish's YAML-like history are
+// understood; entries without timestamps have a zero Time.
+// Returns an error if the file cannot be read or the shell's format is not supported.
+func ReadShellHistory(shell ShellType, path string) ([]HistoryEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("history file not found at %s (use --history-file to specify its location)", path)
+		}
+		return nil, fmt.Errorf("failed to open history file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	switch shell {
+	case ShellBash:
+		return parseBashHistory(file)
+	case ShellZsh:
+		return parseZshHistory(file)
+	case ShellFish:
+		return parseFishHistory(file)
+	default:
+		return nil, fmt.Errorf("reading history is not supported for shell: %s (supported shells: bash, zsh, fish)", shell)
+	}
+}
+
+// newHistoryScanner returns a line scanner that tolerates very long history lines.
+func newHistoryScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return scanner
+}
+
+// parseBashHistory parses bash history, where "#<epoch>" lines written with
+// HISTTIMEFORMAT set carry the timestamp of the following command.
+func parseBashHistory(r io.Reader) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	var timestamp time.Time
+
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if epoch, err := strconv.ParseInt(line[1:], 10, 64); err == nil {
+				timestamp = time.Unix(epoch, 0)
+				continue
+			}
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entries = append(entries, HistoryEntry{Command: line, Time: timestamp})
+		timestamp = time.Time{}
+	}
+	return entries, scanner.Err()
+}
+
+// parseZshHistory parses zsh history in both the plain and the extended
+// ": <epoch>:<duration>;<command>" format. Multi-line commands are joined, and
+// metafied bytes are decoded.
+func parseZshHistory(r io.Reader) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	var pending *HistoryEntry
+
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := unmetafyZsh(scanner.Text())
+
+		if pending != nil {
+			pending.Command += "\n" + line
+		} else {
+			entry := HistoryEntry{Command: line}
+			if strings.HasPrefix(line, ": ") {
+				if header, command, ok := strings.Cut(line[2:], ";"); ok {
+					epoch, _, _ := strings.Cut(header, ":")
+					if seconds, err := strconv.ParseInt(strings.TrimSpace(epoch), 10, 64); err == nil {
+						entry = HistoryEntry{Command: command, Time: time.Unix(seconds, 0)}
+					}
+				}
+			}
+			pending = &entry
+		}
+
+		// A trailing backslash continues the command on the next line
+		if strings.HasSuffix(pending.Command, "\\") {
+			pending.Command = strings.TrimSuffix(pending.Command, "\\")
+			continue
+		}
+		if strings.TrimSpace(pending.Command) != "" {
+			entries = append(entries, *pending)
+		}
+		pending = nil
+	}
+	if pending != nil && strings.TrimSpace(pending.Command) != "" {
+		entries = append(entries, *pending)
+	}
+	return entries, scanner.Err()
+}
+
+// unmetafyZsh reverses the escaping zsh applies to bytes above 0x83 in history files.
+func unmetafyZsh(line string) string {
+	if strings.IndexByte(line, zshMeta) < 0 {
+		return line
+	}
+	decoded := make([]byte, 0, len(line))
+	for i := 0; i < len(line); i++ {
+		if line[i] == zshMeta && i+1 < len(line) {
+			i++
+			decoded = append(decoded, line[i]^32)
+			continue
+		}
+		decoded = append(decoded, line[i])
+	}
+	return string(decoded)
+}
+
+// parseFishHistory parses fish's YAML-like history, made of "- cmd:" entries
+// followed by an indented "when:" timestamp.
+func parseFishHistory(r io.Reader) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+
+	scanner := newHistoryScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "- cmd: "):
+			command := strings.TrimPrefix(line, "- cmd: ")
+			command = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(command)
+			entries = append(entries, HistoryEntry{Command: command})
+		case strings.HasPrefix(line, "  when: ") && len(entries) > 0:
+			if seconds, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "  when: ")), 10, 64); err == nil {
+				entries[len(entries)-1].Time = time.Unix(seconds, 0)
+			}
+		}
+	}
+	return entries, scanner.Err()
+}
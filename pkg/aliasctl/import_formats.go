@@ -0,0 +1,359 @@
+package aliasctl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RegisterImporter(powerShellCSVImporter{})
+	RegisterImporter(yamlImporter{})
+	RegisterImporter(fishAbbrImporter{})
+	RegisterImporter(ohMyZshImporter{})
+	RegisterImporter(doskeyImporter{})
+}
+
+// doskeyMacroPattern matches a doskey macro definition such as "ll=dir /w $*".
+var doskeyMacroPattern = regexp.MustCompile(`^[^\s=]+=`)
+
+// fileLines splits file content into trimmed lines, dropping a UTF-8 byte order mark.
+func fileLines(data []byte) []string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
+
+// hasLinePrefix reports whether any line of data starts with prefix.
+func hasLinePrefix(data []byte, prefix string) bool {
+	for _, line := range fileLines(data) {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ohMyZshImporter reads alias definitions from oh-my-zsh plugin files and other zsh
+// scripts. Global (-g) and suffix (-s) aliases are skipped since they are not commands.
+type ohMyZshImporter struct{}
+
+// Name returns the format name.
+func (ohMyZshImporter) Name() string { return "oh-my-zsh" }
+
+// Description returns a short description of the format.
+func (ohMyZshImporter) Description() string {
+	return "alias lines in oh-my-zsh plugins (*.plugin.zsh) and zsh scripts"
+}
+
+// Detect reports whether the file is a zsh script with alias definitions.
+func (ohMyZshImporter) Detect(path string, data []byte) bool {
+	return strings.HasSuffix(path, ".zsh") || hasLinePrefix(data, "alias ")
+}
+
+// Parse extracts the aliases as zsh commands.
+func (ohMyZshImporter) Parse(data []byte) (map[string]AliasCommands, error) {
+	aliases := make(map[string]AliasCommands)
+	for _, line := range fileLines(data) {
+		if !strings.HasPrefix(line, "alias ") {
+			continue
+		}
+		words, err := splitShellWords(line, false)
+		if err != nil {
+			continue
+		}
+
+		words = words[1:]
+		for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+			option := words[0]
+			words = words[1:]
+			if option == "--" {
+				break
+			}
+			if strings.ContainsAny(option, "gs") {
+				words = nil
+			}
+		}
+		for _, word := range words {
+			name, command, ok := strings.Cut(word, "=")
+			if ok && name != "" && command != "" {
+				aliases[name] = AliasCommands{Zsh: command}
+			}
+		}
+	}
+	return aliases, nil
+}
+
+// fishAbbrImporter reads the output of fish's 'abbr --show' and files of abbr commands.
+// Abbreviations expanded by a function, matched by a regex, limited to a command or
+// expanded anywhere on the line are skipped since they have no alias equivalent.
+type fishAbbrImporter struct{}
+
+// Name returns the format name.
+func (fishAbbrImporter) Name() string { return "fish-abbr" }
+
+// Description returns a short description of the format.
+func (fishAbbrImporter) Description() string { return "fish abbreviations ('abbr --show' output)" }
+
+// Detect reports whether the file contains abbr commands.
+func (fishAbbrImporter) Detect(path string, data []byte) bool {
+	return hasLinePrefix(data, "abbr ")
+}
+
+// Parse extracts the abbreviations as fish commands.
+func (fishAbbrImporter) Parse(data []byte) (map[string]AliasCommands, error) {
+	aliases := make(map[string]AliasCommands)
+	for _, line := range fileLines(data) {
+		if !strings.HasPrefix(line, "abbr ") {
+			continue
+		}
+		words, err := splitShellWords(line, true)
+		if err != nil {
+			continue
+		}
+
+		var positional []string
+		skip := false
+		for i := 1; i < len(words); i++ {
+			word := words[i]
+			if len(positional) > 0 || !strings.HasPrefix(word, "-") {
+				positional = append(positional, word)
+				continue
+			}
+			option, value, hasValue := strings.Cut(word, "=")
+			switch option {
+			case "--":
+				positional = append(positional, words[i+1:]...)
+				i = len(words)
+			case "-f", "--function", "-r", "--regex", "-c", "--command":
+				skip = true
+				if !hasValue {
+					i++
+				}
+			case "-p", "--position":
+				if !hasValue && i+1 < len(words) {
+					i++
+					value = words[i]
+				}
+				skip = skip || value == "anywhere"
+			case "-e", "--erase", "--rename", "-q", "--query", "-l", "--list", "-s", "--show":
+				skip = true
+			}
+		}
+		if skip || len(positional) < 2 {
+			continue
+		}
+		aliases[positional[0]] = AliasCommands{Fish: strings.Join(positional[1:], " ")}
+	}
+	return aliases, nil
+}
+
+// powerShellCSVImporter reads the CSV files written by 'Get-Alias | Export-Alias'.
+// PowerShell's built-in aliases, marked ReadOnly or AllScope, are skipped.
+type powerShellCSVImporter struct{}
+
+// Name returns the format name.
+func (powerShellCSVImporter) Name() string { return "powershell-csv" }
+
+// Description returns a short description of the format.
+func (powerShellCSVImporter) Description() string { return "PowerShell Export-Alias CSV files" }
+
+// Detect reports whether the file is an Export-Alias CSV file.
+func (powerShellCSVImporter) Detect(path string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv") || hasLinePrefix(data, "# Alias File")
+}
+
+// Parse extracts the aliases as PowerShell and PowerShell Core commands.
+// Each record holds the name, definition, description and options of an alias.
+func (powerShellCSVImporter) Parse(data []byte) (map[string]AliasCommands, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := make(map[string]AliasCommands)
+	for _, record := range records {
+		if len(record) < 2 || record[0] == "" || record[1] == "" || strings.EqualFold(record[0], "Name") {
+			continue
+		}
+		if len(record) >= 4 && (strings.Contains(record[3], "ReadOnly") || strings.Contains(record[3], "AllScope")) {
+			continue
+		}
+		commands := AliasCommands{PowerShell: record[1], PowerShellCore: record[1]}
+		if len(record) >= 3 {
+			commands.Description = record[2]
+		}
+		aliases[record[0]] = commands
+	}
+	return aliases, nil
+}
+
+// doskeyImporter reads 'doskey /macros' output, doskey macro files (.mac) and batch
+// files of doskey commands.
+type doskeyImporter struct{}
+
+// Name returns the format name.
+func (doskeyImporter) Name() string { return "doskey" }
+
+// Description returns a short description of the format.
+func (doskeyImporter) Description() string {
+	return "doskey macros ('doskey /macros' output and .mac files)"
+}
+
+// Detect reports whether the file contains doskey macros.
+func (doskeyImporter) Detect(path string, data []byte) bool {
+	if strings.EqualFold(filepath.Ext(path), ".mac") || hasLinePrefix(data, "doskey ") {
+		return true
+	}
+	found := false
+	for _, line := range fileLines(data) {
+		switch {
+		case line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "["):
+		case doskeyMacroPattern.MatchString(line):
+			found = true
+		default:
+			return false
+		}
+	}
+	return found
+}
+
+// Parse extracts the macros as cmd commands.
+func (doskeyImporter) Parse(data []byte) (map[string]AliasCommands, error) {
+	aliases := make(map[string]AliasCommands)
+	for _, line := range fileLines(data) {
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(lower, "doskey ") {
+			line = strings.TrimSpace(line[len("doskey "):])
+		} else if strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") || strings.HasPrefix(line, "::") ||
+			strings.HasPrefix(lower, "rem ") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		if !doskeyMacroPattern.MatchString(line) || strings.HasPrefix(line, "/") {
+			continue
+		}
+		name, command, _ := strings.Cut(line, "=")
+		if command = strings.TrimSpace(command); command != "" {
+			aliases[name] = AliasCommands{Cmd: command}
+		}
+	}
+	return aliases, nil
+}
+
+// yamlImporter reads the YAML or JSON alias files used by other alias tools.
+// Aliases are a map from name to command, or to an object with a command, a
+// description and optional per-shell commands; a list of objects with a name is also
+// accepted, as is either form under a top-level "aliases" key. A plain command is
+// used for bash, zsh, fish and ksh.
+type yamlImporter struct{}
+
+// Name returns the format name.
+func (yamlImporter) Name() string { return "yaml" }
+
+// Description returns a short description of the format.
+func (yamlImporter) Description() string { return "YAML or JSON alias files such as aliases.yml" }
+
+// Detect reports whether the file has a YAML or JSON extension.
+func (yamlImporter) Detect(path string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml", ".json":
+		return true
+	}
+	return false
+}
+
+// Parse extracts the aliases.
+func (yamlImporter) Parse(data []byte) (map[string]AliasCommands, error) {
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if root, ok := document.(map[string]any); ok {
+		if nested, ok := root["aliases"]; ok {
+			document = nested
+		}
+	}
+
+	aliases := make(map[string]AliasCommands)
+	switch entries := document.(type) {
+	case map[string]any:
+		for name, value := range entries {
+			commands, err := yamlAliasCommands(value)
+			if err != nil {
+				return nil, fmt.Errorf("alias '%s': %w", name, err)
+			}
+			aliases[name] = commands
+		}
+	case []any:
+		for i, value := range entries {
+			entry, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("entry %d: expected an object with a name", i+1)
+			}
+			name := yamlString(entry, "name", "alias")
+			if name == "" {
+				return nil, fmt.Errorf("entry %d: missing name", i+1)
+			}
+			commands, err := yamlAliasCommands(entry)
+			if err != nil {
+				return nil, fmt.Errorf("alias '%s': %w", name, err)
+			}
+			aliases[name] = commands
+		}
+	case nil:
+	default:
+		return nil, fmt.Errorf("expected a map or a list of aliases")
+	}
+	return aliases, nil
+}
+
+// yamlAliasCommands converts a single alias value of a YAML or JSON alias file.
+func yamlAliasCommands(value any) (AliasCommands, error) {
+	switch value := value.(type) {
+	case string:
+		return AliasCommands{Bash: value, Zsh: value, Fish: value, Ksh: value}, nil
+	case map[string]any:
+		var commands AliasCommands
+		if command := yamlString(value, "command", "value", "expansion"); command != "" {
+			commands = AliasCommands{Bash: command, Zsh: command, Fish: command, Ksh: command}
+		}
+		commands = mergeAliasCommands(commands, AliasCommands{
+			Bash:           yamlString(value, "bash"),
+			Zsh:            yamlString(value, "zsh"),
+			Fish:           yamlString(value, "fish"),
+			Ksh:            yamlString(value, "ksh"),
+			PowerShell:     yamlString(value, "powershell"),
+			PowerShellCore: yamlString(value, "pwsh"),
+			Cmd:            yamlString(value, "cmd"),
+			Description:    yamlString(value, "description", "desc", "help"),
+		})
+		if commands == (AliasCommands{Description: commands.Description}) {
+			return commands, fmt.Errorf("no command")
+		}
+		return commands, nil
+	default:
+		return AliasCommands{}, fmt.Errorf("expected a command or an object")
+	}
+}
+
+// yamlString returns the first of keys that holds a string in entry.
+func yamlString(entry map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := entry[key].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,141 @@
+package aliasctl
+
+import (
+	"reflect"
+	"testing"
+)
+
+// importTest is a file to parse with an importer and the aliases it must yield.
+type importTest struct {
+	name    string
+	input   string
+	want    map[string]AliasCommands
+	wantErr bool
+}
+
+// runImportTests parses each test's input with the named importer.
+func runImportTests(t *testing.T, format string, tests []importTest) {
+	t.Helper()
+	importer, err := GetImporter(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importer.Parse([]byte(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOhMyZshImporter(t *testing.T) {
+	runImportTests(t, "oh-my-zsh", []importTest{
+		{name: "single quotes", input: "alias gst='git status'\n", want: map[string]AliasCommands{"gst": {Zsh: "git status"}}},
+		{name: "double quotes", input: `alias gl="git log --format=\"%h %s\""`, want: map[string]AliasCommands{"gl": {Zsh: `git log --format="%h %s"`}}},
+		{name: "escaped single quote", input: `alias hi='echo it'\''s me'`, want: map[string]AliasCommands{"hi": {Zsh: "echo it's me"}}},
+		{name: "unquoted", input: "alias ..=cd\\ ..", want: map[string]AliasCommands{"..": {Zsh: "cd .."}}},
+		{name: "several on one line", input: "alias ga='git add' gc='git commit'", want: map[string]AliasCommands{"ga": {Zsh: "git add"}, "gc": {Zsh: "git commit"}}},
+		{name: "trailing comment", input: "alias gp='git push' # push it", want: map[string]AliasCommands{"gp": {Zsh: "git push"}}},
+		{name: "comments and functions", input: "# alias old='ls'\nfunction gdv() { git diff -w \"$@\" }\n  alias gd='git diff'\n", want: map[string]AliasCommands{"gd": {Zsh: "git diff"}}},
+		{name: "global and suffix aliases", input: "alias -g G='| grep'\nalias -s md=vim\nalias -- -='cd -'\n", want: map[string]AliasCommands{"-": {Zsh: "cd -"}}},
+		{name: "malformed lines", input: "alias broken='git status\nalias noval\nalias empty=\nalias ok='ls'\n", want: map[string]AliasCommands{"ok": {Zsh: "ls"}}},
+		{name: "CRLF and byte order mark", input: "\xef\xbb\xbfalias ll='ls -l'\r\nalias la='ls -a'\r\n", want: map[string]AliasCommands{"ll": {Zsh: "ls -l"}, "la": {Zsh: "ls -a"}}},
+	})
+}
+
+func TestFishAbbrImporter(t *testing.T) {
+	runImportTests(t, "fish-abbr", []importTest{
+		{name: "abbr --show", input: "abbr -a -- gco 'git checkout'\nabbr -a -- gst 'git status'\n", want: map[string]AliasCommands{"gco": {Fish: "git checkout"}, "gst": {Fish: "git status"}}},
+		{name: "unquoted words", input: "abbr --add l ls -la", want: map[string]AliasCommands{"l": {Fish: "ls -la"}}},
+		{name: "escaped single quote", input: `abbr -a -- hi 'echo it\'s me'`, want: map[string]AliasCommands{"hi": {Fish: "echo it's me"}}},
+		{name: "double quotes", input: `abbr -a -- e "echo \"\$HOME\""`, want: map[string]AliasCommands{"e": {Fish: `echo "$HOME"`}}},
+		{name: "position option", input: "abbr -a --position command -- gs 'git status'", want: map[string]AliasCommands{"gs": {Fish: "git status"}}},
+		{name: "anywhere abbreviations", input: "abbr -a --position anywhere -- L '| less'\nabbr -a -p=anywhere G '| grep'\n"},
+		{name: "function and regex abbreviations", input: "abbr -a --function last_history -- !!\nabbr -a -r '^\\.\\.+$' --function multicd -- dotdot\nabbr -a -c git -- co checkout\n"},
+		{name: "queries", input: "abbr --erase gs\nabbr --list\nabbr -q gs\n"},
+		{name: "comments", input: "# abbr -a gs 'git status'\nabbr -a gd 'git diff' # diff\n", want: map[string]AliasCommands{"gd": {Fish: "git diff"}}},
+		{name: "malformed lines", input: "abbr -a gs 'git status\nabbr -a lonely\nabbr -a ok 'ls'\n", want: map[string]AliasCommands{"ok": {Fish: "ls"}}},
+	})
+}
+
+func TestPowerShellCSVImporter(t *testing.T) {
+	header := "# Alias File\n# Exported by : user\n# Date/Time : Friday, 17 October 2026 10:00:00\n# Machine : HOST\n"
+	runImportTests(t, "powershell-csv", []importTest{
+		{name: "Export-Alias", input: header + "\"gs\",\"git status\",\"Git status\",\"None\"\n\"ll\",\"Get-ChildItem\",\"\",\"None\"\n", want: map[string]AliasCommands{
+			"gs": {PowerShell: "git status", PowerShellCore: "git status", Description: "Git status"},
+			"ll": {PowerShell: "Get-ChildItem", PowerShellCore: "Get-ChildItem"},
+		}},
+		{name: "escaped quotes", input: `"hi","Write-Host ""hi there""","Says ""hi""","None"`, want: map[string]AliasCommands{
+			"hi": {PowerShell: `Write-Host "hi there"`, PowerShellCore: `Write-Host "hi there"`, Description: `Says "hi"`},
+		}},
+		{name: "commas in a quoted field", input: `"sel","Select-Object Name, Id","",""`, want: map[string]AliasCommands{
+			"sel": {PowerShell: "Select-Object Name, Id", PowerShellCore: "Select-Object Name, Id"},
+		}},
+		{name: "built-in aliases", input: "\"cd\",\"Set-Location\",\"\",\"ReadOnly, AllScope\"\n\"gci\",\"Get-ChildItem\",\"\",\"AllScope\"\n\"mine\",\"Get-Date\",\"\",\"None\"\n", want: map[string]AliasCommands{
+			"mine": {PowerShell: "Get-Date", PowerShellCore: "Get-Date"},
+		}},
+		{name: "header row and short records", input: "Name,Definition,Description,Options\n\"only-name\"\n\"\",\"Get-Date\"\n\"two\",\"Get-Process\"\n", want: map[string]AliasCommands{
+			"two": {PowerShell: "Get-Process", PowerShellCore: "Get-Process"},
+		}},
+		{name: "byte order mark", input: "\xef\xbb\xbf\"gd\",\"Get-Date\",\"\",\"None\"\n", want: map[string]AliasCommands{
+			"gd": {PowerShell: "Get-Date", PowerShellCore: "Get-Date"},
+		}},
+		{name: "bare quotes", input: `gl,git log --format="%h %s",,None`, want: map[string]AliasCommands{
+			"gl": {PowerShell: `git log --format="%h %s"`, PowerShellCore: `git log --format="%h %s"`},
+		}},
+	})
+}
+
+func TestDoskeyImporter(t *testing.T) {
+	runImportTests(t, "doskey", []importTest{
+		{name: "doskey /macros", input: "ll=dir /w $*\nga=git add $*\n", want: map[string]AliasCommands{"ll": {Cmd: "dir /w $*"}, "ga": {Cmd: "git add $*"}}},
+		{name: "batch file", input: "@echo off\nREM aliases\n:: more aliases\ndoskey gs=git status\nDOSKEY gl=git log --oneline $*\n", want: map[string]AliasCommands{"gs": {Cmd: "git status"}, "gl": {Cmd: "git log --oneline $*"}}},
+		{name: "quotes are kept", input: `e=echo "a=b" $T echo done`, want: map[string]AliasCommands{"e": {Cmd: `echo "a=b" $T echo done`}}},
+		{name: "macro file comments and sections", input: ";= comment\n;= doskey /macrofile=aliases.mac\n[cmd.exe]\nls=dir $*\n", want: map[string]AliasCommands{"ls": {Cmd: "dir $*"}}},
+		{name: "doskey options", input: "doskey /macrofile=aliases.mac\ndoskey /exename=cmd.exe\n"},
+		{name: "malformed lines", input: "no equals sign\n=dir\nempty=\nok=dir\n", want: map[string]AliasCommands{"ok": {Cmd: "dir"}}},
+	})
+}
+
+func TestYAMLImporter(t *testing.T) {
+	runImportTests(t, "yaml", []importTest{
+		{name: "map of commands", input: "gs: git status\nll: 'ls -l'\n", want: map[string]AliasCommands{
+			"gs": {Bash: "git status", Zsh: "git status", Fish: "git status", Ksh: "git status"},
+			"ll": {Bash: "ls -l", Zsh: "ls -l", Fish: "ls -l", Ksh: "ls -l"},
+		}},
+		{name: "escaped quotes", input: "hi: \"echo \\\"hi\\\"\"\nit: 'echo it''s'\n", want: map[string]AliasCommands{
+			"hi": {Bash: `echo "hi"`, Zsh: `echo "hi"`, Fish: `echo "hi"`, Ksh: `echo "hi"`},
+			"it": {Bash: "echo it's", Zsh: "echo it's", Fish: "echo it's", Ksh: "echo it's"},
+		}},
+		{name: "objects under aliases", input: "# team aliases\naliases:\n  gs:\n    command: git status # short\n    description: Status\n    powershell: git status -sb\n", want: map[string]AliasCommands{
+			"gs": {Bash: "git status", Zsh: "git status", Fish: "git status", Ksh: "git status", PowerShell: "git status -sb", Description: "Status"},
+		}},
+		{name: "list of objects", input: "- name: k\n  expansion: kubectl\n  help: Kubernetes\n- alias: d\n  cmd: docker\n", want: map[string]AliasCommands{
+			"k": {Bash: "kubectl", Zsh: "kubectl", Fish: "kubectl", Ksh: "kubectl", Description: "Kubernetes"},
+			"d": {Cmd: "docker"},
+		}},
+		{name: "JSON", input: `{"aliases": {"gs": {"bash": "git status", "fish": "git status"}}}`, want: map[string]AliasCommands{
+			"gs": {Bash: "git status", Fish: "git status"},
+		}},
+		{name: "empty file", input: "# nothing yet\n"},
+		{name: "alias without a command", input: "gs:\n  description: Status\n", wantErr: true},
+		{name: "list entry without a name", input: "- command: ls\n", wantErr: true},
+		{name: "list entry that is not an object", input: "- ls\n", wantErr: true},
+		{name: "scalar document", input: "just a string\n", wantErr: true},
+		{name: "invalid YAML", input: "gs: [git status\n", wantErr: true},
+	})
+}
@@ -0,0 +1,201 @@
+package aliasctl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Importer reads aliases from a file written by a shell or another alias tool.
+// Importers are registered with RegisterImporter and selected by name or detected
+// from the file, see ImportAliasesFrom.
+type Importer interface {
+	Name() string                                        // The format name, as given to 'aliasctl import --from'
+	Description() string                                 // A short description of the format
+	Detect(path string, data []byte) bool                // Whether the file looks like this format
+	Parse(data []byte) (map[string]AliasCommands, error) // Extracts the aliases, filling the shells the format is for
+}
+
+// importers holds the registered importers in registration order, which is also
+// the order formats are tried in when detecting a file's format.
+var importers []Importer
+
+// RegisterImporter adds an importer to the registry.
+// Registering a second importer with the same name replaces the first.
+func RegisterImporter(importer Importer) {
+	for i, existing := range importers {
+		if existing.Name() == importer.Name() {
+			importers[i] = importer
+			return
+		}
+	}
+	importers = append(importers, importer)
+}
+
+// Importers returns the registered importers sorted by name.
+func Importers() []Importer {
+	sorted := append([]Importer(nil), importers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name() < sorted[j].Name() })
+	return sorted
+}
+
+// GetImporter returns the importer registered under name.
+func GetImporter(name string) (Importer, error) {
+	for _, importer := range importers {
+		if importer.Name() == name {
+			return importer, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported import format: %s (supported formats: %s)", name, strings.Join(importerNames(), ", "))
+}
+
+// DetectImporter returns the first registered importer that recognizes the file.
+func DetectImporter(path string, data []byte) (Importer, error) {
+	for _, importer := range importers {
+		if importer.Detect(path, data) {
+			return importer, nil
+		}
+	}
+	return nil, fmt.Errorf("could not detect the format of %s (use --from with one of: %s)", path, strings.Join(importerNames(), ", "))
+}
+
+// importerNames returns the names of the registered importers, sorted.
+func importerNames() []string {
+	var names []string
+	for _, importer := range Importers() {
+		names = append(names, importer.Name())
+	}
+	return names
+}
+
+// ImportAliasesFrom imports aliases from a file in the named format, or in the detected
+// format if format is empty. Imported commands replace the existing command for the same
+// alias and shell; commands for other shells are kept. Nothing is imported if an alias
+// name is invalid, see ValidateAliasName.
+// Returns the name of the format that was used.
+func (am *AliasManager) ImportAliasesFrom(format, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found at %s", path)
+		}
+		return "", fmt.Errorf("failed to read %s: %w (check file permissions)", path, err)
+	}
+
+	var importer Importer
+	if format == "" {
+		importer, err = DetectImporter(path, data)
+	} else {
+		importer, err = GetImporter(format)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	imported, err := importer.Parse(data)
+	if err != nil {
+		return importer.Name(), fmt.Errorf("failed to parse %s as %s: %w", path, importer.Name(), err)
+	}
+	if len(imported) == 0 {
+		return importer.Name(), fmt.Errorf("no aliases found in %s (format: %s)", path, importer.Name())
+	}
+
+	for name := range imported {
+		if err := ValidateAliasName(name); err != nil {
+			return importer.Name(), fmt.Errorf("failed to import %s: %w", path, err)
+		}
+	}
+	for name, commands := range imported {
+		am.Aliases[name] = mergeAliasCommands(am.Aliases[name], commands)
+	}
+
+	am.SetOperation(OpImport, fmt.Sprintf("import %s from %s", importer.Name(), filepath.Base(path)))
+	return importer.Name(), am.SaveAliases()
+}
+
+// mergeAliasCommands overlays the non-empty fields of imported onto existing.
+// Imported commands were written by hand, so the shells they replace are no longer
+// marked as AI-generated.
+func mergeAliasCommands(existing, imported AliasCommands) AliasCommands {
+	for _, shell := range SupportedShells {
+		if imported.ForShell(shell) != "" {
+			existing.markAIGenerated(shell, false)
+		}
+	}
+	fields := []struct{ to, from *string }{
+		{&existing.Bash, &imported.Bash},
+		{&existing.Zsh, &imported.Zsh},
+		{&existing.Fish, &imported.Fish},
+		{&existing.Ksh, &imported.Ksh},
+		{&existing.PowerShell, &imported.PowerShell},
+		{&existing.PowerShellCore, &imported.PowerShellCore},
+		{&existing.Cmd, &imported.Cmd},
+		{&existing.Description, &imported.Description},
+	}
+	for _, field := range fields {
+		if *field.from != "" {
+			*field.to = *field.from
+		}
+	}
+	return existing
+}
+
+// splitShellWords splits a line into words the way a POSIX shell or, if fish is set,
+// fish would, handling single quotes, double quotes and backslash escapes. Fish also
+// accepts \' and \\ inside single quotes. An unquoted '#' starting a word begins a
+// comment. Returns an error for an unterminated quote.
+func splitShellWords(line string, fish bool) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			return words, nil
+		case c == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == '\'':
+			i++
+			for ; i < len(line) && line[i] != '\''; i++ {
+				if fish && line[i] == '\\' && i+1 < len(line) && (line[i+1] == '\'' || line[i+1] == '\\') {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
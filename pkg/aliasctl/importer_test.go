@@ -0,0 +1,28 @@
+package aliasctl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportClearsAIGeneratedForImportedShells(t *testing.T) {
+	am := newTestManager(t)
+	am.Aliases["gs"] = AliasCommands{Bash: "git status", Zsh: "git status", Fish: "git status", AIGenerated: "bash,fish,zsh"}
+
+	path := filepath.Join(t.TempDir(), "abbr.fish")
+	if err := os.WriteFile(path, []byte("abbr -a gs 'git status -sb'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.ImportAliasesFrom("fish-abbr", path); err != nil {
+		t.Fatalf("ImportAliasesFrom: %v", err)
+	}
+
+	gs := am.Aliases["gs"]
+	if gs.Fish != "git status -sb" {
+		t.Errorf("fish command = %q, want the imported one", gs.Fish)
+	}
+	if gs.AIGenerated != "bash,zsh" {
+		t.Errorf("AIGenerated = %q, want bash,zsh", gs.AIGenerated)
+	}
+}
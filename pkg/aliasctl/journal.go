@@ -0,0 +1,432 @@
+package aliasctl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Operation types recorded in the journal.
+const (
+	OpAdd        = "add"         // An alias was added or redefined
+	OpRemove     = "remove"      // An alias was removed
+	OpImport     = "import"      // Aliases were imported from a shell configuration file
+	OpEdit       = "edit"        // Aliases were changed by any other means
+	OpAIGenerate = "ai-generate" // An alias generated by an AI provider was saved
+	OpPrune      = "prune"       // Unused aliases were pruned
+	OpRestore    = "restore"     // Pruned aliases were restored
+	OpUndo       = "undo"        // A previous operation was reverted
+	OpRedo       = "redo"        // A reverted operation was applied again
+	OpSync       = "sync"        // Aliases were merged from the sync remote
+	OpReview     = "review"      // AI-produced commands were approved in review
+)
+
+const (
+	// journalMaxEntries is the number of journal entries kept when the journal is compacted.
+	journalMaxEntries = 200
+	// journalMaxAge is the age after which journal entries are garbage collected.
+	journalMaxAge = 90 * 24 * time.Hour
+)
+
+// AliasChange records the state of a single alias before and after an operation.
+// A nil Before means the alias was created; a nil After means it was removed.
+type AliasChange struct {
+	Name   string         `json:"name" yaml:"name" toml:"name"`                                     // The alias name
+	Before *AliasCommands `json:"before,omitempty" yaml:"before,omitempty" toml:"before,omitempty"` // The alias before the operation
+	After  *AliasCommands `json:"after,omitempty" yaml:"after,omitempty" toml:"after,omitempty"`    // The alias after the operation
+}
+
+// JournalEntry is a single mutation of the alias store recorded in the journal.
+type JournalEntry struct {
+	ID          int           `json:"id" yaml:"id" toml:"id"`                                                          // Sequential operation number
+	Time        time.Time     `json:"time" yaml:"time" toml:"time"`                                                    // When the operation was saved
+	Operation   string        `json:"operation" yaml:"operation" toml:"operation"`                                     // The operation type, one of the Op constants
+	Description string        `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"` // Human-readable summary of the operation
+	Reverts     int           `json:"reverts,omitempty" yaml:"reverts,omitempty" toml:"reverts,omitempty"`             // For undo entries, the reverted operation
+	Reapplies   int           `json:"reapplies,omitempty" yaml:"reapplies,omitempty" toml:"reapplies,omitempty"`       // For redo entries, the reapplied operation
+	Undone      bool          `json:"undone,omitempty" yaml:"undone,omitempty" toml:"undone,omitempty"`                // Whether the operation is currently reverted
+	Changes     []AliasChange `json:"changes" yaml:"changes" toml:"changes"`                                           // The aliases changed by the operation
+}
+
+// Summary returns a compact description of the changes, e.g. "+gs ~ll -old".
+func (e JournalEntry) Summary() string {
+	return summarizeChanges(e.Changes)
+}
+
+// summarizeChanges describes alias changes as "+name" for added, "-name" for
+// removed and "~name" for modified aliases.
+func summarizeChanges(changes []AliasChange) string {
+	parts := make([]string, 0, len(changes))
+	for _, change := range changes {
+		switch {
+		case change.Before == nil:
+			parts = append(parts, "+"+change.Name)
+		case change.After == nil:
+			parts = append(parts, "-"+change.Name)
+		default:
+			parts = append(parts, "~"+change.Name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// AliasConflictError is returned when an operation cannot be reverted or reapplied
+// because the aliases it changed have been modified since.
+type AliasConflictError struct {
+	ID      int      // The operation that could not be reverted or reapplied
+	Aliases []string // The aliases modified since
+}
+
+// Error returns the error message for an AliasConflictError.
+func (e *AliasConflictError) Error() string {
+	return fmt.Sprintf("aliases changed since operation #%d: %s", e.ID, strings.Join(e.Aliases, ", "))
+}
+
+// JournalError is added to AliasManager.Warnings when aliases were saved but the change
+// could not be recorded in the journal, so it cannot be undone.
+type JournalError struct {
+	Err error // Why the change could not be recorded
+}
+
+// Error returns the error message for a JournalError.
+func (e *JournalError) Error() string {
+	return fmt.Sprintf("aliases saved but the change could not be recorded for undo: %v", e.Err)
+}
+
+// Unwrap returns the error that kept the change from being recorded.
+func (e *JournalError) Unwrap() error {
+	return e.Err
+}
+
+// SetOperation labels the next SaveAliases call in the journal.
+// Saves without a label are recorded as OpEdit.
+func (am *AliasManager) SetOperation(operation, description string) {
+	am.operation = &JournalEntry{Operation: operation, Description: description}
+}
+
+// journalFile returns the path of the operation journal.
+func (am *AliasManager) journalFile() string {
+	return filepath.Join(am.ConfigDir, "journal.jsonl")
+}
+
+// cloneAliases returns a copy of an alias map.
+func cloneAliases(aliases map[string]AliasCommands) map[string]AliasCommands {
+	clone := make(map[string]AliasCommands, len(aliases))
+	for name, commands := range aliases {
+		clone[name] = commands
+	}
+	return clone
+}
+
+// diffAliases returns the changes turning before into after, sorted by alias name.
+func diffAliases(before, after map[string]AliasCommands) []AliasChange {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	var changes []AliasChange
+	for name := range names {
+		oldCommands, hadOld := before[name]
+		newCommands, hasNew := after[name]
+		if hadOld == hasNew && oldCommands == newCommands {
+			continue
+		}
+		change := AliasChange{Name: name}
+		if hadOld {
+			change.Before = &oldCommands
+		}
+		if hasNew {
+			change.After = &newCommands
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// recordOperation appends the changes made since the last load or save to the journal.
+// Nothing is recorded if the aliases did not change. The pending operation label is
+// consumed either way.
+func (am *AliasManager) recordOperation() error {
+	entry := am.operation
+	am.operation = nil
+	if entry == nil {
+		entry = &JournalEntry{Operation: OpEdit}
+	}
+
+	entry.Changes = diffAliases(am.savedAliases, am.Aliases)
+	am.savedAliases = cloneAliases(am.Aliases)
+	if len(entry.Changes) == 0 {
+		return nil
+	}
+
+	entries, err := am.readJournal()
+	if err != nil {
+		return err
+	}
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	entry.Time = time.Now()
+
+	// Compact the journal once it grows too large or holds expired entries
+	if len(entries) >= journalMaxEntries || (len(entries) > 0 && time.Since(entries[0].Time) > journalMaxAge) {
+		return am.writeJournal(append(gcJournal(entries, time.Now()), *entry))
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(am.journalFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal %s: %w", am.journalFile(), err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// gcJournal drops entries older than journalMaxAge and keeps at most
+// journalMaxEntries-1 of the newest entries, leaving room for one more.
+// Undo and redo entries are always newer than the operation they refer to, so they are
+// dropped together with it: an undo is never kept for an operation that is gone, and
+// an operation that is currently reverted never loses the undo that reverted it.
+func gcJournal(entries []JournalEntry, now time.Time) []JournalEntry {
+	cutoff := now.Add(-journalMaxAge)
+	for len(entries) > 0 && entries[0].Time.Before(cutoff) {
+		entries = entries[1:]
+	}
+	if len(entries) >= journalMaxEntries {
+		entries = entries[len(entries)-journalMaxEntries+1:]
+	}
+	if len(entries) == 0 {
+		return entries
+	}
+
+	oldest := entries[0].ID
+	kept := make([]JournalEntry, 0, len(entries))
+	for _, entry := range entries {
+		if target := entry.Reverts + entry.Reapplies; target != 0 && target < oldest {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// readJournal reads every entry of the journal, oldest first.
+func (am *AliasManager) readJournal() ([]JournalEntry, error) {
+	file, err := os.Open(am.journalFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open journal %s: %w", am.journalFile(), err)
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse journal %s: %w", am.journalFile(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// writeJournal replaces the journal with the given entries.
+// The journal is written to a temporary file first so a failed write never loses it.
+func (am *AliasManager) writeJournal(entries []JournalEntry) error {
+	tmpFile := am.journalFile() + ".tmp"
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return fmt.Errorf("failed to create journal %s: %w", tmpFile, err)
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			file.Close()
+			os.Remove(tmpFile)
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return os.Rename(tmpFile, am.journalFile())
+}
+
+// Journal returns the recorded operations, oldest first, with Undone set for
+// operations that are currently reverted.
+func (am *AliasManager) Journal() ([]JournalEntry, error) {
+	entries, err := am.readJournal()
+	if err != nil {
+		return nil, err
+	}
+	undone, _ := journalState(entries)
+	for i := range entries {
+		entries[i].Undone = undone[entries[i].ID]
+	}
+	return entries, nil
+}
+
+// journalState replays the undo and redo entries of the journal.
+// It returns the set of reverted operations and the redo stack, most recent last.
+// Any operation other than undo and redo clears the redo stack.
+func journalState(entries []JournalEntry) (map[int]bool, []int) {
+	undone := make(map[int]bool)
+	var redoStack []int
+	for _, entry := range entries {
+		switch entry.Operation {
+		case OpUndo:
+			undone[entry.Reverts] = true
+			redoStack = append(redoStack, entry.Reverts)
+		case OpRedo:
+			delete(undone, entry.Reapplies)
+			for i, id := range redoStack {
+				if id == entry.Reapplies {
+					redoStack = append(redoStack[:i], redoStack[i+1:]...)
+					break
+				}
+			}
+		default:
+			redoStack = nil
+		}
+	}
+	return undone, redoStack
+}
+
+// findJournalEntry returns the entry with the given ID.
+func findJournalEntry(entries []JournalEntry, id int) (JournalEntry, bool) {
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return JournalEntry{}, false
+}
+
+// UndoOperation reverts an operation recorded in the journal and saves the aliases.
+// If id is 0 the most recent operation that is not reverted is chosen. Unless force
+// is set, an error of type *AliasConflictError is returned if any alias the operation
+// changed has been modified since. Returns the reverted operation.
+func (am *AliasManager) UndoOperation(id int, force bool) (JournalEntry, error) {
+	entries, err := am.readJournal()
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	undone, _ := journalState(entries)
+
+	if id == 0 {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Operation != OpUndo && entries[i].Operation != OpRedo && !undone[entries[i].ID] {
+				id = entries[i].ID
+				break
+			}
+		}
+		if id == 0 {
+			return JournalEntry{}, fmt.Errorf("nothing to undo")
+		}
+	}
+
+	target, ok := findJournalEntry(entries, id)
+	switch {
+	case !ok:
+		return JournalEntry{}, fmt.Errorf("operation #%d not found in the journal (run 'aliasctl history' to list operations)", id)
+	case target.Operation == OpUndo || target.Operation == OpRedo:
+		return JournalEntry{}, fmt.Errorf("operation #%d is an %s; use 'aliasctl redo' or 'aliasctl undo' on operation #%d instead", id, target.Operation, target.Reverts+target.Reapplies)
+	case undone[id]:
+		return JournalEntry{}, fmt.Errorf("operation #%d has already been undone", id)
+	}
+
+	if err := am.applyChanges(target.ID, target.Changes, true, force); err != nil {
+		return JournalEntry{}, err
+	}
+	am.operation = &JournalEntry{Operation: OpUndo, Description: fmt.Sprintf("undo #%d (%s)", target.ID, target.Operation), Reverts: target.ID}
+	return target, am.SaveAliases()
+}
+
+// RedoOperation reapplies an operation reverted by UndoOperation and saves the aliases.
+// If id is 0 the most recently reverted operation is chosen, as long as no other
+// operation has been saved since. Conflicts are handled as in UndoOperation.
+// Returns the reapplied operation.
+func (am *AliasManager) RedoOperation(id int, force bool) (JournalEntry, error) {
+	entries, err := am.readJournal()
+	if err != nil {
+		return JournalEntry{}, err
+	}
+	undone, redoStack := journalState(entries)
+
+	if id == 0 {
+		if len(redoStack) == 0 {
+			return JournalEntry{}, fmt.Errorf("nothing to redo")
+		}
+		id = redoStack[len(redoStack)-1]
+	}
+
+	target, ok := findJournalEntry(entries, id)
+	switch {
+	case !ok:
+		return JournalEntry{}, fmt.Errorf("operation #%d not found in the journal (run 'aliasctl history' to list operations)", id)
+	case !undone[id]:
+		return JournalEntry{}, fmt.Errorf("operation #%d has not been undone", id)
+	}
+
+	if err := am.applyChanges(target.ID, target.Changes, false, force); err != nil {
+		return JournalEntry{}, err
+	}
+	am.operation = &JournalEntry{Operation: OpRedo, Description: fmt.Sprintf("redo #%d (%s)", target.ID, target.Operation), Reapplies: target.ID}
+	return target, am.SaveAliases()
+}
+
+// applyChanges sets each changed alias to its state before (revert) or after the operation.
+// Unless force is set, nothing is changed if any alias is not in the expected state.
+func (am *AliasManager) applyChanges(id int, changes []AliasChange, revert, force bool) error {
+	var conflicts []string
+	for _, change := range changes {
+		expected := change.Before
+		if revert {
+			expected = change.After
+		}
+		current, exists := am.Aliases[change.Name]
+		if (expected != nil) != exists || (expected != nil && *expected != current) {
+			conflicts = append(conflicts, change.Name)
+		}
+	}
+	if len(conflicts) > 0 && !force {
+		return &AliasConflictError{ID: id, Aliases: conflicts}
+	}
+
+	for _, change := range changes {
+		target := change.After
+		if revert {
+			target = change.Before
+		}
+		if target == nil {
+			delete(am.Aliases, change.Name)
+		} else {
+			am.Aliases[change.Name] = *target
+		}
+	}
+	return nil
+}
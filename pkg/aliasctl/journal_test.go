@@ -0,0 +1,107 @@
+package aliasctl
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestGCJournalDropsUndoWithItsOperation(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-journalMaxAge - time.Hour)
+	entries := []JournalEntry{
+		{ID: 1, Time: old, Operation: OpAdd},
+		{ID: 2, Time: old, Operation: OpAdd},
+		{ID: 3, Time: now, Operation: OpUndo, Reverts: 1},
+		{ID: 4, Time: now, Operation: OpRedo, Reapplies: 1},
+		{ID: 5, Time: now, Operation: OpUndo, Reverts: 2},
+		{ID: 6, Time: now, Operation: OpAdd},
+		{ID: 7, Time: now, Operation: OpUndo, Reverts: 6},
+	}
+
+	kept := gcJournal(entries, now)
+	var ids []int
+	for _, entry := range kept {
+		ids = append(ids, entry.ID)
+	}
+	if len(ids) != 2 || ids[0] != 6 || ids[1] != 7 {
+		t.Fatalf("gcJournal kept %v, want [6 7]", ids)
+	}
+
+	undone, redoStack := journalState(kept)
+	if !undone[6] || len(undone) != 1 {
+		t.Errorf("undone = %v, want only #6", undone)
+	}
+	if len(redoStack) != 1 || redoStack[0] != 6 {
+		t.Errorf("redo stack = %v, want [6]", redoStack)
+	}
+}
+
+func TestUndoRedoSurvivesCompaction(t *testing.T) {
+	am := newTestManager(t)
+	for range journalMaxEntries {
+		am.AddAlias("a", "echo a")
+		if err := am.SaveAliases(); err != nil {
+			t.Fatal(err)
+		}
+		am.RemoveAlias("a")
+		if err := am.SaveAliases(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	am.AddAlias("gs", "git status")
+	if err := am.SaveAliases(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.UndoOperation(0, false); err != nil {
+		t.Fatalf("UndoOperation: %v", err)
+	}
+	if _, exists := am.Aliases["gs"]; exists {
+		t.Fatal("gs still defined after undo")
+	}
+
+	entries, err := am.Journal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) >= journalMaxEntries+1 {
+		t.Errorf("journal has %d entries, want it compacted", len(entries))
+	}
+	ids := make(map[int]bool)
+	for _, entry := range entries {
+		ids[entry.ID] = true
+	}
+	for _, entry := range entries {
+		if target := entry.Reverts + entry.Reapplies; target != 0 && !ids[target] {
+			t.Errorf("%s #%d kept without operation #%d", entry.Operation, entry.ID, target)
+		}
+	}
+
+	if _, err := am.RedoOperation(0, false); err != nil {
+		t.Fatalf("RedoOperation: %v", err)
+	}
+	if _, exists := am.Aliases["gs"]; !exists {
+		t.Error("gs not defined after redo")
+	}
+}
+
+func TestSaveAliasesJournalWarning(t *testing.T) {
+	am := newTestManager(t)
+	// A directory in place of the journal cannot be read or appended to
+	if err := os.MkdirAll(am.journalFile(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	am.AddAlias("gs", "git status")
+	if err := am.SaveAliases(); err != nil {
+		t.Fatalf("SaveAliases: %v, want the aliases saved despite the journal", err)
+	}
+	var journalErr *JournalError
+	if len(am.Warnings) != 1 || !errors.As(am.Warnings[0], &journalErr) {
+		t.Fatalf("Warnings = %v, want a *JournalError", am.Warnings)
+	}
+	if err := am.LoadAliases(); err != nil || am.Aliases["gs"].Bash != "git status" {
+		t.Errorf("gs not saved: %v", err)
+	}
+}
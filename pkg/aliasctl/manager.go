@@ -32,6 +32,7 @@ func NewAliasManager() *AliasManager {
 		EncryptionKey:  encryptionKeyPath,
 		EncryptionUsed: false,
 	}
+	am.aiManager.Cache = &ai.Cache{Dir: filepath.Join(configDir, "ai-cache")}
 
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		fmt.Printf("Warning: couldn't create config directory: %v\n", err)
@@ -0,0 +1,32 @@
+package aliasctl
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// newTestManager returns an AliasManager for bash whose config directory and alias file
+// are in a temporary home directory.
+func newTestManager(t *testing.T) *AliasManager {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/bash")
+	am := NewAliasManager()
+	am.Shell = ShellBash
+	am.AliasFile = filepath.Join(home, ".bash_aliases")
+	return am
+}
+
+func TestValidateAliasName(t *testing.T) {
+	for _, name := range []string{"gs", "ll", "..", "k8s", "docker-ps", "g+", "git:log", "_private", "t-gs"} {
+		if err := ValidateAliasName(name); err != nil {
+			t.Errorf("ValidateAliasName(%q) = %v, want it accepted", name, err)
+		}
+	}
+	for _, name := range []string{"", "-gs", "g s", "x=1", "a;b", "a|b", "a&b", "$(x)", "`x`", "a'b", `a"b`, "a/b", "a>b", "a\nb"} {
+		if err := ValidateAliasName(name); err == nil {
+			t.Errorf("ValidateAliasName(%q) accepted, want an error", name)
+		}
+	}
+}
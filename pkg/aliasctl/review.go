@@ -0,0 +1,229 @@
+package aliasctl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Origins of pending commands.
+const (
+	OriginGenerate = "generate" // Produced by 'aliasctl generate'
+	OriginConvert  = "convert"  // Produced by 'aliasctl convert --all'
+	OriginAsk      = "ask"      // Produced by 'aliasctl ai ask' from a description
+)
+
+// Review actions for a pending command.
+const (
+	ReviewApprove = "approve" // Store the command, as proposed or as edited
+	ReviewReject  = "reject"  // Discard the command
+)
+
+// PendingCommand is a command produced by an AI provider that has not been reviewed yet.
+// Pending commands are kept apart from the aliases, so they are never applied to or
+// exported into shell files until approved with ResolvePending.
+type PendingCommand struct {
+	Name          string        `json:"name" yaml:"name" toml:"name"`                                                    // The alias name
+	Shell         ShellType     `json:"shell" yaml:"shell" toml:"shell"`                                                 // The shell the command is for
+	Command       string        `json:"command" yaml:"command" toml:"command"`                                           // The proposed command
+	SourceShell   ShellType     `json:"source_shell" yaml:"source_shell" toml:"source_shell"`                            // The shell of the command it was produced from
+	SourceCommand string        `json:"source_command" yaml:"source_command" toml:"source_command"`                      // The command it was produced from
+	Origin        string        `json:"origin" yaml:"origin" toml:"origin"`                                              // What produced it: generate, convert or ask
+	Description   string        `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"` // The description of a new alias; for ask, what was asked for
+	CreatedAt     time.Time     `json:"created_at" yaml:"created_at" toml:"created_at"`                                  // When it was produced
+	Risks         []RiskFinding `json:"risks,omitempty" yaml:"risks,omitempty" toml:"risks,omitempty"`                   // What ScreenAICommand found in it
+}
+
+// ReviewDecision is the outcome of reviewing a pending command.
+type ReviewDecision struct {
+	Name    string    // The alias name
+	Shell   ShellType // The shell the command is for
+	Action  string    // ReviewApprove or ReviewReject
+	Command string    // The command to store when approving; empty keeps the proposed command
+}
+
+// pendingFile returns the path of the commands waiting for review.
+func (am *AliasManager) pendingFile() string {
+	return filepath.Join(am.ConfigDir, "pending.toml")
+}
+
+// PendingCommands returns the commands waiting for review, sorted by alias and shell.
+func (am *AliasManager) PendingCommands() ([]PendingCommand, error) {
+	var store struct {
+		Pending []PendingCommand `toml:"pending"`
+	}
+	if _, err := toml.DecodeFile(am.pendingFile(), &store); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read pending commands %s: %w", am.pendingFile(), err)
+	}
+	sort.Slice(store.Pending, func(i, j int) bool {
+		if store.Pending[i].Name != store.Pending[j].Name {
+			return store.Pending[i].Name < store.Pending[j].Name
+		}
+		return store.Pending[i].Shell < store.Pending[j].Shell
+	})
+	return store.Pending, nil
+}
+
+// writePendingCommands saves the commands waiting for review, removing the file when
+// there are none.
+func (am *AliasManager) writePendingCommands(pending []PendingCommand) error {
+	if len(pending) == 0 {
+		if err := os.Remove(am.pendingFile()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	file, err := os.Create(am.pendingFile())
+	if err != nil {
+		return fmt.Errorf("failed to create pending commands file %s: %w (check file permissions)", am.pendingFile(), err)
+	}
+	defer file.Close()
+
+	store := struct {
+		Pending []PendingCommand `toml:"pending"`
+	}{pending}
+	return toml.NewEncoder(file).Encode(store)
+}
+
+// AddPendingCommands queues AI-produced commands for review. A command pending for the
+// same alias and shell is replaced.
+func (am *AliasManager) AddPendingCommands(commands []PendingCommand) error {
+	pending, err := am.PendingCommands()
+	if err != nil {
+		return err
+	}
+	for _, command := range commands {
+		if command.CreatedAt.IsZero() {
+			command.CreatedAt = time.Now()
+		}
+		replaced := false
+		for i := range pending {
+			if pending[i].Name == command.Name && pending[i].Shell == command.Shell {
+				pending[i], replaced = command, true
+			}
+		}
+		if !replaced {
+			pending = append(pending, command)
+		}
+	}
+	return am.writePendingCommands(pending)
+}
+
+// isPending reports whether a command for the alias and shell is waiting for review.
+func isPending(pending []PendingCommand, name string, shell ShellType) bool {
+	for _, command := range pending {
+		if command.Name == name && command.Shell == shell {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvePending applies review decisions. Approved commands are stored on their alias,
+// creating it if needed with the pending description, and marked as generated by AI
+// unless they were edited; rejected commands are discarded. All approvals are saved as a
+// single journal operation.
+func (am *AliasManager) ResolvePending(decisions []ReviewDecision) error {
+	pending, err := am.PendingCommands()
+	if err != nil {
+		return err
+	}
+
+	var approved []string
+	for _, decision := range decisions {
+		index := -1
+		for i, command := range pending {
+			if command.Name == decision.Name && command.Shell == decision.Shell {
+				index = i
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("no pending command for alias '%s' in %s (run 'aliasctl review --list' to see pending commands)", decision.Name, decision.Shell)
+		}
+
+		if decision.Action == ReviewApprove {
+			command := pending[index].Command
+			edited := decision.Command != "" && decision.Command != command
+			if edited {
+				command = decision.Command
+			}
+			commands := am.Aliases[decision.Name]
+			*aliasFields(&commands)[string(decision.Shell)] = command
+			commands.markAIGenerated(decision.Shell, !edited)
+			if commands.Description == "" {
+				commands.Description = pending[index].Description
+			}
+			am.Aliases[decision.Name] = commands
+			approved = append(approved, fmt.Sprintf("%s (%s)", decision.Name, decision.Shell))
+		}
+		pending = append(pending[:index], pending[index+1:]...)
+	}
+
+	if len(approved) > 0 {
+		am.SetOperation(OpReview, "review: approve "+strings.Join(approved, ", "))
+		if err := am.SaveAliases(); err != nil {
+			return err
+		}
+	}
+	return am.writePendingCommands(pending)
+}
+
+// ExplainCommandDiff describes how a proposed command differs from another command word
+// by word, for example "removed: $*; added: $argv". Returns "identical" if they match.
+func ExplainCommandDiff(from, to string) string {
+	a, b := strings.Fields(from), strings.Fields(to)
+
+	// Longest common subsequence of words
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var removed, added []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i, j = i+1, j+1
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, b[j])
+			j++
+		default:
+			removed = append(removed, a[i])
+			i++
+		}
+	}
+
+	var parts []string
+	if len(removed) > 0 {
+		parts = append(parts, "removed: "+strings.Join(removed, " "))
+	}
+	if len(added) > 0 {
+		parts = append(parts, "added: "+strings.Join(added, " "))
+	}
+	if len(parts) == 0 {
+		if from == to {
+			return "identical"
+		}
+		return "only whitespace differs"
+	}
+	return strings.Join(parts, "; ")
+}
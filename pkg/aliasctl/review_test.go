@@ -0,0 +1,56 @@
+package aliasctl
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestApplyAliasesLeavesOutPendingCommands(t *testing.T) {
+	am := newTestManager(t)
+	am.AddAlias("gs", "git status")
+	am.Aliases["ll"] = AliasCommands{Zsh: "ls -l"}
+	if err := am.AddPendingCommands([]PendingCommand{
+		{Name: "ll", Shell: ShellBash, Command: "ls -l --color", SourceShell: ShellZsh, SourceCommand: "ls -l", Origin: OriginConvert},
+		{Name: "gd", Shell: ShellBash, Command: "git diff", Origin: OriginAsk},
+	}); err != nil {
+		t.Fatalf("AddPendingCommands: %v", err)
+	}
+
+	if err := am.ApplyAliases(); err != nil {
+		t.Fatalf("ApplyAliases: %v", err)
+	}
+	data, err := os.ReadFile(am.AliasFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "alias gs=") {
+		t.Errorf("alias file is missing gs:\n%s", content)
+	}
+	for _, pending := range []string{"alias ll=", "alias gd=", "--color", "git diff"} {
+		if strings.Contains(content, pending) {
+			t.Errorf("alias file contains pending %q:\n%s", pending, content)
+		}
+	}
+}
+
+func TestAliasesWithoutShell(t *testing.T) {
+	am := newTestManager(t)
+	am.AddAlias("gs", "git status")
+	am.Aliases["ll"] = AliasCommands{Zsh: "ls -l"}
+	am.Aliases["la"] = AliasCommands{Zsh: "ls -la"}
+	if err := am.AddPendingCommands([]PendingCommand{
+		{Name: "ll", Shell: ShellFish, Command: "ls -l", SourceShell: ShellZsh, SourceCommand: "ls -l", Origin: OriginConvert},
+	}); err != nil {
+		t.Fatalf("AddPendingCommands: %v", err)
+	}
+
+	missing, pending, err := am.AliasesWithoutShell(ShellFish)
+	if err != nil {
+		t.Fatalf("AliasesWithoutShell: %v", err)
+	}
+	if strings.Join(missing, ",") != "gs,la,ll" || strings.Join(pending, ",") != "ll" {
+		t.Errorf("AliasesWithoutShell(fish) = %v, %v; want [gs la ll], [ll]", missing, pending)
+	}
+}
@@ -0,0 +1,730 @@
+package aliasctl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// AI safety policies, chosen with SetAISafetyPolicy.
+const (
+	SafetyPolicyWarn  = "warn"  // Show risky AI results with their findings (the default)
+	SafetyPolicyBlock = "block" // Refuse AI results that add risks the source command did not have
+)
+
+// Risk rules reported by AnalyzeCommandRisk.
+const (
+	RiskDestructive = "destructive"       // Deletes, wipes or force-overwrites data
+	RiskPrivilege   = "privilege"         // Runs with elevated privileges
+	RiskPipeToShell = "pipe-to-shell"     // Executes text produced at run time, such as curl | sh
+	RiskNetwork     = "network"           // Contacts another host
+	RiskOverwrite   = "overwrite"         // Replaces the contents of a file
+	RiskDrift       = "different-command" // Runs different commands than the source command
+)
+
+// RiskFinding is something risky found in a command.
+type RiskFinding struct {
+	Rule       string `json:"rule" yaml:"rule" toml:"rule"`                   // One of the Risk rules
+	Detail     string `json:"detail" yaml:"detail" toml:"detail"`             // What was found
+	Introduced bool   `json:"introduced" yaml:"introduced" toml:"introduced"` // Whether the source command did not have it
+
+	key string // Identifies the finding independent of shell syntax, for comparing with the source
+}
+
+// String returns the finding as "rule: detail".
+func (f RiskFinding) String() string {
+	if f.Introduced && f.Rule != RiskDrift {
+		return fmt.Sprintf("%s: %s (not in the source command)", f.Rule, f.Detail)
+	}
+	return fmt.Sprintf("%s: %s", f.Rule, f.Detail)
+}
+
+// UnsafeCommandError is returned for an AI result that the block policy refuses.
+type UnsafeCommandError struct {
+	Command  string        // The refused command
+	Findings []RiskFinding // The risks that caused the refusal
+}
+
+// Error returns the error message for an UnsafeCommandError.
+func (e *UnsafeCommandError) Error() string {
+	reasons := make([]string, 0, len(e.Findings))
+	for _, finding := range e.Findings {
+		reasons = append(reasons, finding.String())
+	}
+	return fmt.Sprintf("refusing AI result '%s': %s", e.Command, strings.Join(reasons, "; "))
+}
+
+// commandSegment is one simple command of a command line, such as one side of a pipe.
+type commandSegment struct {
+	words     []string         // The words of the command, unquoted
+	piped     bool             // Whether its input is piped from the previous command
+	redirects []string         // Files its output replaces with > redirections
+	nested    []commandSegment // Commands run by $(...), `...` and <(...) substitutions in it
+}
+
+// Groups of commands that do the same thing in different shells, so a conversion from
+// one to another is not reported as a different command.
+var equivalentCommands = [][]string{
+	{"ls", "dir", "get-childitem", "gci", "exa", "eza", "lsd", "find"},
+	{"cat", "type", "get-content", "gc", "bat", "batcat"},
+	{"rm", "del", "erase", "remove-item", "ri", "rmdir", "rd"},
+	{"cp", "copy", "copy-item", "cpi", "xcopy", "robocopy"},
+	{"mv", "move", "move-item", "mi", "ren", "rename", "rename-item"},
+	{"clear", "cls", "clear-host"},
+	{"grep", "findstr", "select-string", "sls", "rg", "egrep", "fgrep"},
+	{"echo", "printf", "write-output", "write-host", "write"},
+	{"pwd", "get-location", "gl"},
+	{"cd", "chdir", "set-location", "sl", "pushd", "popd", "push-location", "pop-location"},
+	{"which", "where", "get-command", "gcm"},
+	{"ps", "tasklist", "get-process", "gps"},
+	{"kill", "pkill", "killall", "taskkill", "stop-process", "spps"},
+	{"touch", "new-item", "ni"},
+	{"mkdir", "md", "new-item", "ni"},
+	{"curl", "wget", "invoke-webrequest", "iwr", "invoke-restmethod", "irm"},
+	{"open", "xdg-open", "start", "start-process", "invoke-item", "ii"},
+	{"history", "get-history", "h"},
+	{"head", "tail", "select-object", "select"},
+	{"sort", "sort-object"},
+	{"wc", "measure-object"},
+	{"sleep", "start-sleep"},
+	{"date", "get-date"},
+	{"man", "get-help", "help"},
+	{"less", "more", "out-host"},
+	{"source", "."},
+	{"sudo", "doas", "gsudo", "runas"},
+	{"sh", "bash", "zsh", "fish", "ksh", "dash", "pwsh", "powershell", "cmd"},
+	{"tar", "zip", "unzip", "compress-archive", "expand-archive"},
+}
+
+// Commands that only run the command given as their arguments.
+var wrapperCommands = map[string]bool{
+	"sudo": true, "doas": true, "gsudo": true, "env": true, "nohup": true, "time": true, "nice": true,
+	"exec": true, "command": true, "builtin": true, "xargs": true, "watch": true, "stdbuf": true, "timeout": true,
+}
+
+// Options of wrapperCommands that take a value, by wrapper. A value given as the next
+// word, such as the user in sudo -u root, is not the wrapped command.
+var wrapperValueFlags = map[string]map[string]bool{
+	"sudo":    {"-u": true, "-g": true, "-U": true, "-C": true, "-h": true, "-p": true, "-r": true, "-t": true, "-D": true, "-R": true, "-T": true},
+	"doas":    {"-u": true, "-C": true},
+	"env":     {"-u": true, "-C": true, "--unset": true, "--chdir": true},
+	"nice":    {"-n": true, "--adjustment": true},
+	"exec":    {"-a": true},
+	"time":    {"-f": true, "-o": true},
+	"xargs":   {"-I": true, "-n": true, "-L": true, "-P": true, "-d": true, "-E": true, "-s": true, "-a": true},
+	"watch":   {"-n": true, "--interval": true},
+	"stdbuf":  {"-i": true, "-o": true, "-e": true},
+	"timeout": {"-s": true, "-k": true, "--signal": true, "--kill-after": true},
+}
+
+// Shell keywords and builtins that conversions add without changing what a command does.
+var neutralCommands = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true, "for": true, "while": true, "do": true,
+	"done": true, "case": true, "esac": true, "function": true, "end": true, "begin": true, "and": true,
+	"or": true, "not": true, "set": true, "test": true, "[": true, "[[": true, "true": true, "false": true,
+	"return": true, "local": true, "export": true, "setlocal": true, "endlocal": true, "param": true,
+	"{": true, "}": true, "@echo": true, "shift": true, "argparse": true,
+}
+
+// Commands that run their input or argument as code.
+var interpreterCommands = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "ksh": true, "dash": true, "csh": true, "tcsh": true,
+	"python": true, "python3": true, "perl": true, "ruby": true, "node": true, "php": true,
+	"pwsh": true, "powershell": true, "cmd": true,
+	"eval": true, "source": true, ".": true, "iex": true, "invoke-expression": true,
+}
+
+// Commands that contact other hosts.
+var networkCommands = map[string]bool{
+	"curl": true, "wget": true, "fetch": true, "aria2c": true, "http": true, "https": true,
+	"nc": true, "ncat": true, "netcat": true, "socat": true, "telnet": true, "ftp": true, "sftp": true,
+	"tftp": true, "scp": true, "ssh": true, "invoke-webrequest": true, "iwr": true,
+	"invoke-restmethod": true, "irm": true, "start-bitstransfer": true, "bitsadmin": true,
+}
+
+// Commands that run as another, usually privileged, user.
+var privilegeCommands = map[string]bool{
+	"sudo": true, "doas": true, "gsudo": true, "su": true, "pkexec": true, "runas": true,
+}
+
+// Commands that destroy data whatever their arguments.
+var wipeCommands = map[string]bool{
+	"mkfs": true, "shred": true, "wipefs": true, "fdisk": true, "sfdisk": true, "parted": true,
+	"format": true, "diskpart": true, "format-volume": true, "clear-disk": true, "initialize-disk": true,
+	"remove-partition": true, "truncate": true, "shutdown": true, "reboot": true, "halt": true,
+	"poweroff": true, "stop-computer": true, "restart-computer": true,
+}
+
+var (
+	// forkBombPattern matches the classic shell fork bomb.
+	forkBombPattern = regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&`)
+	// urlPattern matches URLs in command arguments.
+	urlPattern = regexp.MustCompile(`(?i)\b(https?|ftp)://[^\s'"]+`)
+	// rsyncRemotePattern matches remote rsync paths such as host:path.
+	rsyncRemotePattern = regexp.MustCompile(`^[^/\s]+:`)
+)
+
+// AnalyzeCommandRisk reports destructive commands, privilege escalation, commands run
+// from pipes or downloads, network access and file overwrites in a command. If source
+// is not empty, the command is compared with it: findings the source did not have are
+// marked as introduced, and commands that run different programs than the source are
+// reported.
+func AnalyzeCommandRisk(command, source string) []RiskFinding {
+	findings := analyzeSegments(parseCommandSegments(command))
+	if forkBombPattern.MatchString(command) {
+		findings = append(findings, RiskFinding{Rule: RiskDestructive, Detail: "fork bomb", key: "fork-bomb"})
+	}
+	if source == "" {
+		return dedupeFindings(findings)
+	}
+
+	sourceSegments := parseCommandSegments(source)
+	sourceKeys := make(map[string]bool)
+	for _, finding := range analyzeSegments(sourceSegments) {
+		sourceKeys[finding.key] = true
+	}
+	if forkBombPattern.MatchString(source) {
+		sourceKeys["fork-bomb"] = true
+	}
+	for i := range findings {
+		findings[i].Introduced = !sourceKeys[findings[i].key]
+	}
+
+	// Compare the programs run, allowing each shell's equivalent of a command
+	sourcePrograms := segmentPrograms(sourceSegments)
+	programs := segmentPrograms(parseCommandSegments(command))
+	for _, program := range programs {
+		if !containsEquivalent(sourcePrograms, program) {
+			findings = append(findings, RiskFinding{Rule: RiskDrift, Detail: fmt.Sprintf("runs %s, which the source command does not", program), Introduced: true, key: "drift+" + program})
+		}
+	}
+	if len(sourcePrograms) > 0 && len(programs) > 0 && !containsEquivalent(programs, sourcePrograms[0]) {
+		findings = append(findings, RiskFinding{Rule: RiskDrift, Detail: fmt.Sprintf("does not run %s like the source command", sourcePrograms[0]), Introduced: true, key: "drift-" + sourcePrograms[0]})
+	}
+	return dedupeFindings(findings)
+}
+
+// SetAISafetyPolicy sets whether risky AI results are blocked or shown with a warning.
+func (am *AliasManager) SetAISafetyPolicy(policy string) error {
+	switch policy {
+	case SafetyPolicyWarn, SafetyPolicyBlock:
+	default:
+		return fmt.Errorf("unknown AI safety policy '%s': use %s or %s", policy, SafetyPolicyWarn, SafetyPolicyBlock)
+	}
+	am.AISafetyPolicy = policy
+	return am.SaveConfig()
+}
+
+// ScreenAICommand analyzes a command produced by an AI provider from source (see
+// AnalyzeCommandRisk). Under the block policy, a command with risks the source did not
+// have is refused with an *UnsafeCommandError; a command written from a description,
+// with an empty source, is refused for any risk. Otherwise the findings are returned
+// for display.
+func (am *AliasManager) ScreenAICommand(command, source string) ([]RiskFinding, error) {
+	findings := AnalyzeCommandRisk(command, source)
+	if am.AISafetyPolicy != SafetyPolicyBlock {
+		return findings, nil
+	}
+
+	var introduced []RiskFinding
+	for _, finding := range findings {
+		if finding.Introduced || source == "" {
+			introduced = append(introduced, finding)
+		}
+	}
+	if len(introduced) > 0 {
+		return findings, &UnsafeCommandError{Command: command, Findings: introduced}
+	}
+	return findings, nil
+}
+
+// analyzeSegments applies the risk rules to each command and its substitutions.
+func analyzeSegments(segments []commandSegment) []RiskFinding {
+	var findings []RiskFinding
+	for i, segment := range segments {
+		findings = append(findings, analyzeSegments(segment.nested)...)
+		words, wrappers := unwrapCommand(segment.words)
+		detail := limitDetail(strings.Join(segment.words, " "))
+
+		for _, wrapper := range wrappers {
+			if privilegeCommands[wrapper] {
+				findings = append(findings, RiskFinding{Rule: RiskPrivilege, Detail: detail, key: "privilege"})
+			}
+		}
+		for _, target := range segment.redirects {
+			if strings.HasPrefix(target, "/dev/") {
+				findings = append(findings, RiskFinding{Rule: RiskDestructive, Detail: "writes to device " + target, key: "device+" + target})
+			} else {
+				findings = append(findings, RiskFinding{Rule: RiskOverwrite, Detail: "> " + target + " replaces the file if it exists", key: "overwrite+" + target})
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		program := programName(words[0])
+		args := words[1:]
+		if privilegeCommands[program] {
+			findings = append(findings, RiskFinding{Rule: RiskPrivilege, Detail: detail, key: "privilege"})
+		}
+		if program == "start-process" && hasArgValue(args, "-verb", "runas") {
+			findings = append(findings, RiskFinding{Rule: RiskPrivilege, Detail: detail, key: "privilege"})
+		}
+		if isDestructive(program, args) {
+			findings = append(findings, RiskFinding{Rule: RiskDestructive, Detail: detail, key: "destructive+" + canonicalProgram(program)})
+		}
+		if target := overwrittenFile(program, args); target != "" {
+			findings = append(findings, RiskFinding{Rule: RiskOverwrite, Detail: fmt.Sprintf("%s replaces %s if it exists", program, target), key: "overwrite+" + target})
+		}
+
+		network := networkCommands[program] || (program == "rsync" && slices.ContainsFunc(args, rsyncRemotePattern.MatchString))
+		if network {
+			findings = append(findings, RiskFinding{Rule: RiskNetwork, Detail: detail, key: "network+" + canonicalProgram(program)})
+		} else if url := urlPattern.FindString(strings.Join(args, " ")); url != "" && len(segment.nested) == 0 {
+			findings = append(findings, RiskFinding{Rule: RiskNetwork, Detail: "contacts " + url, key: "network+" + url})
+		}
+
+		if interpreterCommands[program] {
+			source := ""
+			if segment.piped && i > 0 {
+				source = limitDetail(strings.Join(segments[i-1].words, " "))
+			}
+			for _, nested := range segment.nested {
+				if nestedWords, _ := unwrapCommand(nested.words); len(nestedWords) > 0 && networkCommands[programName(nestedWords[0])] {
+					source = "a download: " + limitDetail(strings.Join(nested.words, " "))
+				}
+			}
+			if source != "" {
+				findings = append(findings, RiskFinding{Rule: RiskPipeToShell, Detail: fmt.Sprintf("%s runs code from %s", program, source), key: "pipe-to-shell"})
+			}
+			// Commands passed as a string, such as bash -c '...', are analyzed too
+			if code := inlineScript(program, args); code != "" {
+				findings = append(findings, analyzeSegments(parseCommandSegments(code))...)
+			}
+		}
+	}
+	return findings
+}
+
+// isDestructive reports whether a command deletes or wipes data.
+func isDestructive(program string, args []string) bool {
+	switch {
+	case wipeCommands[program] || strings.HasPrefix(program, "mkfs."):
+		return true
+	case program == "dd" && slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "of=") }):
+		return true
+	case program == "rm":
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "--") {
+				if arg == "--recursive" || arg == "--force" {
+					return true
+				}
+			} else if strings.HasPrefix(arg, "-") && strings.ContainsAny(arg, "rRf") {
+				return true
+			} else if isSweepingPath(arg) {
+				return true
+			}
+		}
+	case program == "remove-item" || program == "ri" || program == "del" || program == "erase" || program == "rd" || program == "rmdir":
+		for _, arg := range args {
+			lower := strings.ToLower(arg)
+			if strings.HasPrefix(lower, "-r") || strings.HasPrefix(lower, "-fo") || lower == "/s" || lower == "/q" || isSweepingPath(arg) {
+				return true
+			}
+		}
+	case program == "chmod" || program == "chown" || program == "chgrp":
+		if slices.ContainsFunc(args, func(arg string) bool { return arg == "-R" || arg == "--recursive" }) {
+			return true
+		}
+	case program == "find":
+		if slices.ContainsFunc(args, func(arg string) bool { return arg == "-delete" }) {
+			return true
+		}
+	case program == "git" && len(args) > 0:
+		rest := strings.Join(args[1:], " ")
+		switch args[0] {
+		case "reset":
+			if strings.Contains(rest, "--hard") {
+				return true
+			}
+		case "clean":
+			if slices.ContainsFunc(args[1:], func(arg string) bool {
+				return strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg, "f") || arg == "--force"
+			}) {
+				return true
+			}
+		case "push":
+			if slices.ContainsFunc(args[1:], func(arg string) bool { return arg == "-f" || arg == "--force" || strings.HasPrefix(arg, "+") }) {
+				return true
+			}
+		case "branch":
+			if slices.ContainsFunc(args[1:], func(arg string) bool { return arg == "-D" }) {
+				return true
+			}
+		case "stash":
+			if strings.HasPrefix(rest, "clear") || strings.HasPrefix(rest, "drop") {
+				return true
+			}
+		}
+	case program == "mv" && len(args) > 0 && args[len(args)-1] == "/dev/null":
+		return true
+	case (program == "kubectl" || program == "helm") && len(args) > 0 && (args[0] == "delete" || args[0] == "uninstall"):
+		return true
+	case program == "docker" || program == "podman":
+		rest := strings.Join(args, " ")
+		if strings.Contains(rest, "prune") || strings.HasPrefix(rest, "rm -f") || strings.HasPrefix(rest, "volume rm") {
+			return true
+		}
+	}
+	return false
+}
+
+// overwrittenFile returns the file a command replaces instead of appending to, or an
+// empty string if it replaces none.
+func overwrittenFile(program string, args []string) string {
+	switch program {
+	case "tee":
+		var files []string
+		for _, arg := range args {
+			if arg == "-a" || arg == "--append" {
+				return ""
+			}
+			if !strings.HasPrefix(arg, "-") && !isNullDevice(arg) {
+				files = append(files, arg)
+			}
+		}
+		return strings.Join(files, " ")
+	case "out-file", "set-content", "sc":
+		var target string
+		for i, arg := range args {
+			lower := strings.ToLower(arg)
+			if lower == "-append" {
+				return ""
+			}
+			if (lower == "-filepath" || lower == "-path") && i+1 < len(args) {
+				target = args[i+1]
+			} else if target == "" && !strings.HasPrefix(arg, "-") {
+				target = arg
+			}
+		}
+		if isNullDevice(target) {
+			return ""
+		}
+		return target
+	}
+	return ""
+}
+
+// inlineScript returns the code an interpreter is given as an argument, such as the
+// string after bash -c, pwsh -Command or cmd /c.
+func inlineScript(program string, args []string) string {
+	for i, arg := range args {
+		lower := strings.ToLower(arg)
+		switch {
+		case (lower == "-c" || lower == "-command" || lower == "/c" || lower == "/k") && i+1 < len(args):
+			return strings.Join(args[i+1:], " ")
+		case program == "eval" || program == "iex" || program == "invoke-expression":
+			return strings.Join(args, " ")
+		}
+	}
+	return ""
+}
+
+// segmentPrograms returns the programs a command line runs, without wrappers such as
+// sudo and without shell keywords, in order of appearance.
+func segmentPrograms(segments []commandSegment) []string {
+	var programs []string
+	for _, segment := range segments {
+		words, _ := unwrapCommand(segment.words)
+		if len(words) > 0 {
+			program := programName(words[0])
+			if !neutralCommands[program] && !strings.HasPrefix(program, "$") && !strings.HasPrefix(program, "%") && !containsEquivalent(programs, program) {
+				programs = append(programs, program)
+			}
+		}
+		for _, nested := range segmentPrograms(segment.nested) {
+			if !containsEquivalent(programs, nested) {
+				programs = append(programs, nested)
+			}
+		}
+	}
+	return programs
+}
+
+// containsEquivalent reports whether programs has program or an equivalent of it.
+func containsEquivalent(programs []string, program string) bool {
+	for _, candidate := range programs {
+		if candidate == program {
+			return true
+		}
+		for _, group := range equivalentCommands {
+			if slices.Contains(group, candidate) && slices.Contains(group, program) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canonicalProgram returns the first command of the group program belongs to, so
+// equivalent commands compare equal.
+func canonicalProgram(program string) string {
+	for _, group := range equivalentCommands {
+		if slices.Contains(group, program) {
+			return group[0]
+		}
+	}
+	return program
+}
+
+// unwrapCommand strips variable assignments and wrapper commands such as sudo or env
+// from the start of a command. Returns the remaining words and the wrappers removed.
+func unwrapCommand(words []string) ([]string, []string) {
+	var wrappers []string
+	for len(words) > 0 {
+		word := words[0]
+		if strings.Contains(word, "=") && !strings.HasPrefix(word, "=") && !strings.HasPrefix(word, "-") {
+			words = words[1:]
+			continue
+		}
+		program := programName(word)
+		if !wrapperCommands[program] {
+			break
+		}
+		wrappers = append(wrappers, program)
+		words = words[1:]
+		for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+			flag := words[0]
+			words = words[1:]
+			if wrapperValueFlags[program][flag] && len(words) > 0 {
+				words = words[1:]
+			}
+		}
+		if program == "timeout" && len(words) > 0 {
+			words = words[1:]
+		}
+	}
+	return words, wrappers
+}
+
+// programName returns the lowercase name of a program without its directory or a
+// Windows executable extension.
+func programName(word string) string {
+	name := strings.ToLower(word)
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 && i < len(name)-1 {
+		name = name[i+1:]
+	}
+	for _, ext := range []string{".exe", ".cmd", ".bat", ".com", ".ps1"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// parseCommandSegments splits a command line into its simple commands at pipes, command
+// separators and background operators, honoring quotes. Substitutions are parsed into
+// the nested commands of the command they appear in, and > redirections are collected.
+func parseCommandSegments(command string) []commandSegment {
+	var segments []commandSegment
+	var current commandSegment
+	var word strings.Builder
+	inWord := false
+
+	endWord := func() {
+		if inWord {
+			current.words = append(current.words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endSegment := func(piped bool) {
+		endWord()
+		if len(current.words) > 0 || len(current.nested) > 0 || len(current.redirects) > 0 {
+			segments = append(segments, current)
+		}
+		current = commandSegment{piped: piped}
+	}
+	substitution := func(inner string) {
+		current.nested = append(current.nested, parseCommandSegments(inner)...)
+	}
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && i+1 < len(command) && strings.IndexByte("\"'\\$`|&;<>() \t", command[i+1]) >= 0:
+			i++
+			word.WriteByte(command[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				end = len(command) - i - 1
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				switch {
+				case command[i] == '\\' && i+1 < len(command):
+					i++
+				case command[i] == '$' && i+1 < len(command) && command[i+1] == '(':
+					end := matchingParen(command, i+1)
+					substitution(command[i+2 : end])
+					word.WriteString(command[i:min(end+1, len(command))])
+					i = end
+					continue
+				case command[i] == '`':
+					if end := strings.IndexByte(command[i+1:], '`'); end >= 0 {
+						substitution(command[i+1 : i+1+end])
+						word.WriteString(command[i : i+2+end])
+						i += end + 1
+						continue
+					}
+				}
+				word.WriteByte(command[i])
+			}
+			inWord = true
+		case (c == '$' || c == '<' || c == '>') && i+1 < len(command) && command[i+1] == '(':
+			end := matchingParen(command, i+1)
+			substitution(command[i+2 : end])
+			word.WriteString(command[i:min(end+1, len(command))])
+			i = end
+			inWord = true
+		case c == '`':
+			end := strings.IndexByte(command[i+1:], '`')
+			if end < 0 {
+				word.WriteByte(c)
+				inWord = true
+				continue
+			}
+			substitution(command[i+1 : i+1+end])
+			i += end + 1
+		case c == ' ' || c == '\t':
+			endWord()
+		case c == '|':
+			if i+1 < len(command) && command[i+1] == '|' {
+				i++
+				endSegment(false)
+			} else {
+				endSegment(true)
+			}
+		case c == '&':
+			if i+1 < len(command) && command[i+1] == '&' {
+				i++
+			}
+			endSegment(false)
+		case c == ';' || c == '\n':
+			endSegment(false)
+		case c == '>':
+			// A file descriptor number belongs to the redirection, not the command
+			if inWord && strings.Trim(word.String(), "0123456789") == "" {
+				word.Reset()
+				inWord = false
+			}
+			endWord()
+			if i+1 < len(command) && (command[i+1] == '>' || command[i+1] == '&') {
+				// Appending (>>) and duplicating a descriptor (>&2) leave files intact
+				i++
+				for i+1 < len(command) && strings.IndexByte(" \t|&;", command[i+1]) < 0 {
+					i++
+				}
+				continue
+			}
+			if i+1 < len(command) && command[i+1] == '|' {
+				i++
+			}
+			j := i + 1
+			for j < len(command) && (command[j] == ' ' || command[j] == '\t') {
+				j++
+			}
+			k := j
+			for k < len(command) && strings.IndexByte(" \t|&;<>", command[k]) < 0 {
+				k++
+			}
+			if target := strings.Trim(command[j:k], `'"`); target != "" && !isNullDevice(target) {
+				current.redirects = append(current.redirects, target)
+			}
+			i = k - 1
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endSegment(false)
+	return segments
+}
+
+// matchingParen returns the index of the parenthesis closing the one at open, or the
+// end of s if it is not closed.
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\'':
+			if end := strings.IndexByte(s[i+1:], '\''); end >= 0 {
+				i += end + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// isSweepingPath reports whether a path argument covers a whole tree such as /, ~ or a
+// wildcard.
+func isSweepingPath(path string) bool {
+	switch strings.TrimRight(path, `/\`) {
+	case "", "~", "$HOME", "${HOME}", "*", ".", "..", "C:", "c:", "%USERPROFILE%", "$env:USERPROFILE":
+		return true
+	}
+	return strings.Contains(path, "*")
+}
+
+// isNullDevice reports whether a redirection target discards output.
+func isNullDevice(target string) bool {
+	switch strings.ToLower(target) {
+	case "/dev/null", "/dev/stdout", "/dev/stderr", "/dev/tty", "nul", "$null":
+		return true
+	}
+	return false
+}
+
+// hasArgValue reports whether args has the flag followed by the value, case-insensitively.
+func hasArgValue(args []string, flag, value string) bool {
+	for i := 0; i+1 < len(args); i++ {
+		if strings.EqualFold(args[i], flag) && strings.EqualFold(strings.Trim(args[i+1], `'"`), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// limitDetail shortens a command for display in a finding.
+func limitDetail(text string) string {
+	if len(text) > 60 {
+		return text[:57] + "..."
+	}
+	return text
+}
+
+// dedupeFindings removes repeated findings, keeping the first of each.
+func dedupeFindings(findings []RiskFinding) []RiskFinding {
+	seen := make(map[string]bool)
+	var unique []RiskFinding
+	for _, finding := range findings {
+		id := finding.Rule + "\x00" + finding.Detail
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, finding)
+		}
+	}
+	return unique
+}
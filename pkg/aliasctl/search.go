@@ -0,0 +1,210 @@
+package aliasctl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field weights applied to fuzzy scores so that name matches rank above
+// command matches, which in turn rank above description matches.
+const (
+	nameMatchWeight        = 3
+	commandMatchWeight     = 2
+	descriptionMatchWeight = 1
+)
+
+// SearchResult is a single ranked match returned by SearchAliases.
+type SearchResult struct {
+	Name        string `json:"name" yaml:"name" toml:"name"`                                                    // The alias name
+	Command     string `json:"command" yaml:"command" toml:"command"`                                           // The command for the current shell, or the first defined command
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"` // Optional description
+	Score       int    `json:"score" yaml:"score" toml:"score"`                                                 // The relevance score, higher is better
+	Field       string `json:"field" yaml:"field" toml:"field"`                                                 // The field that matched best: name, command or description
+}
+
+// SearchAliases fuzzy-matches the query against alias names, commands and descriptions.
+// Each whitespace-separated term of the query must match at least one field. Results
+// are ordered by descending score, then by name. An empty query matches every alias.
+// Aliases from bundles are searched as well.
+func (am *AliasManager) SearchAliases(query string) []SearchResult {
+	terms := strings.Fields(query)
+	results := []SearchResult{}
+
+	aliases := am.EffectiveAliases()
+	for _, name := range sortedNames(aliases) {
+		commands := aliases[name]
+		command := commands.ForShell(am.Shell)
+		if command == "" {
+			for _, shell := range SupportedShells {
+				if command = commands.ForShell(shell); command != "" {
+					break
+				}
+			}
+		}
+
+		result := SearchResult{Name: name, Command: command, Description: commands.Description}
+		matched := true
+		bestField, bestScore := "name", 0
+		for _, term := range terms {
+			termField, termScore := "", 0
+			if score, ok := FuzzyScore(term, name); ok && score*nameMatchWeight > termScore {
+				termField, termScore = "name", score*nameMatchWeight
+			}
+			for _, shellCommand := range commands.Shells() {
+				if score, ok := FuzzyScore(term, shellCommand); ok && score*commandMatchWeight > termScore {
+					termField, termScore = "command", score*commandMatchWeight
+				}
+			}
+			if score, ok := FuzzyScore(term, commands.Description); ok && score*descriptionMatchWeight > termScore {
+				termField, termScore = "description", score*descriptionMatchWeight
+			}
+			if termField == "" {
+				matched = false
+				break
+			}
+			if termScore > bestScore {
+				bestField, bestScore = termField, termScore
+			}
+			result.Score += termScore
+		}
+		if !matched {
+			continue
+		}
+		result.Field = bestField
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// NarrowSearch matches query against the aliases of results, as SearchAliases does, and
+// returns the results that still match, rescored and reordered for the query. Aliases
+// that are not in results are left out, so each query narrows the list further.
+func (am *AliasManager) NarrowSearch(results []SearchResult, query string) []SearchResult {
+	current := make(map[string]bool, len(results))
+	for _, result := range results {
+		current[result.Name] = true
+	}
+	narrowed := []SearchResult{}
+	for _, result := range am.SearchAliases(query) {
+		if current[result.Name] {
+			narrowed = append(narrowed, result)
+		}
+	}
+	return narrowed
+}
+
+// FuzzyScore reports whether every character of pattern appears in text in order,
+// ignoring case, and scores the quality of the match.
+// Consecutive characters, matches at word boundaries, prefixes and exact substrings
+// score higher. Returns false if pattern is not a subsequence of text.
+func FuzzyScore(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+
+	score := 0
+	pi := 0
+	previous := -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score++
+		if ti == previous+1 {
+			score += 4 // consecutive characters
+		}
+		if ti == 0 || isWordBoundary(t[ti-1]) {
+			score += 3 // start of a word
+		}
+		previous = ti
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+
+	lowerText, lowerPattern := string(t), string(p)
+	switch {
+	case lowerText == lowerPattern:
+		score += 20
+	case strings.HasPrefix(lowerText, lowerPattern):
+		score += 10
+	case strings.Contains(lowerText, lowerPattern):
+		score += 5
+	}
+	return score, true
+}
+
+// isWordBoundary reports whether r separates words in names and commands.
+func isWordBoundary(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("-_./:|;=", r)
+}
+
+// Search widget scripts insert the command of an alias picked with
+// 'aliasctl search --interactive' at the cursor, bound to Ctrl-X Ctrl-A.
+const (
+	bashSearchWidget = `# aliasctl search widget for bash
+__aliasctl_search_widget() {
+	local selected
+	selected="$(aliasctl search --interactive)" || return
+	READLINE_LINE="${READLINE_LINE:0:$READLINE_POINT}${selected}${READLINE_LINE:$READLINE_POINT}"
+	READLINE_POINT=$(( READLINE_POINT + ${#selected} ))
+}
+bind -x '"\C-x\C-a": __aliasctl_search_widget'
+`
+
+	zshSearchWidget = `# aliasctl search widget for zsh
+__aliasctl_search_widget() {
+	local selected
+	selected="$(aliasctl search --interactive </dev/tty)" || { zle reset-prompt; return }
+	LBUFFER="${LBUFFER}${selected}"
+	zle reset-prompt
+}
+zle -N __aliasctl_search_widget
+bindkey '^X^A' __aliasctl_search_widget
+`
+
+	fishSearchWidget = `# aliasctl search widget for fish
+function __aliasctl_search_widget
+    set -l selected (aliasctl search --interactive)
+    and commandline -i -- $selected
+    commandline -f repaint
+end
+bind \cx\ca __aliasctl_search_widget
+`
+
+	powerShellSearchWidget = `# aliasctl search widget for PowerShell (requires PSReadLine)
+Set-PSReadLineKeyHandler -Chord 'Ctrl+x,Ctrl+a' -BriefDescription 'aliasctl search' -ScriptBlock {
+    $selected = aliasctl search --interactive
+    if ($LASTEXITCODE -eq 0 -and $selected) {
+        [Microsoft.PowerShell.PSConsoleReadLine]::Insert($selected)
+    }
+}
+`
+)
+
+// SearchWidgetScript returns a shell snippet that binds Ctrl-X Ctrl-A to the
+// interactive alias picker and inserts the selected command into the command line.
+// Returns an error if the shell has no line editor aliasctl can hook into.
+func SearchWidgetScript(shell ShellType) (string, error) {
+	switch shell {
+	case ShellBash:
+		return bashSearchWidget, nil
+	case ShellZsh:
+		return zshSearchWidget, nil
+	case ShellFish:
+		return fishSearchWidget, nil
+	case ShellPowerShell, ShellPowerShellCore:
+		return powerShellSearchWidget, nil
+	default:
+		return "", fmt.Errorf("search widget not available for shell: %s (supported shells: bash, zsh, fish, powershell, pwsh)", shell)
+	}
+}
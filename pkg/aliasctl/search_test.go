@@ -0,0 +1,29 @@
+package aliasctl
+
+import "testing"
+
+func TestNarrowSearchKeepsToCurrentResults(t *testing.T) {
+	am := newTestManager(t)
+	am.AddAlias("gs", "git status")
+	am.AddAlias("gp", "git push")
+	am.AddAlias("kgp", "kubectl get pods")
+
+	results := am.SearchAliases("git")
+	if len(results) != 2 {
+		t.Fatalf("SearchAliases(git) = %d results, want 2", len(results))
+	}
+
+	// "p" also matches kgp, which is not in the list
+	narrowed := am.NarrowSearch(results, "p")
+	var names []string
+	for _, result := range narrowed {
+		names = append(names, result.Name)
+	}
+	if len(names) != 1 || names[0] != "gp" {
+		t.Errorf("NarrowSearch(git results, p) = %v, want [gp]", names)
+	}
+
+	if narrowed := am.NarrowSearch(narrowed, "status"); len(narrowed) != 0 {
+		t.Errorf("NarrowSearch(gp, status) = %v, want none", narrowed)
+	}
+}
@@ -0,0 +1,244 @@
+package aliasctl
+
+import (
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SuggestOptions controls which history commands SuggestAliases proposes.
+type SuggestOptions struct {
+	MinCount  int // Minimum number of times a command must appear in history
+	MinLength int // Minimum length of a command worth aliasing
+	Limit     int // Maximum number of suggestions to return, 0 for no limit
+}
+
+// AliasSuggestion is a frequently typed command proposed as a new alias.
+type AliasSuggestion struct {
+	Name            string `json:"name" yaml:"name" toml:"name"`                                     // The proposed alias name
+	Command         string `json:"command" yaml:"command" toml:"command"`                            // The command or command prefix to alias
+	Count           int    `json:"count" yaml:"count" toml:"count"`                                  // How often the command appears in history
+	KeystrokesSaved int    `json:"keystrokes_saved" yaml:"keystrokes_saved" toml:"keystrokes_saved"` // Characters that would not have been typed with the alias
+	Prefix          bool   `json:"prefix" yaml:"prefix" toml:"prefix"`                               // Whether the command is a prefix of longer commands
+}
+
+// commandSeparators end the part of a command line that can be aliased.
+var commandSeparators = map[string]bool{"|": true, "||": true, "&&": true, ";": true, "&": true}
+
+// SuggestAliases finds frequently typed long commands and command prefixes in history
+// and proposes aliases for them, ranked by the keystrokes they would save. Suggestions
+// that would save nothing are left out.
+// Commands that already start with an alias name, and commands covered by an existing
+// alias for the current shell, are excluded. Names are generated offline from the
+// command's words and never collide with existing aliases or executables.
+func (am *AliasManager) SuggestAliases(history []HistoryEntry, opts SuggestOptions) []AliasSuggestion {
+	if opts.MinCount <= 0 {
+		opts.MinCount = 3
+	}
+
+	aliases := am.EffectiveAliases()
+	existing := make([]string, 0, len(aliases))
+	for _, commands := range aliases {
+		if command := strings.TrimSpace(commands.ForShell(am.Shell)); command != "" {
+			existing = append(existing, command)
+		}
+	}
+
+	fullCounts := make(map[string]int)
+	prefixCounts := make(map[string]int)
+	for _, entry := range history {
+		words := strings.Fields(entry.Command)
+		if len(words) == 0 || strings.Contains(entry.Command, "\n") {
+			continue
+		}
+		if _, isAlias := aliases[words[0]]; isAlias {
+			continue
+		}
+		for i, word := range words {
+			if commandSeparators[word] {
+				words = words[:i]
+				break
+			}
+		}
+		if len(words) == 0 {
+			continue
+		}
+
+		fullCounts[strings.Join(words, " ")]++
+		for n := 2; n <= len(words); n++ {
+			prefixCounts[strings.Join(words[:n], " ")]++
+		}
+	}
+
+	// Prefix counts include exact uses, so a command is a prefix candidate only
+	// when it was also typed as the start of longer commands
+	candidates := make(map[string]*AliasSuggestion)
+	add := func(command string, count int, prefix bool) {
+		if count < opts.MinCount || len(command) < opts.MinLength || isCoveredByAlias(command, existing) {
+			return
+		}
+		candidates[command] = &AliasSuggestion{Command: command, Count: count, Prefix: prefix}
+	}
+	for command, count := range fullCounts {
+		if !strings.Contains(command, " ") {
+			add(command, count, false)
+		}
+	}
+	for command, count := range prefixCounts {
+		add(command, count, count > fullCounts[command])
+	}
+
+	// A prefix only used as part of one longer command adds nothing over that command
+	for command, candidate := range candidates {
+		for other, longer := range candidates {
+			if other != command && longer.Count == candidate.Count && strings.HasPrefix(other, command+" ") {
+				delete(candidates, command)
+				break
+			}
+		}
+	}
+
+	suggestions := make([]AliasSuggestion, 0, len(candidates))
+	for _, candidate := range candidates {
+		suggestions = append(suggestions, *candidate)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return suggestions[i].Command < suggestions[j].Command
+	})
+
+	taken := func(name string) bool {
+		if _, exists := aliases[name]; exists {
+			return true
+		}
+		for _, suggestion := range suggestions {
+			if suggestion.Name == name {
+				return true
+			}
+		}
+		_, err := exec.LookPath(name)
+		return err == nil
+	}
+	for i := range suggestions {
+		suggestions[i].Name = SuggestAliasName(suggestions[i].Command, taken)
+		suggestions[i].KeystrokesSaved = keystrokesSaved(suggestions[i].Count, suggestions[i].Command, suggestions[i].Name)
+	}
+
+	suggestions = rankSuggestions(suggestions)
+	if opts.Limit > 0 && len(suggestions) > opts.Limit {
+		suggestions = suggestions[:opts.Limit]
+	}
+	return suggestions
+}
+
+// NameSuggestions gives suggestions the names in names, by index, such as names chosen
+// by AI, and ranks them again by keystrokes saved. A suggestion keeps its offline name if
+// the new name is an existing alias, another suggestion already has it, or it is not
+// shorter than the command.
+func (am *AliasManager) NameSuggestions(suggestions []AliasSuggestion, names map[int]string) []AliasSuggestion {
+	used := make(map[string]bool, len(suggestions))
+	for _, suggestion := range suggestions {
+		used[suggestion.Name] = true
+	}
+	for i := range suggestions {
+		name, ok := names[i]
+		if !ok || name == "" || name == suggestions[i].Name || used[name] || am.AliasSource(name) != "" {
+			continue
+		}
+		saved := keystrokesSaved(suggestions[i].Count, suggestions[i].Command, name)
+		if saved <= 0 {
+			continue
+		}
+		delete(used, suggestions[i].Name)
+		used[name] = true
+		suggestions[i].Name = name
+		suggestions[i].KeystrokesSaved = saved
+	}
+	return rankSuggestions(suggestions)
+}
+
+// keystrokesSaved returns the characters not typed when a command typed count times is
+// replaced by an alias called name.
+func keystrokesSaved(count int, command, name string) int {
+	return count * (len(command) - len(name))
+}
+
+// rankSuggestions drops the suggestions that save no keystrokes and orders the rest by
+// the keystrokes they save, keeping the order of suggestions that save the same.
+func rankSuggestions(suggestions []AliasSuggestion) []AliasSuggestion {
+	ranked := suggestions[:0]
+	for _, suggestion := range suggestions {
+		if suggestion.KeystrokesSaved > 0 {
+			ranked = append(ranked, suggestion)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].KeystrokesSaved > ranked[j].KeystrokesSaved
+	})
+	return ranked
+}
+
+// isCoveredByAlias reports whether command is, or starts with, an existing alias command.
+func isCoveredByAlias(command string, existing []string) bool {
+	for _, aliased := range existing {
+		if command == aliased || strings.HasPrefix(command, aliased+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestAliasName derives a short alias name from a command without using AI.
+// It takes the initials of the command's words, splitting on dashes and using the
+// letter of short flags (e.g. "kubectl get pods" becomes "kgp"). If the name is
+// taken, more letters of the last word are added, and finally a number.
+func SuggestAliasName(command string, taken func(string) bool) string {
+	var initials []rune
+	var lastWord []rune
+	for _, word := range strings.Fields(command) {
+		word = strings.TrimLeft(word, "-")
+		if slash := strings.LastIndex(word, "/"); slash >= 0 {
+			word = word[slash+1:]
+		}
+		for _, part := range strings.FieldsFunc(word, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			runes := []rune(strings.ToLower(part))
+			if len(runes) > 0 && unicode.IsLetter(runes[0]) {
+				initials = append(initials, runes[0])
+				lastWord = runes
+			}
+		}
+	}
+
+	name := string(initials)
+	if name == "" {
+		name = "a"
+	}
+	used := 1
+	if len(initials) == 1 && len(lastWord) > 1 {
+		name += string(lastWord[1])
+		used = 2
+	}
+	if taken == nil || !taken(name) {
+		return name
+	}
+
+	candidate := name
+	for i := used; i < len(lastWord); i++ {
+		if !unicode.IsLetter(lastWord[i]) {
+			continue
+		}
+		candidate += string(lastWord[i])
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+	for i := 2; ; i++ {
+		if numbered := name + strconv.Itoa(i); !taken(numbered) {
+			return numbered
+		}
+	}
+}
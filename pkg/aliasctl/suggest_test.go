@@ -0,0 +1,94 @@
+package aliasctl
+
+import "testing"
+
+// repeatHistory returns a history in which each command is typed the given number of times.
+func repeatHistory(counts map[string]int) []HistoryEntry {
+	var history []HistoryEntry
+	for command, count := range counts {
+		for range count {
+			history = append(history, HistoryEntry{Command: command})
+		}
+	}
+	return history
+}
+
+func TestSuggestAliasesRanksByKeystrokesSaved(t *testing.T) {
+	am := newTestManager(t)
+	history := repeatHistory(map[string]int{
+		"kubectl get pods --all-namespaces": 3,
+		"git status":                        10,
+		"zz":                                20,
+	})
+
+	suggestions := am.SuggestAliases(history, SuggestOptions{MinCount: 3})
+	if len(suggestions) == 0 {
+		t.Fatal("SuggestAliases returned no suggestions")
+	}
+	for i, suggestion := range suggestions {
+		if suggestion.KeystrokesSaved <= 0 {
+			t.Errorf("suggestion %q saves %d keystrokes, want it left out", suggestion.Command, suggestion.KeystrokesSaved)
+		}
+		if i > 0 && suggestion.KeystrokesSaved > suggestions[i-1].KeystrokesSaved {
+			t.Errorf("suggestion %q ranked below %q but saves more", suggestion.Command, suggestions[i-1].Command)
+		}
+	}
+}
+
+func TestNameSuggestions(t *testing.T) {
+	am := newTestManager(t)
+	am.AddAlias("gs", "git status")
+	suggestions := []AliasSuggestion{
+		{Name: "gl", Command: "git log --oneline", Count: 2},
+		{Name: "dcu", Command: "docker compose up -d", Count: 4},
+		{Name: "ls1", Command: "ls -1", Count: 50},
+		{Name: "kgp", Command: "kubectl get pods", Count: 3},
+	}
+	for i := range suggestions {
+		suggestions[i].KeystrokesSaved = keystrokesSaved(suggestions[i].Count, suggestions[i].Command, suggestions[i].Name)
+	}
+
+	named := am.NameSuggestions(suggestions, map[int]string{
+		0: "glog",              // Accepted
+		1: "glog",              // Already given to the first suggestion
+		2: "list-one-per-line", // Longer than the command
+		3: "gs",                // An existing alias
+	})
+
+	want := map[string]string{
+		"git log --oneline":    "glog",
+		"docker compose up -d": "dcu",
+		"ls -1":                "ls1",
+		"kubectl get pods":     "kgp",
+	}
+	seen := map[string]bool{}
+	for i, suggestion := range named {
+		if suggestion.Name != want[suggestion.Command] {
+			t.Errorf("%q named %q, want %q", suggestion.Command, suggestion.Name, want[suggestion.Command])
+		}
+		if seen[suggestion.Name] {
+			t.Errorf("name %q given to more than one suggestion", suggestion.Name)
+		}
+		seen[suggestion.Name] = true
+		if saved := keystrokesSaved(suggestion.Count, suggestion.Command, suggestion.Name); suggestion.KeystrokesSaved != saved {
+			t.Errorf("%q saves %d keystrokes, want %d", suggestion.Command, suggestion.KeystrokesSaved, saved)
+		}
+		if i > 0 && suggestion.KeystrokesSaved > named[i-1].KeystrokesSaved {
+			t.Errorf("not ranked by keystrokes saved: %q after %q", suggestion.Command, named[i-1].Command)
+		}
+	}
+	if len(named) != len(want) {
+		t.Errorf("NameSuggestions returned %d suggestions, want %d", len(named), len(want))
+	}
+}
+
+func TestNameSuggestionsDropsSuggestionsThatSaveNothing(t *testing.T) {
+	am := newTestManager(t)
+	named := am.NameSuggestions([]AliasSuggestion{
+		{Name: "make", Command: "make", Count: 9, KeystrokesSaved: 0},
+		{Name: "mt", Command: "make test", Count: 2, KeystrokesSaved: 14},
+	}, nil)
+	if len(named) != 1 || named[0].Command != "make test" {
+		t.Errorf("NameSuggestions = %+v, want only 'make test'", named)
+	}
+}
@@ -0,0 +1,702 @@
+package aliasctl
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	syncAliasesFile = "aliases.toml" // The alias store inside the sync repository
+	syncConfigFile  = "config.toml"  // The shared, non-secret configuration inside the sync repository
+	syncRemote      = "origin"       // The name of the sync remote
+	syncBranch      = "main"         // The branch created by SyncInit
+)
+
+// SyncedConfig is the part of the configuration shared through sync: the default AI
+// provider, the fallback chain and the non-secret settings of each provider. API keys and
+// their references, a provider's headers, which often carry credentials, and
+// machine-specific settings such as the shell, the alias file and a provider's proxy and
+// certificates are never synced.
+type SyncedConfig struct {
+	AIProvider      string                    `toml:"ai_provider"`       // The name of the default AI provider
+	AIFallbackChain []string                  `toml:"ai_fallback_chain"` // Providers tried in order until one answers
+	Providers       map[string]SyncedProvider `toml:"providers"`         // The AI providers, by name
+}
+
+// SyncedProvider is the shared part of a ProviderConfig.
+type SyncedProvider struct {
+	Type     string            `toml:"type"`              // The provider type, see AIProviderTypes
+	Endpoint string            `toml:"endpoint"`          // The endpoint URL
+	Model    string            `toml:"model"`             // The model name
+	Timeout  string            `toml:"timeout,omitempty"` // The request timeout, such as "30s"
+	Options  map[string]string `toml:"options,omitempty"` // Settings specific to the provider type
+}
+
+// fields returns the settings of the provider keyed by name, for merging. Options are
+// keyed as "options.<name>".
+func (p SyncedProvider) fields() map[string]string {
+	fields := map[string]string{"type": p.Type, "endpoint": p.Endpoint, "model": p.Model, "timeout": p.Timeout}
+	for name, value := range p.Options {
+		fields["options."+name] = value
+	}
+	return fields
+}
+
+// syncedProviderFromFields returns the provider with the settings returned by fields.
+// Empty options are left out.
+func syncedProviderFromFields(fields map[string]string) SyncedProvider {
+	provider := SyncedProvider{Type: fields["type"], Endpoint: fields["endpoint"], Model: fields["model"], Timeout: fields["timeout"]}
+	for field, value := range fields {
+		if value == "" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(field, "options."); ok {
+			if provider.Options == nil {
+				provider.Options = make(map[string]string)
+			}
+			provider.Options[name] = value
+		}
+	}
+	return provider
+}
+
+// fields returns every synced setting keyed by name, such as "ai_provider" or
+// "providers.openai.model", for comparing configurations.
+func (c SyncedConfig) fields() map[string]string {
+	fields := map[string]string{"ai_provider": c.AIProvider, "ai_fallback_chain": strings.Join(c.AIFallbackChain, ",")}
+	for name, provider := range c.Providers {
+		for field, value := range provider.fields() {
+			fields["providers."+name+"."+field] = value
+		}
+	}
+	return fields
+}
+
+// Equal reports whether two synced configurations have the same settings.
+func (c SyncedConfig) Equal(other SyncedConfig) bool {
+	return maps.Equal(c.fields(), other.fields())
+}
+
+// SyncConflict describes a change made both locally and on the remote that cannot be merged.
+// Field names the conflicting shell command, "description", or a config setting; it is
+// empty when one side removed an alias the other side modified.
+type SyncConflict struct {
+	Name   string `json:"name" yaml:"name" toml:"name"`       // The alias name, or "config" for settings
+	Field  string `json:"field" yaml:"field" toml:"field"`    // The conflicting field
+	Base   string `json:"base" yaml:"base" toml:"base"`       // The value at the last common sync
+	Ours   string `json:"ours" yaml:"ours" toml:"ours"`       // The local value
+	Theirs string `json:"theirs" yaml:"theirs" toml:"theirs"` // The remote value
+}
+
+// SyncConflictError is returned by SyncPull when local and remote changes conflict
+// and no resolution strategy was given. Nothing is changed locally.
+type SyncConflictError struct {
+	Conflicts []SyncConflict // The unresolved conflicts
+}
+
+// Error returns the error message for a SyncConflictError.
+func (e *SyncConflictError) Error() string {
+	names := make([]string, 0, len(e.Conflicts))
+	seen := make(map[string]bool)
+	for _, conflict := range e.Conflicts {
+		if !seen[conflict.Name] {
+			seen[conflict.Name] = true
+			names = append(names, conflict.Name)
+		}
+	}
+	return fmt.Sprintf("%d conflict(s) between local and remote changes: %s", len(e.Conflicts), strings.Join(names, ", "))
+}
+
+// SyncResult reports the outcome of SyncPull.
+type SyncResult struct {
+	UpToDate      bool           // Whether the remote had nothing new
+	Changes       []AliasChange  // The local aliases changed by the merge
+	ConfigChanged bool           // Whether shared settings were updated
+	Conflicts     []SyncConflict // Conflicts resolved with the requested strategy
+}
+
+// SyncStatus describes the state of the sync repository.
+type SyncStatus struct {
+	Initialized  bool   `json:"initialized" yaml:"initialized" toml:"initialized"`                               // Whether sync has been set up
+	Dir          string `json:"dir" yaml:"dir" toml:"dir"`                                                       // The sync repository directory
+	Remote       string `json:"remote,omitempty" yaml:"remote,omitempty" toml:"remote,omitempty"`                // The remote URL
+	Branch       string `json:"branch,omitempty" yaml:"branch,omitempty" toml:"branch,omitempty"`                // The synced branch
+	LastCommit   string `json:"last_commit,omitempty" yaml:"last_commit,omitempty" toml:"last_commit,omitempty"` // The latest sync commit
+	LocalChanges string `json:"local_changes" yaml:"local_changes" toml:"local_changes"`                         // Alias changes not yet committed, e.g. "+gs -ll"
+	Ahead        int    `json:"ahead" yaml:"ahead" toml:"ahead"`                                                 // Sync commits not yet pushed
+	Behind       int    `json:"behind" yaml:"behind" toml:"behind"`                                              // Remote commits not yet pulled
+	FetchError   string `json:"fetch_error,omitempty" yaml:"fetch_error,omitempty" toml:"fetch_error,omitempty"` // Why the remote could not be reached
+}
+
+// SyncDir returns the directory of the git repository used for sync.
+func (am *AliasManager) SyncDir() string {
+	return filepath.Join(am.ConfigDir, "sync")
+}
+
+// syncInitialized reports whether the sync repository exists.
+func (am *AliasManager) syncInitialized() bool {
+	_, err := os.Stat(filepath.Join(am.SyncDir(), ".git"))
+	return err == nil
+}
+
+// git runs the git binary in the sync repository and returns its trimmed standard output.
+// The error includes git's own message so failures such as authentication problems are visible.
+func (am *AliasManager) git(args ...string) (string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", fmt.Errorf("git is required for sync but was not found in PATH")
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = am.SyncDir()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], message)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitCommit commits the staged files. If the user has not configured a git identity,
+// an aliasctl identity is set for the sync repository only.
+func (am *AliasManager) gitCommit(message string) error {
+	if email, _ := am.git("config", "user.email"); email == "" {
+		host, _ := os.Hostname()
+		if _, err := am.git("config", "user.name", "aliasctl"); err != nil {
+			return err
+		}
+		if _, err := am.git("config", "user.email", "aliasctl@"+host); err != nil {
+			return err
+		}
+	}
+	_, err := am.git("commit", "--quiet", "-m", message)
+	return err
+}
+
+// requireSync returns an error if the sync repository has not been set up.
+func (am *AliasManager) requireSync() error {
+	if !am.syncInitialized() {
+		return fmt.Errorf("sync is not set up\n\nRun 'aliasctl sync init <remote-url>' first")
+	}
+	return nil
+}
+
+// currentBranch returns the branch checked out in the sync repository.
+func (am *AliasManager) currentBranch() (string, error) {
+	return am.git("symbolic-ref", "--short", "HEAD")
+}
+
+// SyncInit creates the sync repository in the config directory and commits the current
+// aliases and shared settings. If the repository already exists, only the remote is updated.
+// Run SyncPull afterwards to merge aliases already on the remote.
+func (am *AliasManager) SyncInit(remote string) error {
+	if !am.syncInitialized() {
+		if err := os.MkdirAll(am.SyncDir(), 0755); err != nil {
+			return fmt.Errorf("failed to create sync directory %s: %w (check directory permissions)", am.SyncDir(), err)
+		}
+		if _, err := am.git("init", "--quiet"); err != nil {
+			return err
+		}
+		if _, err := am.git("symbolic-ref", "HEAD", "refs/heads/"+syncBranch); err != nil {
+			return err
+		}
+	}
+
+	if remote != "" {
+		if current, _ := am.git("remote", "get-url", syncRemote); current == "" {
+			if _, err := am.git("remote", "add", syncRemote, remote); err != nil {
+				return err
+			}
+		} else if current != remote {
+			if _, err := am.git("remote", "set-url", syncRemote, remote); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := am.commitSyncState()
+	return err
+}
+
+// commitSyncState writes the current aliases and shared settings into the sync
+// repository and commits them. Returns false if there was nothing to commit.
+func (am *AliasManager) commitSyncState() (bool, error) {
+	config, err := am.loadSyncedConfig()
+	if err != nil {
+		return false, err
+	}
+	if err := am.writeSyncFiles(am.Aliases, config); err != nil {
+		return false, err
+	}
+
+	if _, err := am.git("add", syncAliasesFile, syncConfigFile); err != nil {
+		return false, err
+	}
+	if status, err := am.git("status", "--porcelain"); err != nil || status == "" {
+		return false, err
+	}
+
+	host, _ := os.Hostname()
+	if err := am.gitCommit(fmt.Sprintf("Update aliases from %s", host)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeSyncFiles writes the alias store and shared settings into the sync repository.
+func (am *AliasManager) writeSyncFiles(aliases map[string]AliasCommands, config SyncedConfig) error {
+	for file, data := range map[string]any{syncAliasesFile: aliases, syncConfigFile: config} {
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(data); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file, err)
+		}
+		path := filepath.Join(am.SyncDir(), file)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// loadSyncedConfig reads the shared settings from the configuration file.
+func (am *AliasManager) loadSyncedConfig() (SyncedConfig, error) {
+	var config Config
+	if _, err := toml.DecodeFile(am.ConfigFile, &config); err != nil && !os.IsNotExist(err) {
+		return SyncedConfig{}, fmt.Errorf("failed to read config file %s: %w", am.ConfigFile, err)
+	}
+	synced := SyncedConfig{AIProvider: config.AIProvider, AIFallbackChain: config.AIFallbackChain}
+	for name, provider := range config.providerConfigs() {
+		if synced.Providers == nil {
+			synced.Providers = make(map[string]SyncedProvider)
+		}
+		synced.Providers[name] = SyncedProvider{
+			Type:     provider.Type,
+			Endpoint: provider.Endpoint,
+			Model:    provider.Model,
+			Timeout:  provider.Timeout,
+			Options:  provider.Options,
+		}
+	}
+	return synced, nil
+}
+
+// saveSyncedConfig writes the shared settings into the configuration file and reloads it.
+// Providers missing from synced are removed. The API keys, headers and machine-specific
+// settings of the remaining providers, and every other setting, are left untouched; providers
+// still in the flat fields of earlier versions are moved to provider tables.
+func (am *AliasManager) saveSyncedConfig(synced SyncedConfig) error {
+	var config Config
+	if _, err := toml.DecodeFile(am.ConfigFile, &config); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file %s: %w", am.ConfigFile, err)
+	}
+	local := config.providerConfigs()
+	config.AIProvider = synced.AIProvider
+	config.AIFallbackChain = synced.AIFallbackChain
+	config.Providers = make(map[string]ProviderConfig, len(synced.Providers))
+	for name, shared := range synced.Providers {
+		provider := local[name]
+		provider.Type = shared.Type
+		provider.Endpoint = shared.Endpoint
+		provider.Model = shared.Model
+		provider.Timeout = shared.Timeout
+		provider.Options = shared.Options
+		config.Providers[name] = provider
+	}
+	config.OllamaEndpoint, config.OllamaModel, config.OllamaTimeout = "", "", ""
+	config.OpenAIEndpoint, config.OpenAIKey, config.OpenAIKeyEncrypted, config.OpenAIModel, config.OpenAITimeout = "", "", "", "", ""
+	config.AnthropicEndpoint, config.AnthropicKey, config.AnthropicKeyEncrypted, config.AnthropicModel, config.AnthropicTimeout = "", "", "", "", ""
+
+	file, err := os.Create(am.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to create config file at %s: %w", am.ConfigFile, err)
+	}
+	defer file.Close()
+	if err := toml.NewEncoder(file).Encode(config); err != nil {
+		return fmt.Errorf("failed to write TOML configuration: %w", err)
+	}
+	return am.LoadConfig()
+}
+
+// readSyncRevision reads the aliases and shared settings stored at a git revision.
+// Files missing from the revision are treated as empty.
+func (am *AliasManager) readSyncRevision(rev string) (map[string]AliasCommands, SyncedConfig, error) {
+	aliases := make(map[string]AliasCommands)
+	var config SyncedConfig
+	if rev == "" {
+		return aliases, config, nil
+	}
+
+	if data, err := am.git("show", rev+":"+syncAliasesFile); err == nil {
+		if _, err := toml.Decode(data, &aliases); err != nil {
+			return nil, config, fmt.Errorf("failed to parse %s at %s: %w", syncAliasesFile, rev, err)
+		}
+	}
+	if data, err := am.git("show", rev+":"+syncConfigFile); err == nil {
+		if _, err := toml.Decode(data, &config); err != nil {
+			return nil, config, fmt.Errorf("failed to parse %s at %s: %w", syncConfigFile, rev, err)
+		}
+	}
+	return aliases, config, nil
+}
+
+// SyncPush commits the current aliases and shared settings and pushes them to the remote.
+// Returns an error asking for a pull first if the remote has changes not merged locally.
+func (am *AliasManager) SyncPush() error {
+	if err := am.requireSync(); err != nil {
+		return err
+	}
+	if _, err := am.commitSyncState(); err != nil {
+		return err
+	}
+
+	branch, err := am.currentBranch()
+	if err != nil {
+		return err
+	}
+	if _, err := am.git("push", "--quiet", "--set-upstream", syncRemote, branch); err != nil {
+		if strings.Contains(err.Error(), "rejected") || strings.Contains(err.Error(), "fetch first") {
+			return fmt.Errorf("the remote has changes you don't have yet\n\nRun 'aliasctl sync pull' first, then push again")
+		}
+		return err
+	}
+	return nil
+}
+
+// SyncPull fetches the remote and merges its aliases and shared settings into the local ones
+// using a three-way merge per alias, and per shell command when both sides changed the same
+// alias. strategy resolves conflicts: "ours" keeps the local value and "theirs" the remote
+// value. With an empty strategy, conflicts abort the pull with a *SyncConflictError and
+// nothing is changed.
+func (am *AliasManager) SyncPull(strategy string) (*SyncResult, error) {
+	if strategy != "" && strategy != "ours" && strategy != "theirs" {
+		return nil, fmt.Errorf("invalid conflict strategy: %s (use ours or theirs)", strategy)
+	}
+	if err := am.requireSync(); err != nil {
+		return nil, err
+	}
+	if _, err := am.commitSyncState(); err != nil {
+		return nil, err
+	}
+
+	branch, err := am.currentBranch()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := am.git("fetch", "--quiet", syncRemote); err != nil {
+		return nil, err
+	}
+
+	theirsRev := syncRemote + "/" + branch
+	theirsHash, err := am.git("rev-parse", "--verify", "--quiet", theirsRev)
+	if err != nil || theirsHash == "" {
+		// Nothing has been pushed to the remote yet
+		return &SyncResult{UpToDate: true}, nil
+	}
+	oursHash, err := am.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	baseHash, _ := am.git("merge-base", "HEAD", theirsRev)
+	if baseHash == theirsHash {
+		return &SyncResult{UpToDate: true}, nil
+	}
+
+	baseAliases, baseConfig, err := am.readSyncRevision(baseHash)
+	if err != nil {
+		return nil, err
+	}
+	ourAliases, ourConfig, err := am.readSyncRevision(oursHash)
+	if err != nil {
+		return nil, err
+	}
+	theirAliases, theirConfig, err := am.readSyncRevision(theirsHash)
+	if err != nil {
+		return nil, err
+	}
+
+	prefer := strategy
+	if prefer == "" {
+		prefer = "ours"
+	}
+	merged, conflicts := MergeAliases(baseAliases, ourAliases, theirAliases, prefer)
+	mergedConfig, configConflicts := mergeSyncedConfig(baseConfig, ourConfig, theirConfig, prefer)
+	conflicts = append(conflicts, configConflicts...)
+	if len(conflicts) > 0 && strategy == "" {
+		return nil, &SyncConflictError{Conflicts: conflicts}
+	}
+
+	if baseHash == oursHash {
+		if _, err := am.git("merge", "--quiet", "--ff-only", theirsRev); err != nil {
+			return nil, err
+		}
+	} else {
+		// Record the merge with git, but take the content from the semantic merge
+		if _, err := am.git("merge", "--quiet", "--no-ff", "--no-commit", "--allow-unrelated-histories", "-s", "ours", theirsRev); err != nil {
+			return nil, err
+		}
+		if err := am.writeSyncFiles(merged, mergedConfig); err != nil {
+			return nil, err
+		}
+		if _, err := am.git("add", syncAliasesFile, syncConfigFile); err != nil {
+			return nil, err
+		}
+		if err := am.gitCommit(fmt.Sprintf("Merge aliases from %s", theirsRev)); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &SyncResult{Changes: diffAliases(am.Aliases, merged), Conflicts: conflicts}
+	if len(result.Changes) > 0 {
+		am.Aliases = merged
+		am.SetOperation(OpSync, fmt.Sprintf("sync pull from %s", theirsRev))
+		if err := am.SaveAliases(); err != nil {
+			return nil, err
+		}
+	}
+	if !mergedConfig.Equal(ourConfig) {
+		result.ConfigChanged = true
+		if err := am.saveSyncedConfig(mergedConfig); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// SyncStatus reports the sync repository's remote, unsynced local changes and how far the
+// local and remote history have diverged. The remote is fetched first; if that fails the
+// error is reported in FetchError and the counts reflect the last fetch.
+func (am *AliasManager) SyncStatus() (SyncStatus, error) {
+	status := SyncStatus{Dir: am.SyncDir(), Initialized: am.syncInitialized()}
+	if !status.Initialized {
+		return status, nil
+	}
+
+	status.Remote, _ = am.git("remote", "get-url", syncRemote)
+	branch, err := am.currentBranch()
+	if err != nil {
+		return status, err
+	}
+	status.Branch = branch
+	status.LastCommit, _ = am.git("log", "-1", "--format=%h %s (%cr)")
+
+	committed, _, err := am.readSyncRevision("HEAD")
+	if err != nil {
+		return status, err
+	}
+	status.LocalChanges = summarizeChanges(diffAliases(committed, am.Aliases))
+
+	if status.Remote == "" {
+		return status, nil
+	}
+	if _, err := am.git("fetch", "--quiet", syncRemote); err != nil {
+		status.FetchError = err.Error()
+	}
+	if counts, err := am.git("rev-list", "--left-right", "--count", "HEAD..."+syncRemote+"/"+branch); err == nil {
+		if fields := strings.Fields(counts); len(fields) == 2 {
+			status.Ahead, _ = strconv.Atoi(fields[0])
+			status.Behind, _ = strconv.Atoi(fields[1])
+		}
+	}
+	return status, nil
+}
+
+// aliasFields returns the mergeable fields of an alias keyed by name.
+func aliasFields(c *AliasCommands) map[string]*string {
+	fields := map[string]*string{"description": &c.Description, "ai_generated": &c.AIGenerated}
+	for _, shell := range SupportedShells {
+		switch shell {
+		case ShellBash:
+			fields[string(shell)] = &c.Bash
+		case ShellZsh:
+			fields[string(shell)] = &c.Zsh
+		case ShellFish:
+			fields[string(shell)] = &c.Fish
+		case ShellKsh:
+			fields[string(shell)] = &c.Ksh
+		case ShellPowerShell:
+			fields[string(shell)] = &c.PowerShell
+		case ShellPowerShellCore:
+			fields[string(shell)] = &c.PowerShellCore
+		case ShellCmd:
+			fields[string(shell)] = &c.Cmd
+		}
+	}
+	return fields
+}
+
+// mergeValue merges one value changed on either side since base.
+// Returns the merged value and whether both sides changed it differently,
+// in which case the value preferred by the strategy is returned.
+func mergeValue(base, ours, theirs, prefer string) (string, bool) {
+	switch {
+	case ours == theirs || theirs == base:
+		return ours, false
+	case ours == base:
+		return theirs, false
+	case prefer == "theirs":
+		return theirs, true
+	default:
+		return ours, true
+	}
+}
+
+// MergeAliases performs a three-way merge of two alias sets that diverged from base.
+// Aliases changed on one side only take that side's value. When both sides changed the
+// same alias, each shell command and the description are merged separately, and only
+// values changed differently on both sides conflict. Conflicts are resolved in favour of
+// prefer ("ours" or "theirs") and reported, sorted by alias name.
+func MergeAliases(base, ours, theirs map[string]AliasCommands, prefer string) (map[string]AliasCommands, []SyncConflict) {
+	names := make(map[string]bool)
+	for _, aliases := range []map[string]AliasCommands{base, ours, theirs} {
+		for name := range aliases {
+			names[name] = true
+		}
+	}
+
+	merged := make(map[string]AliasCommands)
+	var conflicts []SyncConflict
+	for name := range names {
+		baseCommands, inBase := base[name]
+		ourCommands, inOurs := ours[name]
+		theirCommands, inTheirs := theirs[name]
+
+		switch {
+		case inOurs == inTheirs && ourCommands == theirCommands,
+			inTheirs == inBase && theirCommands == baseCommands:
+			if inOurs {
+				merged[name] = ourCommands
+			}
+		case inOurs == inBase && ourCommands == baseCommands:
+			if inTheirs {
+				merged[name] = theirCommands
+			}
+		case !inOurs || !inTheirs:
+			// One side removed the alias while the other changed it
+			conflict := SyncConflict{Name: name, Ours: "(removed)", Theirs: "(removed)"}
+			if inOurs {
+				conflict.Ours = "(modified)"
+			}
+			if inTheirs {
+				conflict.Theirs = "(modified)"
+			}
+			conflicts = append(conflicts, conflict)
+			if prefer == "theirs" && inTheirs {
+				merged[name] = theirCommands
+			} else if prefer != "theirs" && inOurs {
+				merged[name] = ourCommands
+			}
+		default:
+			var result AliasCommands
+			baseFields, ourFields, theirFields := aliasFields(&baseCommands), aliasFields(&ourCommands), aliasFields(&theirCommands)
+			for field, target := range aliasFields(&result) {
+				value, conflicted := mergeValue(*baseFields[field], *ourFields[field], *theirFields[field], prefer)
+				*target = value
+				if conflicted {
+					conflicts = append(conflicts, SyncConflict{Name: name, Field: field, Base: *baseFields[field], Ours: *ourFields[field], Theirs: *theirFields[field]})
+				}
+			}
+			merged[name] = result
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Name != conflicts[j].Name {
+			return conflicts[i].Name < conflicts[j].Name
+		}
+		return conflicts[i].Field < conflicts[j].Field
+	})
+	return merged, conflicts
+}
+
+// mergeSyncedConfig performs a three-way merge of the shared settings, setting by setting.
+// Providers are merged like aliases in MergeAliases: a provider changed on one side only
+// takes that side's settings, a provider changed on both sides is merged setting by
+// setting, and a provider removed on one side and changed on the other is a conflict.
+// The fallback chain is merged as a single setting.
+func mergeSyncedConfig(base, ours, theirs SyncedConfig, prefer string) (SyncedConfig, []SyncConflict) {
+	var conflicts []SyncConflict
+	mergeSetting := func(field, base, ours, theirs string) string {
+		value, conflicted := mergeValue(base, ours, theirs, prefer)
+		if conflicted {
+			conflicts = append(conflicts, SyncConflict{Name: "config", Field: field, Base: base, Ours: ours, Theirs: theirs})
+		}
+		return value
+	}
+
+	merged := SyncedConfig{AIProvider: mergeSetting("ai_provider", base.AIProvider, ours.AIProvider, theirs.AIProvider)}
+	chain := mergeSetting("ai_fallback_chain", strings.Join(base.AIFallbackChain, ","), strings.Join(ours.AIFallbackChain, ","), strings.Join(theirs.AIFallbackChain, ","))
+	if chain != "" {
+		merged.AIFallbackChain = strings.Split(chain, ",")
+	}
+
+	names := make(map[string]bool)
+	for _, config := range []SyncedConfig{base, ours, theirs} {
+		for name := range config.Providers {
+			names[name] = true
+		}
+	}
+	for name := range names {
+		baseProvider, inBase := base.Providers[name]
+		ourProvider, inOurs := ours.Providers[name]
+		theirProvider, inTheirs := theirs.Providers[name]
+		baseFields, ourFields, theirFields := baseProvider.fields(), ourProvider.fields(), theirProvider.fields()
+
+		var provider SyncedProvider
+		keep := true
+		switch {
+		case inOurs == inTheirs && maps.Equal(ourFields, theirFields),
+			inTheirs == inBase && maps.Equal(theirFields, baseFields):
+			provider, keep = ourProvider, inOurs
+		case inOurs == inBase && maps.Equal(ourFields, baseFields):
+			provider, keep = theirProvider, inTheirs
+		case !inOurs || !inTheirs:
+			// One side removed the provider while the other changed it
+			conflict := SyncConflict{Name: "config", Field: "providers." + name, Ours: "(removed)", Theirs: "(removed)"}
+			if inOurs {
+				conflict.Ours = "(modified)"
+			}
+			if inTheirs {
+				conflict.Theirs = "(modified)"
+			}
+			conflicts = append(conflicts, conflict)
+			if prefer == "theirs" {
+				provider, keep = theirProvider, inTheirs
+			} else {
+				provider, keep = ourProvider, inOurs
+			}
+		default:
+			fields := make(map[string]string)
+			for _, side := range []map[string]string{baseFields, ourFields, theirFields} {
+				for field := range side {
+					fields[field] = ""
+				}
+			}
+			for field := range fields {
+				fields[field] = mergeSetting("providers."+name+"."+field, baseFields[field], ourFields[field], theirFields[field])
+			}
+			provider = syncedProviderFromFields(fields)
+		}
+		if keep {
+			if merged.Providers == nil {
+				merged.Providers = make(map[string]SyncedProvider)
+			}
+			merged.Providers[name] = provider
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Field < conflicts[j].Field })
+	return merged, conflicts
+}
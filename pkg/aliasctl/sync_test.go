@@ -0,0 +1,363 @@
+package aliasctl
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+// newSyncPair returns two managers, standing in for two machines, that sync through the
+// same bare repository. The first has pushed gs before the second pulled it.
+func newSyncPair(t *testing.T) (*AliasManager, *AliasManager) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "aliases.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+
+	laptop := newTestManager(t)
+	laptop.AddAlias("gs", "git status")
+	mustSave(t, laptop)
+	if err := laptop.SyncInit(remote); err != nil {
+		t.Fatalf("SyncInit: %v", err)
+	}
+	if err := laptop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+
+	desktop := newTestManager(t)
+	if err := desktop.SyncInit(remote); err != nil {
+		t.Fatalf("SyncInit: %v", err)
+	}
+	if _, err := desktop.SyncPull(""); err != nil {
+		t.Fatalf("SyncPull: %v", err)
+	}
+	return laptop, desktop
+}
+
+func mustSave(t *testing.T, am *AliasManager) {
+	t.Helper()
+	if err := am.SaveAliases(); err != nil {
+		t.Fatalf("SaveAliases: %v", err)
+	}
+}
+
+func TestSyncPushPull(t *testing.T) {
+	laptop, desktop := newSyncPair(t)
+	if got := desktop.Aliases["gs"].Bash; got != "git status" {
+		t.Fatalf("desktop gs = %q after the first pull, want %q", got, "git status")
+	}
+
+	desktop.AddAlias("ll", "ls -l")
+	mustSave(t, desktop)
+	if err := desktop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+
+	result, err := laptop.SyncPull("")
+	if err != nil {
+		t.Fatalf("SyncPull: %v", err)
+	}
+	if got := laptop.Aliases["ll"].Bash; got != "ls -l" {
+		t.Errorf("laptop ll = %q, want %q", got, "ls -l")
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Name != "ll" {
+		t.Errorf("changes = %+v, want only ll", result.Changes)
+	}
+
+	result, err = laptop.SyncPull("")
+	if err != nil {
+		t.Fatalf("SyncPull: %v", err)
+	}
+	if !result.UpToDate {
+		t.Errorf("second pull not up to date: %+v", result)
+	}
+}
+
+func TestSyncPullConflictingEdits(t *testing.T) {
+	laptop, desktop := newSyncPair(t)
+
+	laptop.AddAlias("gs", "git status -sb")
+	mustSave(t, laptop)
+	if err := laptop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+	desktop.AddAlias("gs", "git status --short")
+	mustSave(t, desktop)
+
+	if err := desktop.SyncPush(); err == nil {
+		t.Fatal("SyncPush succeeded without pulling the remote changes first")
+	}
+
+	_, err := desktop.SyncPull("")
+	var conflictErr *SyncConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("SyncPull error = %v, want a *SyncConflictError", err)
+	}
+	if len(conflictErr.Conflicts) != 1 || conflictErr.Conflicts[0].Field != string(ShellBash) {
+		t.Fatalf("conflicts = %+v, want one on the bash command of gs", conflictErr.Conflicts)
+	}
+	if got := desktop.Aliases["gs"].Bash; got != "git status --short" {
+		t.Fatalf("desktop gs = %q after an aborted pull, want it unchanged", got)
+	}
+
+	result, err := desktop.SyncPull("theirs")
+	if err != nil {
+		t.Fatalf("SyncPull(theirs): %v", err)
+	}
+	if got := desktop.Aliases["gs"].Bash; got != "git status -sb" {
+		t.Errorf("desktop gs = %q, want the remote %q", got, "git status -sb")
+	}
+	if len(result.Conflicts) != 1 {
+		t.Errorf("resolved conflicts = %+v, want one", result.Conflicts)
+	}
+
+	if err := desktop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush after the merge: %v", err)
+	}
+	if _, err := laptop.SyncPull(""); err != nil {
+		t.Fatalf("laptop SyncPull after the merge: %v", err)
+	}
+	if got := laptop.Aliases["gs"].Bash; got != "git status -sb" {
+		t.Errorf("laptop gs = %q, want %q", got, "git status -sb")
+	}
+}
+
+func TestSyncPullDeleteAndEdit(t *testing.T) {
+	laptop, desktop := newSyncPair(t)
+
+	laptop.RemoveAlias("gs")
+	mustSave(t, laptop)
+	if err := laptop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+	desktop.AddAlias("gs", "git status -sb")
+	mustSave(t, desktop)
+
+	_, err := desktop.SyncPull("")
+	var conflictErr *SyncConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("SyncPull error = %v, want a *SyncConflictError", err)
+	}
+	if len(conflictErr.Conflicts) != 1 {
+		t.Fatalf("conflicts = %+v, want one", conflictErr.Conflicts)
+	}
+	if conflict := conflictErr.Conflicts[0]; conflict.Field != "" || conflict.Ours != "(modified)" || conflict.Theirs != "(removed)" {
+		t.Fatalf("conflicts = %+v, want gs modified here and removed on the remote", conflictErr.Conflicts)
+	}
+
+	if _, err := desktop.SyncPull("ours"); err != nil {
+		t.Fatalf("SyncPull(ours): %v", err)
+	}
+	if got := desktop.Aliases["gs"].Bash; got != "git status -sb" {
+		t.Errorf("desktop gs = %q, want the local edit kept", got)
+	}
+
+	// The other way round, the remote deletion wins
+	laptop2, desktop2 := newSyncPair(t)
+	laptop2.RemoveAlias("gs")
+	mustSave(t, laptop2)
+	if err := laptop2.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+	desktop2.AddAlias("gs", "git status -sb")
+	mustSave(t, desktop2)
+	if _, err := desktop2.SyncPull("theirs"); err != nil {
+		t.Fatalf("SyncPull(theirs): %v", err)
+	}
+	if _, exists := desktop2.Aliases["gs"]; exists {
+		t.Error("desktop gs kept, want the remote deletion applied")
+	}
+}
+
+// readConfig returns the configuration file of am.
+func readConfig(t *testing.T, am *AliasManager) Config {
+	t.Helper()
+	var config Config
+	if _, err := toml.DecodeFile(am.ConfigFile, &config); err != nil {
+		t.Fatalf("reading config: %v", err)
+	}
+	return config
+}
+
+func TestSyncConfigProviders(t *testing.T) {
+	laptop, desktop := newSyncPair(t)
+
+	// The desktop still has OpenAI in the flat fields of the baseline config format, next
+	// to a gateway whose headers carry credentials
+	local := `DefaultShell = "bash"
+DefaultAliasFile = "` + desktop.AliasFile + `"
+ai_provider = "openai"
+OpenAIEndpoint = "https://api.openai.com/v1"
+OpenAIModel = "gpt-4o-mini"
+OpenAIKey = "sk-desktop"
+
+[providers.gateway]
+type = "openai"
+endpoint = "https://llm.example.com"
+model = "gpt-4o"
+api_key_ref = "env:GATEWAY_KEY"
+proxy = "http://proxy.desktop.lan:3128"
+
+[providers.gateway.headers]
+Authorization = "Bearer sk-header-secret"
+x-api-key = "sk-gateway-secret"
+`
+	if err := os.WriteFile(desktop.ConfigFile, []byte(local), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := desktop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+	if _, err := laptop.SyncPull(""); err != nil {
+		t.Fatalf("SyncPull: %v", err)
+	}
+	config := readConfig(t, laptop)
+	if provider := config.Providers["openai"]; provider.Type != "openai" || provider.Model != "gpt-4o-mini" || provider.APIKey != "" {
+		t.Errorf("laptop openai = %+v, want the model without the key", provider)
+	}
+	if provider := config.Providers["gateway"]; provider.Endpoint != "https://llm.example.com" || provider.APIKeyRef != "" || provider.Proxy != "" || len(provider.Headers) != 0 {
+		t.Errorf("laptop gateway = %+v, want the endpoint without the key ref, headers or proxy", provider)
+	}
+
+	// The laptop changes the model, adds a provider and makes it the default
+	config.AIProvider = "ollama"
+	config.AIFallbackChain = []string{"ollama", "openai"}
+	openai := config.Providers["openai"]
+	openai.Model = "gpt-4o"
+	config.Providers["openai"] = openai
+	config.Providers["ollama"] = ProviderConfig{Type: "ollama", Endpoint: "http://localhost:11434", Model: "llama3.2"}
+	var buf strings.Builder
+	if err := toml.NewEncoder(&buf).Encode(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(laptop.ConfigFile, []byte(buf.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := laptop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+
+	result, err := desktop.SyncPull("")
+	if err != nil {
+		t.Fatalf("SyncPull: %v", err)
+	}
+	if !result.ConfigChanged {
+		t.Error("ConfigChanged = false, want true")
+	}
+	config = readConfig(t, desktop)
+	if config.AIProvider != "ollama" || strings.Join(config.AIFallbackChain, ",") != "ollama,openai" {
+		t.Errorf("ai_provider = %q, fallback = %v; want the laptop's", config.AIProvider, config.AIFallbackChain)
+	}
+	if config.OpenAIEndpoint != "" || config.OpenAIModel != "" || config.OpenAIKey != "" {
+		t.Errorf("config still has flat provider fields: %+v", config)
+	}
+	if openai := config.Providers["openai"]; openai.Type != "openai" || openai.Model != "gpt-4o" || openai.APIKey != "sk-desktop" {
+		t.Errorf("desktop openai = %+v, want the new model in a provider table with the local key", openai)
+	}
+	gateway := config.Providers["gateway"]
+	if gateway.APIKeyRef != "env:GATEWAY_KEY" || gateway.Proxy != "http://proxy.desktop.lan:3128" || gateway.Headers["Authorization"] != "Bearer sk-header-secret" {
+		t.Errorf("desktop gateway = %+v, want the local key ref, headers and proxy kept", gateway)
+	}
+	if ollama := config.Providers["ollama"]; ollama.Type != "ollama" || ollama.Model != "llama3.2" {
+		t.Errorf("desktop ollama = %+v, want the laptop's provider", ollama)
+	}
+
+	// Pushing again round-trips the same shared settings, without secrets
+	if err := desktop.SyncPush(); err != nil {
+		t.Fatalf("SyncPush: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(desktop.SyncDir(), syncConfigFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"api_key", "proxy", "headers", "OpenAI"} {
+		if strings.Contains(string(data), field) {
+			t.Errorf("synced config contains %q:\n%s", field, data)
+		}
+	}
+	var synced SyncedConfig
+	if _, err := toml.Decode(string(data), &synced); err != nil {
+		t.Fatalf("decoding synced config: %v", err)
+	}
+	if want, _ := laptop.loadSyncedConfig(); !synced.Equal(want) {
+		t.Errorf("synced config = %+v, want the laptop's %+v", synced, want)
+	}
+
+	// No credential has ever left either machine
+	for _, am := range []*AliasManager{desktop, laptop} {
+		history, err := am.git("log", "--all", "-p")
+		if err != nil {
+			t.Fatalf("git log: %v", err)
+		}
+		for _, secret := range []string{"sk-desktop", "GATEWAY_KEY", "sk-header-secret", "sk-gateway-secret", "Authorization", "proxy.desktop.lan"} {
+			if strings.Contains(history, secret) {
+				t.Errorf("sync repository history contains %q", secret)
+			}
+		}
+	}
+}
+
+func TestMergeSyncedConfig(t *testing.T) {
+	openai := SyncedProvider{Type: "openai", Endpoint: "https://api.openai.com/v1", Model: "gpt-4o-mini"}
+	withModel := func(p SyncedProvider, model string) SyncedProvider {
+		p.Model = model
+		return p
+	}
+	config := func(providers map[string]SyncedProvider) SyncedConfig {
+		return SyncedConfig{AIProvider: "openai", Providers: providers}
+	}
+	base := config(map[string]SyncedProvider{"openai": openai})
+
+	tests := []struct {
+		name          string
+		ours, theirs  SyncedConfig
+		want          SyncedConfig
+		wantConflicts []SyncConflict
+	}{
+		{
+			name:   "option added on one side, model changed on the other",
+			ours:   config(map[string]SyncedProvider{"openai": {Type: "openai", Endpoint: openai.Endpoint, Model: openai.Model, Options: map[string]string{"api_version": "2024-10-21"}}}),
+			theirs: config(map[string]SyncedProvider{"openai": withModel(openai, "gpt-4o")}),
+			want:   config(map[string]SyncedProvider{"openai": {Type: "openai", Endpoint: openai.Endpoint, Model: "gpt-4o", Options: map[string]string{"api_version": "2024-10-21"}}}),
+		},
+		{
+			name:          "model changed on both sides",
+			ours:          config(map[string]SyncedProvider{"openai": withModel(openai, "gpt-4.1")}),
+			theirs:        config(map[string]SyncedProvider{"openai": withModel(openai, "gpt-4o")}),
+			want:          config(map[string]SyncedProvider{"openai": withModel(openai, "gpt-4.1")}),
+			wantConflicts: []SyncConflict{{Name: "config", Field: "providers.openai.model", Base: "gpt-4o-mini", Ours: "gpt-4.1", Theirs: "gpt-4o"}},
+		},
+		{
+			name:          "removed here, changed there",
+			ours:          config(nil),
+			theirs:        config(map[string]SyncedProvider{"openai": withModel(openai, "gpt-4o")}),
+			want:          config(nil),
+			wantConflicts: []SyncConflict{{Name: "config", Field: "providers.openai", Ours: "(removed)", Theirs: "(modified)"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := mergeSyncedConfig(base, tt.ours, tt.theirs, "ours")
+			if !got.Equal(tt.want) {
+				t.Errorf("merged = %+v, want %+v", got, tt.want)
+			}
+			if len(conflicts) != len(tt.wantConflicts) {
+				t.Fatalf("conflicts = %+v, want %+v", conflicts, tt.wantConflicts)
+			}
+			for i := range conflicts {
+				if conflicts[i] != tt.wantConflicts[i] {
+					t.Errorf("conflict %d = %+v, want %+v", i, conflicts[i], tt.wantConflicts[i])
+				}
+			}
+		})
+	}
+}
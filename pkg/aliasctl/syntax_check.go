@@ -0,0 +1,130 @@
+package aliasctl
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// powerShellParseScript parses the script read from standard input without executing it.
+// Parse errors are written to standard output and reported through a non-zero exit code.
+const powerShellParseScript = `$errors = $null; ` +
+	`[System.Management.Automation.Language.Parser]::ParseInput([Console]::In.ReadToEnd(), [ref]$null, [ref]$errors) | Out-Null; ` +
+	`if ($errors.Count -gt 0) { $errors | ForEach-Object { "line $($_.Extent.StartLineNumber): $($_.Message)" }; exit 1 }`
+
+// syntaxChecker describes how to run a shell's parser without executing the script.
+type syntaxChecker struct {
+	binaries []string // Candidate executables, tried in order
+	args     []string // Arguments that make the shell parse standard input only
+}
+
+// syntaxCheckers maps each shell type to its parse-only invocation.
+// CMD has no parse-only mode, so it is intentionally absent.
+var syntaxCheckers = map[ShellType]syntaxChecker{
+	ShellBash:           {binaries: []string{"bash"}, args: []string{"-n"}},
+	ShellZsh:            {binaries: []string{"zsh"}, args: []string{"-n"}},
+	ShellKsh:            {binaries: []string{"ksh", "mksh"}, args: []string{"-n"}},
+	ShellFish:           {binaries: []string{"fish"}, args: []string{"--no-execute"}},
+	ShellPowerShell:     {binaries: []string{"powershell", "pwsh"}, args: []string{"-NoProfile", "-NonInteractive", "-Command", powerShellParseScript}},
+	ShellPowerShellCore: {binaries: []string{"pwsh"}, args: []string{"-NoProfile", "-NonInteractive", "-Command", powerShellParseScript}},
+}
+
+// SyntaxCheckUnavailableError is returned when a shell's syntax cannot be verified,
+// either because the shell is not installed or because it has no parse-only mode.
+type SyntaxCheckUnavailableError struct {
+	Shell  ShellType // The shell whose syntax could not be checked
+	Reason string    // Why verification is unavailable
+}
+
+// Error returns the error message for a SyntaxCheckUnavailableError.
+func (e *SyntaxCheckUnavailableError) Error() string {
+	return fmt.Sprintf("cannot verify %s syntax: %s", e.Shell, e.Reason)
+}
+
+// AliasSyntaxError is returned when the shell's parser rejects the rendered aliases.
+// It lists the aliases whose definitions fail to parse on their own, along with
+// the parser output.
+type AliasSyntaxError struct {
+	Shell   ShellType // The shell whose parser rejected the aliases
+	Aliases []string  // The aliases whose definitions failed to parse
+	Output  string    // The diagnostic output of the parser
+}
+
+// Error returns the error message for an AliasSyntaxError.
+// It names the offending aliases when they could be identified.
+func (e *AliasSyntaxError) Error() string {
+	msg := fmt.Sprintf("%s rejected the generated aliases", e.Shell)
+	if len(e.Aliases) > 0 {
+		msg = fmt.Sprintf("%s rejected the definition of %s", e.Shell, strings.Join(e.Aliases, ", "))
+	}
+	if e.Output != "" {
+		msg += "\n\n" + e.Output
+	}
+	return msg
+}
+
+// VerifyAliases checks the alias block for the current shell with the shell's own parser.
+// Nothing is written to disk. If the block fails to parse, each alias is checked on its
+// own so the offending definitions can be reported.
+// Returns a SyntaxCheckUnavailableError if the shell cannot be invoked, or an
+// AliasSyntaxError if the parser rejects the block.
+func (am *AliasManager) VerifyAliases() error {
+	block := am.renderAliasBlock(am.Shell)
+	output, err := CheckShellSyntax(am.Shell, block)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*SyntaxCheckUnavailableError); ok {
+		return err
+	}
+
+	syntaxErr := &AliasSyntaxError{Shell: am.Shell, Output: output}
+	aliases := am.EffectiveAliases()
+	for _, name := range sortedNames(aliases) {
+		command := aliases[name].ForShell(am.Shell)
+		if command == "" {
+			continue
+		}
+		if _, err := CheckShellSyntax(am.Shell, formatAliasDefinition(am.Shell, name, command)); err != nil {
+			syntaxErr.Aliases = append(syntaxErr.Aliases, name)
+		}
+	}
+	return syntaxErr
+}
+
+// CheckShellSyntax runs the parser of the given shell over script without executing it.
+// It returns the combined parser output and an error if the script does not parse.
+// A SyntaxCheckUnavailableError is returned if no suitable shell binary is installed.
+func CheckShellSyntax(shell ShellType, script string) (string, error) {
+	checker, ok := syntaxCheckers[shell]
+	if !ok {
+		return "", &SyntaxCheckUnavailableError{Shell: shell, Reason: "the shell has no parse-only mode"}
+	}
+
+	binary := ""
+	for _, candidate := range checker.binaries {
+		if path, err := exec.LookPath(candidate); err == nil {
+			binary = path
+			break
+		}
+	}
+	if binary == "" {
+		return "", &SyntaxCheckUnavailableError{Shell: shell, Reason: fmt.Sprintf("%s is not installed", checker.binaries[0])}
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(binary, checker.args...)
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return strings.TrimSpace(output.String()), fmt.Errorf("%s syntax check failed", shell)
+		}
+		return "", &SyntaxCheckUnavailableError{Shell: shell, Reason: err.Error()}
+	}
+
+	return strings.TrimSpace(output.String()), nil
+}
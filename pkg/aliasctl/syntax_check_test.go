@@ -0,0 +1,84 @@
+package aliasctl
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheckShellSyntax(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{name: "aliases", script: "alias gs='git status'\nalias ll='ls -la'\n"},
+		{name: "function", script: "gco() { git checkout \"$@\"; }\n"},
+		{name: "unterminated quote", script: "alias gs='git status\n", wantErr: true},
+		{name: "unbalanced braces", script: "gco() { git checkout\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := CheckShellSyntax(ShellBash, tt.script)
+			if tt.wantErr {
+				var unavailable *SyntaxCheckUnavailableError
+				if err == nil || errors.As(err, &unavailable) || output == "" {
+					t.Errorf("CheckShellSyntax = %q, %v; want a syntax error with the parser output", output, err)
+				}
+			} else if err != nil {
+				t.Errorf("CheckShellSyntax = %q, %v; want no error", output, err)
+			}
+		})
+	}
+
+	// The script is parsed, never run
+	marker := filepath.Join(t.TempDir(), "ran")
+	if _, err := CheckShellSyntax(ShellBash, "touch "+marker+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Error("CheckShellSyntax ran the script")
+	}
+}
+
+func TestVerifyAliases(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	am := newTestManager(t)
+	am.AddAlias("gs", "git status")
+	if err := am.VerifyAliases(); err != nil {
+		t.Fatalf("VerifyAliases: %v", err)
+	}
+
+	am.AddAlias("hi", "echo it's me")
+	am.AddAlias("ll", "ls -la")
+	err := am.VerifyAliases()
+	var syntaxErr *AliasSyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("VerifyAliases error = %v, want an *AliasSyntaxError", err)
+	}
+	if syntaxErr.Shell != ShellBash || !slices.Equal(syntaxErr.Aliases, []string{"hi"}) || syntaxErr.Output == "" {
+		t.Errorf("AliasSyntaxError = %+v, want hi named with the bash output", syntaxErr)
+	}
+}
+
+func TestSyntaxCheckUnavailable(t *testing.T) {
+	var unavailable *SyntaxCheckUnavailableError
+	if _, err := CheckShellSyntax(ShellCmd, "doskey gs=git status\n"); !errors.As(err, &unavailable) || unavailable.Shell != ShellCmd {
+		t.Errorf("CheckShellSyntax(cmd) error = %v, want a *SyntaxCheckUnavailableError", err)
+	}
+
+	// No shell can be found without a PATH
+	t.Setenv("PATH", t.TempDir())
+	if _, err := CheckShellSyntax(ShellZsh, "alias gs='git status'\n"); !errors.As(err, &unavailable) || unavailable.Reason != "zsh is not installed" {
+		t.Errorf("CheckShellSyntax(zsh) error = %v, want zsh reported missing", err)
+	}
+}
@@ -1,6 +1,10 @@
 package aliasctl
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/aliasctl/aliasctl/pkg/aliasctl/ai"
 )
 
@@ -18,15 +22,108 @@ const (
 	ShellCmd            ShellType = "cmd"
 )
 
+// SupportedShells lists every shell type aliasctl can manage, in display order.
+var SupportedShells = []ShellType{ShellBash, ShellZsh, ShellFish, ShellKsh, ShellPowerShell, ShellPowerShellCore, ShellCmd}
+
+// ParseShellType converts a shell name into a supported ShellType.
+// Returns an error listing the supported shells if the name is not recognized.
+func ParseShellType(name string) (ShellType, error) {
+	for _, shell := range SupportedShells {
+		if string(shell) == name {
+			return shell, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported shell: %s (supported shells: bash, zsh, fish, ksh, powershell, pwsh, cmd)", name)
+}
+
 // AliasCommands holds the commands for all supported shells.
 type AliasCommands struct {
-	Bash           string `json:"bash"`
-	Zsh            string `json:"zsh"`
-	Fish           string `json:"fish"`
-	Ksh            string `json:"ksh"`
-	PowerShell     string `json:"powershell"`
-	PowerShellCore string `json:"pwsh"`
-	Cmd            string `json:"cmd"`
+	Bash           string `json:"bash" yaml:"bash"`
+	Zsh            string `json:"zsh" yaml:"zsh"`
+	Fish           string `json:"fish" yaml:"fish"`
+	Ksh            string `json:"ksh" yaml:"ksh"`
+	PowerShell     string `json:"powershell" yaml:"powershell"`
+	PowerShellCore string `json:"pwsh" yaml:"pwsh"`
+	Cmd            string `json:"cmd" yaml:"cmd"`
+	Description    string `json:"description,omitempty" yaml:"description,omitempty" toml:",omitempty"`   // Optional human-readable description
+	AIGenerated    string `json:"ai_generated,omitempty" yaml:"ai_generated,omitempty" toml:",omitempty"` // Comma-separated shells whose command was generated by AI
+}
+
+// AliasListSchemaVersion is the version of the AliasList schema used for structured output.
+// It is incremented whenever a field is removed or changes meaning.
+const AliasListSchemaVersion = 1
+
+// AliasList is the structured result of listing aliases.
+type AliasList struct {
+	SchemaVersion int          `json:"schema_version" yaml:"schema_version" toml:"schema_version"`    // The version of this schema
+	Platform      string       `json:"platform" yaml:"platform" toml:"platform"`                      // The operating system platform
+	Shell         ShellType    `json:"shell,omitempty" yaml:"shell,omitempty" toml:"shell,omitempty"` // The shell filter, empty when listing all shells
+	Aliases       []AliasEntry `json:"aliases" yaml:"aliases" toml:"aliases"`                         // The matching aliases, sorted by name
+}
+
+// AliasEntry describes a single alias in structured output.
+type AliasEntry struct {
+	Name        string            `json:"name" yaml:"name" toml:"name"`                                                       // The alias name
+	Command     string            `json:"command" yaml:"command" toml:"command"`                                              // The command for the listed shell
+	Commands    map[string]string `json:"commands" yaml:"commands" toml:"commands"`                                           // Every defined command keyed by shell type
+	Description string            `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`    // Optional description
+	Source      string            `json:"source" yaml:"source" toml:"source"`                                                 // The layer the alias comes from: personal or bundle:<name>
+	AIGenerated []string          `json:"ai_generated,omitempty" yaml:"ai_generated,omitempty" toml:"ai_generated,omitempty"` // Shells whose command was generated by AI
+}
+
+// ProviderDetails describes a configured AI provider in structured output.
+type ProviderDetails struct {
+	Name     string `json:"name" yaml:"name" toml:"name"`             // The name the provider is registered under
+	Type     string `json:"type" yaml:"type" toml:"type"`             // The provider implementation type
+	Endpoint string `json:"endpoint" yaml:"endpoint" toml:"endpoint"` // The provider endpoint URL
+	Model    string `json:"model" yaml:"model" toml:"model"`          // The model used for requests
+	Timeout  string `json:"timeout" yaml:"timeout" toml:"timeout"`    // The time allowed for a request
+	Default  bool   `json:"default" yaml:"default" toml:"default"`    // Whether this is the default provider
+	Fallback int    `json:"fallback" yaml:"fallback" toml:"fallback"` // The provider's place in the fallback chain, 0 if not in it
+}
+
+// ProviderCheck is the outcome of checking a configured AI provider in structured output.
+type ProviderCheck struct {
+	Name     string `json:"name" yaml:"name" toml:"name"`             // The name the provider is registered under
+	Type     string `json:"type" yaml:"type" toml:"type"`             // The provider implementation type
+	Endpoint string `json:"endpoint" yaml:"endpoint" toml:"endpoint"` // The provider endpoint URL
+	Model    string `json:"model" yaml:"model" toml:"model"`          // The model used for requests
+	Status   string `json:"status" yaml:"status" toml:"status"`       // CheckOK or CheckError
+	Message  string `json:"message" yaml:"message" toml:"message"`    // What was found, or why the check failed
+	Models   int    `json:"models" yaml:"models" toml:"models"`       // The number of models the provider lists, 0 if it was not asked
+	Latency  string `json:"latency" yaml:"latency" toml:"latency"`    // How long the provider took to answer, empty if it was not asked
+}
+
+// AIUsageEntry is the token usage of a group of AI requests in structured output, see
+// AIUsage. Only the fields the requests are grouped by are set.
+type AIUsageEntry struct {
+	Day          string  `json:"day,omitempty" yaml:"day,omitempty" toml:"day,omitempty"`                // The day, as 2006-01-02
+	Command      string  `json:"command,omitempty" yaml:"command,omitempty" toml:"command,omitempty"`    // The command, such as generate
+	Provider     string  `json:"provider,omitempty" yaml:"provider,omitempty" toml:"provider,omitempty"` // The name of the provider
+	Model        string  `json:"model,omitempty" yaml:"model,omitempty" toml:"model,omitempty"`          // The model that answered
+	Requests     int     `json:"requests" yaml:"requests" toml:"requests"`                               // The number of requests answered
+	InputTokens  int     `json:"input_tokens" yaml:"input_tokens" toml:"input_tokens"`                   // The input tokens of the requests
+	OutputTokens int     `json:"output_tokens" yaml:"output_tokens" toml:"output_tokens"`                // The output tokens of the requests
+	Cost         float64 `json:"cost" yaml:"cost" toml:"cost"`                                           // The estimated cost in dollars
+}
+
+// AIUsageReport is the token usage and estimated cost of the AI requests of a month.
+type AIUsageReport struct {
+	Month    string         `json:"month" yaml:"month" toml:"month"`                                        // The month, as 2006-01
+	Entries  []AIUsageEntry `json:"entries" yaml:"entries" toml:"entries"`                                  // The usage of each group of requests
+	Total    AIUsageEntry   `json:"total" yaml:"total" toml:"total"`                                        // The usage of all requests
+	Budget   float64        `json:"budget" yaml:"budget" toml:"budget"`                                     // The monthly budget in dollars, zero for none
+	Unpriced []string       `json:"unpriced,omitempty" yaml:"unpriced,omitempty" toml:"unpriced,omitempty"` // Models used without a price, which count as free
+}
+
+// ShellInfo describes the detected shell environment in structured output.
+type ShellInfo struct {
+	Shell           ShellType `json:"shell" yaml:"shell" toml:"shell"`                                     // The configured shell type
+	Platform        string    `json:"platform" yaml:"platform" toml:"platform"`                            // The operating system platform
+	AliasFile       string    `json:"alias_file" yaml:"alias_file" toml:"alias_file"`                      // The shell file aliases are applied to
+	AliasFileExists bool      `json:"alias_file_exists" yaml:"alias_file_exists" toml:"alias_file_exists"` // Whether the alias file exists
+	ConfigDir       string    `json:"config_dir" yaml:"config_dir" toml:"config_dir"`                      // The aliasctl configuration directory
+	ConfigDirExists bool      `json:"config_dir_exists" yaml:"config_dir_exists" toml:"config_dir_exists"` // Whether the configuration directory exists
 }
 
 // AliasManager handles platform-specific alias operations.
@@ -42,25 +139,74 @@ type AliasManager struct {
 	ConfigFile     string                   // The path to the configuration file
 	EncryptionKey  string                   // The path to the encryption key file
 	EncryptionUsed bool                     // Whether encryption is being used
+	savedAliases   map[string]AliasCommands // The aliases as last loaded or saved, for the journal
+	operation      *JournalEntry            // The operation label for the next save
+	bundleLayers   []bundleLayer            // Read-only alias layers from subscribed bundles
+
+	TrustedBundleKeys    []string // Public keys trusted to sign bundles
+	RequireSignedBundles bool     // Whether unsigned bundles are refused
+	AISafetyPolicy       string   // Whether risky AI results are blocked or only warned about
+	AINamingConventions  string   // The team's alias naming conventions, given to AI providers
+
+	Warnings []error // Problems that did not stop an operation, such as a JournalError
+
+	aiKeyRefs    map[string]string         // API key references by provider name, see ProviderConfig.APIKeyRef
+	aiUnloaded   map[string]ProviderConfig // Saved AI providers that failed to load, kept when saving
+	aiLoadErrors map[string]error          // Why each provider in aiUnloaded failed to load
 }
 
 // Config represents the application configuration.
+// AI providers are saved in Providers; the flat provider fields are only read, to
+// migrate configurations written before providers were saved as tables.
 type Config struct {
-	DefaultShell          ShellType       `json:"default_shell"`           // The default shell type
-	DefaultAliasFile      string          `json:"default_alias_file"`      // The default alias file path
-	AIProvider            string          `json:"ai_provider"`             // The default AI provider type
-	AIProviders           map[string]bool `json:"ai_providers"`            // Map of configured AI providers
-	OllamaEndpoint        string          `json:"ollama_endpoint"`         // The Ollama endpoint URL
-	OllamaModel           string          `json:"ollama_model"`            // The Ollama model name
-	OpenAIEndpoint        string          `json:"openai_endpoint"`         // The OpenAI endpoint URL
-	OpenAIKey             string          `json:"openai_key"`              // The OpenAI API key (plaintext, deprecated)
-	OpenAIKeyEncrypted    string          `json:"openai_key_encrypted"`    // The OpenAI API key (encrypted)
-	OpenAIModel           string          `json:"openai_model"`            // The OpenAI model name
-	AnthropicEndpoint     string          `json:"anthropic_endpoint"`      // The Anthropic endpoint URL
-	AnthropicKey          string          `json:"anthropic_key"`           // The Anthropic API key (plaintext, deprecated)
-	AnthropicKeyEncrypted string          `json:"anthropic_key_encrypted"` // The Anthropic API key (encrypted)
-	AnthropicModel        string          `json:"anthropic_model"`         // The Anthropic model name
-	UseEncryption         bool            `json:"use_encryption"`          // Whether to use encryption for API keys
+	DefaultShell          ShellType                 `json:"default_shell"`                                                  // The default shell type
+	DefaultAliasFile      string                    `json:"default_alias_file"`                                             // The default alias file path
+	AIProvider            string                    `json:"ai_provider"`                                                    // The name of the default AI provider
+	AIProviders           map[string]bool           `json:"ai_providers"`                                                   // Map of configured AI providers
+	OllamaEndpoint        string                    `json:"ollama_endpoint" toml:"OllamaEndpoint,omitempty"`                // The Ollama endpoint URL (deprecated)
+	OllamaModel           string                    `json:"ollama_model" toml:"OllamaModel,omitempty"`                      // The Ollama model name (deprecated)
+	OllamaTimeout         string                    `json:"ollama_timeout" toml:"OllamaTimeout,omitempty"`                  // The Ollama request timeout (deprecated)
+	OpenAIEndpoint        string                    `json:"openai_endpoint" toml:"OpenAIEndpoint,omitempty"`                // The OpenAI endpoint URL (deprecated)
+	OpenAIKey             string                    `json:"openai_key" toml:"OpenAIKey,omitempty"`                          // The OpenAI API key (plaintext, deprecated)
+	OpenAIKeyEncrypted    string                    `json:"openai_key_encrypted" toml:"OpenAIKeyEncrypted,omitempty"`       // The OpenAI API key (encrypted, deprecated)
+	OpenAIModel           string                    `json:"openai_model" toml:"OpenAIModel,omitempty"`                      // The OpenAI model name (deprecated)
+	OpenAITimeout         string                    `json:"openai_timeout" toml:"OpenAITimeout,omitempty"`                  // The OpenAI request timeout (deprecated)
+	AnthropicEndpoint     string                    `json:"anthropic_endpoint" toml:"AnthropicEndpoint,omitempty"`          // The Anthropic endpoint URL (deprecated)
+	AnthropicKey          string                    `json:"anthropic_key" toml:"AnthropicKey,omitempty"`                    // The Anthropic API key (plaintext, deprecated)
+	AnthropicKeyEncrypted string                    `json:"anthropic_key_encrypted" toml:"AnthropicKeyEncrypted,omitempty"` // The Anthropic API key (encrypted, deprecated)
+	AnthropicModel        string                    `json:"anthropic_model" toml:"AnthropicModel,omitempty"`                // The Anthropic model name (deprecated)
+	AnthropicTimeout      string                    `json:"anthropic_timeout" toml:"AnthropicTimeout,omitempty"`            // The Anthropic request timeout (deprecated)
+	UseEncryption         bool                      `json:"use_encryption"`                                                 // Whether to use encryption for API keys
+	TrustedBundleKeys     []string                  `json:"trusted_bundle_keys"`                                            // Minisign or SSH public keys trusted to sign bundles
+	RequireSignedBundles  bool                      `json:"require_signed_bundles"`                                         // Whether unsigned bundles are refused
+	AISafetyPolicy        string                    `json:"ai_safety_policy"`                                               // "warn" (default) or "block" for risky AI results
+	AIFallbackChain       []string                  `json:"ai_fallback_chain"`                                              // Providers tried in order until one answers
+	AICacheTTL            string                    `json:"ai_cache_ttl"`                                                   // How long cached AI answers are reused, such as "720h"
+	AICacheMaxSize        int64                     `json:"ai_cache_max_size"`                                              // The size limit of the AI answer cache in bytes
+	AINamingConventions   string                    `json:"ai_naming_conventions"`                                          // Alias naming rules added to AI prompts
+	AIMonthlyBudget       float64                   `json:"ai_monthly_budget"`                                              // The monthly budget for hosted AI providers in dollars, zero for none
+	AIPrices              map[string]AIPrice        `json:"ai_prices"`                                                      // The price of each model, by model name
+	Providers             map[string]ProviderConfig `json:"providers" toml:"providers"`                                     // The AI providers, by name
+}
+
+// ProviderConfig is the saved configuration of an AI provider. Several providers may
+// share a type, such as an OpenAI-compatible gateway next to api.openai.com.
+// The API key is given by at most one of APIKey, APIKeyEncrypted and APIKeyRef.
+type ProviderConfig struct {
+	Type            string            `json:"type" toml:"type"`                                               // The provider type, see AIProviderTypes; the provider name if empty
+	Endpoint        string            `json:"endpoint" toml:"endpoint"`                                       // The endpoint URL
+	Model           string            `json:"model" toml:"model"`                                             // The model name
+	APIKey          string            `json:"api_key,omitempty" toml:"api_key,omitempty"`                     // The API key (plaintext)
+	APIKeyEncrypted string            `json:"api_key_encrypted,omitempty" toml:"api_key_encrypted,omitempty"` // The API key (encrypted)
+	APIKeyRef       string            `json:"api_key_ref,omitempty" toml:"api_key_ref,omitempty"`             // Where the API key is read from, "env:NAME" or "file:PATH"
+	Timeout         string            `json:"timeout,omitempty" toml:"timeout,omitempty"`                     // The request timeout, such as "30s"
+	Headers         map[string]string `json:"headers,omitempty" toml:"headers,omitempty"`                     // Extra headers sent with every request
+	Proxy           string            `json:"proxy,omitempty" toml:"proxy,omitempty"`                         // The proxy URL, "none" to ignore HTTPS_PROXY; the environment's proxy if empty
+	CACert          string            `json:"ca_cert,omitempty" toml:"ca_cert,omitempty"`                     // A PEM bundle of certificate authorities trusted besides the system's
+	ClientCert      string            `json:"client_cert,omitempty" toml:"client_cert,omitempty"`             // The PEM client certificate, for endpoints that require one
+	ClientKey       string            `json:"client_key,omitempty" toml:"client_key,omitempty"`               // The PEM private key of the client certificate
+	TLSMinVersion   string            `json:"tls_min_version,omitempty" toml:"tls_min_version,omitempty"`     // The lowest TLS version accepted, such as "1.2"
+	Options         map[string]string `json:"options,omitempty" toml:"options,omitempty"`                     // Settings specific to the provider type
 }
 
 // AIProvider interface for AI services.
@@ -88,3 +234,64 @@ type AnthropicProvider struct {
 	APIKey   string // The Anthropic API key
 	Model    string // The Anthropic model name
 }
+
+// ForShell returns the command stored for the given shell type.
+// It returns an empty string if no command is defined for that shell.
+func (c AliasCommands) ForShell(shell ShellType) string {
+	switch shell {
+	case ShellBash:
+		return c.Bash
+	case ShellZsh:
+		return c.Zsh
+	case ShellFish:
+		return c.Fish
+	case ShellKsh:
+		return c.Ksh
+	case ShellPowerShell:
+		return c.PowerShell
+	case ShellPowerShellCore:
+		return c.PowerShellCore
+	case ShellCmd:
+		return c.Cmd
+	}
+	return ""
+}
+
+// AIGeneratedShells returns the shells whose command was generated by AI.
+func (c AliasCommands) AIGeneratedShells() []string {
+	if c.AIGenerated == "" {
+		return nil
+	}
+	return strings.Split(c.AIGenerated, ",")
+}
+
+// IsAIGenerated reports whether the command for the given shell was generated by AI.
+func (c AliasCommands) IsAIGenerated(shell ShellType) bool {
+	return slices.Contains(c.AIGeneratedShells(), string(shell))
+}
+
+// markAIGenerated records whether the command for the given shell was generated by AI.
+func (c *AliasCommands) markAIGenerated(shell ShellType, generated bool) {
+	var shells []string
+	for _, existing := range c.AIGeneratedShells() {
+		if existing != string(shell) {
+			shells = append(shells, existing)
+		}
+	}
+	if generated {
+		shells = append(shells, string(shell))
+		slices.Sort(shells)
+	}
+	c.AIGenerated = strings.Join(shells, ",")
+}
+
+// Shells returns every non-empty command keyed by shell type.
+func (c AliasCommands) Shells() map[string]string {
+	shells := make(map[string]string)
+	for _, shell := range SupportedShells {
+		if command := c.ForShell(shell); command != "" {
+			shells[string(shell)] = command
+		}
+	}
+	return shells
+}
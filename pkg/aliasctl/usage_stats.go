@@ -0,0 +1,215 @@
+package aliasctl
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// AliasUsage reports how often and how recently an alias appears in shell history.
+type AliasUsage struct {
+	Name     string     `json:"name" yaml:"name" toml:"name"`                                              // The alias name
+	Command  string     `json:"command" yaml:"command" toml:"command"`                                     // The command for the current shell
+	Count    int        `json:"count" yaml:"count" toml:"count"`                                           // How many times the alias was used
+	LastUsed *time.Time `json:"last_used,omitempty" yaml:"last_used,omitempty" toml:"last_used,omitempty"` // When the alias was last used, if history has timestamps
+}
+
+// AliasUsageStats cross-references the aliases of the current shell against history.
+// An alias counts as used when it is the first word of a command, including commands
+// chained with pipes, && or ; and commands prefixed with sudo or variable assignments.
+// Results are ordered by descending use count, then by name.
+func (am *AliasManager) AliasUsageStats(history []HistoryEntry) []AliasUsage {
+	usage := make(map[string]*AliasUsage)
+	for _, name := range am.sortedAliasNames() {
+		if command := am.Aliases[name].ForShell(am.Shell); command != "" {
+			usage[name] = &AliasUsage{Name: name, Command: command}
+		}
+	}
+
+	for _, entry := range history {
+		for _, word := range commandWords(entry.Command) {
+			stats, ok := usage[word]
+			if !ok {
+				continue
+			}
+			stats.Count++
+			if !entry.Time.IsZero() && (stats.LastUsed == nil || entry.Time.After(*stats.LastUsed)) {
+				lastUsed := entry.Time
+				stats.LastUsed = &lastUsed
+			}
+		}
+	}
+
+	results := make([]AliasUsage, 0, len(usage))
+	for _, stats := range usage {
+		results = append(results, *stats)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// commandWords returns the command name of every simple command in a command line.
+func commandWords(line string) []string {
+	var words []string
+	expectCommand := true
+	for _, field := range strings.Fields(line) {
+		if commandSeparators[field] {
+			expectCommand = true
+			continue
+		}
+		trimmed := strings.TrimRight(field, ";|&")
+		if !expectCommand {
+			if trimmed != field {
+				expectCommand = true
+			}
+			continue
+		}
+		if trimmed == "sudo" || trimmed == "time" || trimmed == "nohup" || strings.Contains(trimmed, "=") {
+			continue
+		}
+		words = append(words, strings.TrimLeft(trimmed, "({"))
+		expectCommand = trimmed != field
+	}
+	return words
+}
+
+// StaleAliases selects the aliases not used within the given number of days.
+// Aliases never seen in history are always stale. Aliases seen without timestamps
+// are considered in use, since their last use cannot be determined.
+func StaleAliases(usage []AliasUsage, days int, now time.Time) []AliasUsage {
+	cutoff := now.AddDate(0, 0, -days)
+	var stale []AliasUsage
+	for _, stats := range usage {
+		if stats.Count == 0 || (stats.LastUsed != nil && stats.LastUsed.Before(cutoff)) {
+			stale = append(stale, stats)
+		}
+	}
+	return stale
+}
+
+// pruneBackupDir returns the directory holding backups of pruned aliases.
+func (am *AliasManager) pruneBackupDir() string {
+	return filepath.Join(am.ConfigDir, "pruned")
+}
+
+// PruneAliases removes the named aliases and saves the alias store.
+// Before anything is removed, the aliases are written to a timestamped backup in the
+// config directory so the prune can be undone with RestorePrunedAliases.
+// Returns the path of the backup file.
+func (am *AliasManager) PruneAliases(names []string) (string, error) {
+	removed := make(map[string]AliasCommands)
+	for _, name := range names {
+		if commands, exists := am.Aliases[name]; exists {
+			removed[name] = commands
+		}
+	}
+	if len(removed) == 0 {
+		return "", fmt.Errorf("none of the selected aliases exist")
+	}
+
+	dir := am.pruneBackupDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory %s: %w (check directory permissions)", dir, err)
+	}
+
+	file, err := createPruneBackup(dir)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	backupFile := file.Name()
+
+	if err := toml.NewEncoder(file).Encode(removed); err != nil {
+		return "", fmt.Errorf("failed to write backup file %s: %w", backupFile, err)
+	}
+
+	for name := range removed {
+		am.RemoveAlias(name)
+	}
+	am.SetOperation(OpPrune, fmt.Sprintf("prune %d unused alias(es)", len(removed)))
+	if err := am.SaveAliases(); err != nil {
+		return backupFile, err
+	}
+	return backupFile, nil
+}
+
+// createPruneBackup creates a new backup file in dir, named after the current time so the
+// backups sort in the order they were made. The file is created exclusively, so a prune
+// made within the same clock tick as another gets a numbered name instead of overwriting
+// the earlier backup.
+func createPruneBackup(dir string) (*os.File, error) {
+	stamp := time.Now().Format("20060102-150405.000000000")
+	for n := 0; ; n++ {
+		name := fmt.Sprintf("pruned-%s.toml", stamp)
+		if n > 0 {
+			name = fmt.Sprintf("pruned-%s-%d.toml", stamp, n)
+		}
+		backupFile := filepath.Join(dir, name)
+		file, err := os.OpenFile(backupFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create backup file %s: %w (check file permissions)", backupFile, err)
+		}
+		return file, nil
+	}
+}
+
+// LatestPruneBackup returns the most recent backup written by PruneAliases.
+// Returns an error if no backup exists.
+func (am *AliasManager) LatestPruneBackup() (string, error) {
+	matches, err := filepath.Glob(filepath.Join(am.pruneBackupDir(), "pruned-*.toml"))
+	if err != nil || len(matches) == 0 {
+		return "", fmt.Errorf("no pruned aliases to restore in %s", am.pruneBackupDir())
+	}
+	// Compare the names without the extension so a numbered backup sorts after the one
+	// made in the same clock tick before it.
+	sort.Slice(matches, func(i, j int) bool {
+		return strings.TrimSuffix(matches[i], ".toml") < strings.TrimSuffix(matches[j], ".toml")
+	})
+	return matches[len(matches)-1], nil
+}
+
+// RestorePrunedAliases restores the aliases saved in a prune backup file.
+// Aliases that have been redefined since the prune are left untouched.
+// The backup file is removed once the aliases are saved.
+// Returns the names of the restored and skipped aliases.
+func (am *AliasManager) RestorePrunedAliases(backupFile string) (restored, skipped []string, err error) {
+	var backup map[string]AliasCommands
+	if _, err := toml.DecodeFile(backupFile, &backup); err != nil {
+		return nil, nil, fmt.Errorf("failed to read backup file %s: %w", backupFile, err)
+	}
+
+	for name, commands := range backup {
+		if _, exists := am.Aliases[name]; exists {
+			skipped = append(skipped, name)
+			continue
+		}
+		am.Aliases[name] = commands
+		restored = append(restored, name)
+	}
+	sort.Strings(restored)
+	sort.Strings(skipped)
+
+	am.SetOperation(OpRestore, fmt.Sprintf("restore from %s", filepath.Base(backupFile)))
+	if err := am.SaveAliases(); err != nil {
+		return nil, nil, err
+	}
+	if err := os.Remove(backupFile); err != nil {
+		return restored, skipped, fmt.Errorf("aliases restored but failed to remove backup file %s: %w", backupFile, err)
+	}
+	return restored, skipped, nil
+}
@@ -0,0 +1,44 @@
+package aliasctl
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPruneAliasesKeepsEveryBackup(t *testing.T) {
+	am := newTestManager(t)
+	am.AddAlias("a", "echo a")
+	am.AddAlias("b", "echo b")
+
+	first, err := am.PruneAliases([]string{"a"})
+	if err != nil {
+		t.Fatalf("PruneAliases(a): %v", err)
+	}
+	second, err := am.PruneAliases([]string{"b"})
+	if err != nil {
+		t.Fatalf("PruneAliases(b): %v", err)
+	}
+	if first == second {
+		t.Fatalf("both prunes wrote to %s", first)
+	}
+	for _, backup := range []string{first, second} {
+		if _, err := os.Stat(backup); err != nil {
+			t.Errorf("backup %s: %v", backup, err)
+		}
+	}
+
+	latest, err := am.LatestPruneBackup()
+	if err != nil {
+		t.Fatalf("LatestPruneBackup: %v", err)
+	}
+	if latest != second {
+		t.Errorf("LatestPruneBackup = %s, want %s", latest, second)
+	}
+	restored, _, err := am.RestorePrunedAliases(latest)
+	if err != nil {
+		t.Fatalf("RestorePrunedAliases: %v", err)
+	}
+	if len(restored) != 1 || restored[0] != "b" {
+		t.Errorf("restored %v, want [b]", restored)
+	}
+}